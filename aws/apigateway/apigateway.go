@@ -62,6 +62,8 @@ func ProxyResponse(status int, headers http.Header, body []byte) events.APIGatew
 // Binary Media Types to "*/*". This is not necessary for HTTP APIs.
 //
 // If headers is nil or empty, DefaultHTTPHeaders() will be used.
+//
+// Use SetV2HTTPResponseCookies to add cookies to the response.
 func V2HTTPResponse(status int, headers http.Header, body []byte) events.APIGatewayV2HTTPResponse {
 	// determine HTTP headers to use; use DefaultHTTPHeaders if user input is
 	// nil or empty
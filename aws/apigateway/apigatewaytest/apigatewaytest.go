@@ -0,0 +1,543 @@
+// Package apigatewaytest implements utilities for testing Lambda handlers that
+// serve API Gateway events over real HTTP
+package apigatewaytest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Request context values used when building events. They are fixed so that
+// golden tests produce stable output for everything except request ids and
+// timestamps.
+const (
+	AccountID  = "123456789012"
+	APIID      = "1234567890"
+	ResourceID = "abcdef"
+)
+
+// internalServerErrorBody is the body API Gateway returns when the integration
+// fails.
+const internalServerErrorBody = `{"message":"Internal Server Error"}`
+
+// ProxyHandler is the signature of a Lambda handler for API Gateway REST APIs
+// (payload format version 1.0).
+type ProxyHandler func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// V2HTTPHandler is the signature of a Lambda handler for API Gateway HTTP APIs
+// (payload format version 2.0).
+type V2HTTPHandler func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error)
+
+type Option struct {
+	stage            string
+	stageVariables   map[string]string
+	binaryMediaTypes []string
+	now              func() time.Time
+}
+
+// WithStage sets the stage name reported in the request context. The default
+// is "test" for REST APIs and "$default" for HTTP APIs.
+func WithStage(s string) func(o *Option) {
+	return func(o *Option) {
+		o.stage = s
+	}
+}
+
+// WithStageVariables sets the stage variables passed to the handler.
+func WithStageVariables(v map[string]string) func(o *Option) {
+	return func(o *Option) {
+		o.stageVariables = v
+	}
+}
+
+// WithBinaryMediaTypes sets the Binary Media Types of a REST API. Request
+// bodies with a matching Content-Type are base64 encoded. The default is none,
+// matching a newly created REST API. Use "*/*" to treat every body as binary.
+//
+// HTTP APIs do not use Binary Media Types and ignore this option.
+func WithBinaryMediaTypes(t ...string) func(o *Option) {
+	return func(o *Option) {
+		o.binaryMediaTypes = t
+	}
+}
+
+// WithClock sets the function used to timestamp the request context.
+func WithClock(now func() time.Time) func(o *Option) {
+	return func(o *Option) {
+		o.now = now
+	}
+}
+
+func newOption(stage string, options ...func(*Option)) Option {
+	op := Option{
+		stage: stage,
+		now:   time.Now,
+	}
+
+	for _, o := range options {
+		o(&op)
+	}
+
+	return op
+}
+
+// NewServer starts and returns a new httptest.Server that converts each HTTP
+// request into an events.APIGatewayProxyRequest, invokes h, and writes the
+// response back the same way API Gateway REST APIs do.
+//
+// The caller should call Close when finished, to shut it down.
+func NewServer(h ProxyHandler, options ...func(*Option)) *httptest.Server {
+	return httptest.NewServer(NewProxyHTTPHandler(h, options...))
+}
+
+// NewV2Server starts and returns a new httptest.Server that converts each HTTP
+// request into an events.APIGatewayV2HTTPRequest, invokes h, and writes the
+// response back the same way API Gateway HTTP APIs do.
+//
+// The caller should call Close when finished, to shut it down.
+func NewV2Server(h V2HTTPHandler, options ...func(*Option)) *httptest.Server {
+	return httptest.NewServer(NewV2HTTPHandler(h, options...))
+}
+
+// NewProxyHTTPHandler returns a http.Handler that serves h as an API Gateway
+// REST API would. It is useful with httptest.NewRecorder when a listening
+// server is not needed.
+func NewProxyHTTPHandler(h ProxyHandler, options ...func(*Option)) http.Handler {
+	op := newOption("test", options...)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := newProxyRequest(r, op)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resp, err := h(r.Context(), req)
+		if err != nil {
+			writeInternalServerError(w)
+			return
+		}
+
+		if err := WriteProxyResponse(w, resp); err != nil {
+			writeBadGateway(w)
+		}
+	})
+}
+
+// NewV2HTTPHandler returns a http.Handler that serves h as an API Gateway
+// HTTP API would. It is useful with httptest.NewRecorder when a listening
+// server is not needed.
+func NewV2HTTPHandler(h V2HTTPHandler, options ...func(*Option)) http.Handler {
+	op := newOption("$default", options...)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := newV2HTTPRequest(r, op)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resp, err := h(r.Context(), req)
+		if err != nil {
+			writeInternalServerError(w)
+			return
+		}
+
+		if err := WriteV2HTTPResponse(w, resp); err != nil {
+			writeBadGateway(w)
+		}
+	})
+}
+
+// NewProxyRequest converts r into the event API Gateway REST APIs send to a
+// Lambda proxy integration on a greedy "/{proxy+}" resource.
+func NewProxyRequest(r *http.Request, options ...func(*Option)) (events.APIGatewayProxyRequest, error) {
+	return newProxyRequest(r, newOption("test", options...))
+}
+
+// NewV2HTTPRequest converts r into the event API Gateway HTTP APIs send to a
+// Lambda integration on the "$default" route.
+func NewV2HTTPRequest(r *http.Request, options ...func(*Option)) (events.APIGatewayV2HTTPRequest, error) {
+	return newV2HTTPRequest(r, newOption("$default", options...))
+}
+
+func newProxyRequest(r *http.Request, op Option) (events.APIGatewayProxyRequest, error) {
+	raw, err := readBody(r)
+	if err != nil {
+		return events.APIGatewayProxyRequest{}, err
+	}
+
+	isBinary := len(raw) > 0 && matchesMediaType(r.Header.Get("Content-Type"), op.binaryMediaTypes)
+	body, isBase64Encoded := encodeBody(raw, isBinary)
+
+	headers := make(map[string]string, len(r.Header))
+	multiValueHeaders := make(map[string][]string, len(r.Header))
+
+	for k, v := range requestHeaders(r) {
+		headers[k] = v[len(v)-1]
+		multiValueHeaders[k] = v
+	}
+
+	query := r.URL.Query()
+	queryStringParameters := make(map[string]string, len(query))
+	multiValueQueryStringParameters := make(map[string][]string, len(query))
+
+	for k, v := range query {
+		queryStringParameters[k] = v[len(v)-1]
+		multiValueQueryStringParameters[k] = v
+	}
+
+	now := op.now()
+	requestID := newRequestID()
+
+	return events.APIGatewayProxyRequest{
+		Resource:                        "/{proxy+}",
+		Path:                            r.URL.Path,
+		HTTPMethod:                      r.Method,
+		Headers:                         nilIfEmpty(headers),
+		MultiValueHeaders:               nilIfEmpty(multiValueHeaders),
+		QueryStringParameters:           nilIfEmpty(queryStringParameters),
+		MultiValueQueryStringParameters: nilIfEmpty(multiValueQueryStringParameters),
+		PathParameters: map[string]string{
+			"proxy": strings.TrimPrefix(r.URL.Path, "/"),
+		},
+		StageVariables: op.stageVariables,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			AccountID:         AccountID,
+			ResourceID:        ResourceID,
+			Stage:             op.stage,
+			DomainName:        host(r),
+			DomainPrefix:      domainPrefix(host(r)),
+			RequestID:         requestID,
+			ExtendedRequestID: requestID,
+			Protocol:          r.Proto,
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  sourceIP(r),
+				UserAgent: r.UserAgent(),
+			},
+			ResourcePath:     "/{proxy+}",
+			Path:             path.Join("/", op.stage, r.URL.Path),
+			HTTPMethod:       r.Method,
+			RequestTime:      now.UTC().Format("02/Jan/2006:15:04:05 -0700"),
+			RequestTimeEpoch: now.UnixMilli(),
+			APIID:            APIID,
+		},
+		Body:            body,
+		IsBase64Encoded: isBase64Encoded,
+	}, nil
+}
+
+func newV2HTTPRequest(r *http.Request, op Option) (events.APIGatewayV2HTTPRequest, error) {
+	raw, err := readBody(r)
+	if err != nil {
+		return events.APIGatewayV2HTTPRequest{}, err
+	}
+
+	isBinary := len(raw) > 0 && !IsTextMediaType(r.Header.Get("Content-Type"))
+	body, isBase64Encoded := encodeBody(raw, isBinary)
+
+	// HTTP APIs lower case header names, join repeated headers with a comma
+	// and move cookies into their own field
+	headers := make(map[string]string, len(r.Header))
+
+	var cookies []string
+
+	for k, v := range requestHeaders(r) {
+		if strings.EqualFold(k, "Cookie") {
+			for _, c := range v {
+				for _, p := range strings.Split(c, ";") {
+					if p = strings.TrimSpace(p); p != "" {
+						cookies = append(cookies, p)
+					}
+				}
+			}
+
+			continue
+		}
+
+		headers[strings.ToLower(k)] = strings.Join(v, ",")
+	}
+
+	query := r.URL.Query()
+	queryStringParameters := make(map[string]string, len(query))
+
+	for k, v := range query {
+		queryStringParameters[k] = strings.Join(v, ",")
+	}
+
+	now := op.now()
+
+	return events.APIGatewayV2HTTPRequest{
+		Version:               "2.0",
+		RouteKey:              "$default",
+		RawPath:               r.URL.EscapedPath(),
+		RawQueryString:        r.URL.RawQuery,
+		Cookies:               cookies,
+		Headers:               headers,
+		QueryStringParameters: nilIfEmpty(queryStringParameters),
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			RouteKey:     "$default",
+			AccountID:    AccountID,
+			Stage:        op.stage,
+			RequestID:    newRequestID(),
+			APIID:        APIID,
+			DomainName:   host(r),
+			DomainPrefix: domainPrefix(host(r)),
+			Time:         now.UTC().Format("02/Jan/2006:15:04:05 -0700"),
+			TimeEpoch:    now.UnixMilli(),
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Protocol:  r.Proto,
+				SourceIP:  sourceIP(r),
+				UserAgent: r.UserAgent(),
+			},
+		},
+		StageVariables:  op.stageVariables,
+		Body:            body,
+		IsBase64Encoded: isBase64Encoded,
+	}, nil
+}
+
+// WriteProxyResponse writes resp to w as API Gateway REST APIs do. Headers and
+// MultiValueHeaders are merged, with MultiValueHeaders taking precedence, and
+// the body is base64 decoded if IsBase64Encoded is set.
+func WriteProxyResponse(w http.ResponseWriter, resp events.APIGatewayProxyResponse) error {
+	body, err := ResponseBody(resp.IsBase64Encoded, resp.Body)
+	if err != nil {
+		return err
+	}
+
+	writeResponse(w, resp.StatusCode, resp.Headers, resp.MultiValueHeaders, nil, body)
+
+	return nil
+}
+
+// WriteV2HTTPResponse writes resp to w as API Gateway HTTP APIs do. Headers
+// and MultiValueHeaders are merged, with MultiValueHeaders taking precedence,
+// each of Cookies becomes a Set-Cookie header, and the body is base64 decoded
+// if IsBase64Encoded is set.
+func WriteV2HTTPResponse(w http.ResponseWriter, resp events.APIGatewayV2HTTPResponse) error {
+	body, err := ResponseBody(resp.IsBase64Encoded, resp.Body)
+	if err != nil {
+		return err
+	}
+
+	writeResponse(w, resp.StatusCode, resp.Headers, resp.MultiValueHeaders, resp.Cookies, body)
+
+	return nil
+}
+
+// ResponseBody returns the response body and properly base64 decodes it if
+// necessary.
+func ResponseBody(isBase64Encoded bool, body string) ([]byte, error) {
+	if isBase64Encoded {
+		data, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, fmt.Errorf("decode response body: %w", err)
+		}
+
+		return data, nil
+	}
+
+	return []byte(body), nil
+}
+
+// IsTextMediaType returns true if API Gateway HTTP APIs pass a request body
+// with the given Content-Type to Lambda as text rather than base64.
+func IsTextMediaType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	if strings.HasPrefix(mt, "text/") {
+		return true
+	}
+
+	switch mt {
+	case "application/json",
+		"application/javascript",
+		"application/xml",
+		"application/x-www-form-urlencoded":
+		return true
+	}
+
+	return strings.HasSuffix(mt, "+json") || strings.HasSuffix(mt, "+xml")
+}
+
+func writeResponse(w http.ResponseWriter, status int, headers map[string]string, multiValueHeaders map[string][]string, cookies []string, body []byte) {
+	for k, v := range headers {
+		w.Header().Set(k, v)
+	}
+
+	for k, v := range multiValueHeaders {
+		w.Header()[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+	}
+
+	for _, c := range cookies {
+		w.Header().Add("Set-Cookie", c)
+	}
+
+	// API Gateway treats a missing status code as an integration failure
+	if status == 0 {
+		writeBadGateway(w)
+		return
+	}
+
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+func writeInternalServerError(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	_, _ = io.WriteString(w, internalServerErrorBody)
+}
+
+func writeBadGateway(w http.ResponseWriter) {
+	for k := range w.Header() {
+		w.Header().Del(k)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadGateway)
+	_, _ = io.WriteString(w, internalServerErrorBody)
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+
+	defer r.Body.Close()
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+
+	return b, nil
+}
+
+func encodeBody(raw []byte, isBinary bool) (string, bool) {
+	if isBinary {
+		return base64.StdEncoding.EncodeToString(raw), true
+	}
+
+	return string(raw), false
+}
+
+// requestHeaders returns the request headers including Host, which net/http
+// removes from r.Header
+func requestHeaders(r *http.Request) http.Header {
+	h := r.Header.Clone()
+	if h == nil {
+		h = make(http.Header)
+	}
+
+	if r.Host != "" {
+		h.Set("Host", r.Host)
+	}
+
+	return h
+}
+
+func matchesMediaType(contentType string, binaryMediaTypes []string) bool {
+	if len(binaryMediaTypes) == 0 {
+		return false
+	}
+
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mt = ""
+	}
+
+	for _, b := range binaryMediaTypes {
+		if b == "*/*" {
+			return true
+		}
+
+		if mt == "" {
+			continue
+		}
+
+		if strings.HasSuffix(b, "/*") {
+			if strings.HasPrefix(mt, strings.TrimSuffix(b, "*")) {
+				return true
+			}
+
+			continue
+		}
+
+		if strings.EqualFold(b, mt) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func host(r *http.Request) string {
+	if r.Host != "" {
+		return r.Host
+	}
+
+	return r.URL.Host
+}
+
+func domainPrefix(h string) string {
+	if hostname, _, err := net.SplitHostPort(h); err == nil {
+		h = hostname
+	}
+
+	prefix, _, _ := strings.Cut(h, ".")
+
+	return prefix
+}
+
+func sourceIP(r *http.Request) string {
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return ip
+	}
+
+	return r.RemoteAddr
+}
+
+// newRequestID returns a random UUID formatted string
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	h := hex.EncodeToString(b)
+
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+func nilIfEmpty[M ~map[string]V, V any](m M) M {
+	if len(m) == 0 {
+		return nil
+	}
+
+	return m
+}
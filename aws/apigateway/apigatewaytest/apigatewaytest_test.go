@@ -0,0 +1,797 @@
+package apigatewaytest
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/Vitality-South/goutil/aws/apigateway"
+)
+
+// The events in testdata are real API Gateway, ALB and Function URL events
+// copied from the testdata of github.com/aws/aws-lambda-go/events under the
+// Apache License 2.0; see testdata/NOTICE and testdata/LICENSE.
+
+// dataObjects are the JSON objects of events whose keys are data, such as
+// header names, rather than field names
+var dataObjects = map[string]bool{
+	"headers":                         true,
+	"multiValueHeaders":               true,
+	"queryStringParameters":           true,
+	"multiValueQueryStringParameters": true,
+	"pathParameters":                  true,
+	"stageVariables":                  true,
+	"authorizer":                      true,
+}
+
+// jsonFields flattens the leaves of a decoded JSON value into their paths and
+// kinds, leaving out zero values unless zero is set. Keys of data objects are
+// replaced with "*".
+func jsonFields(prefix string, v any, zero bool, fields map[string]map[string]bool) {
+	switch x := v.(type) {
+	case map[string]any:
+		parent := prefix[strings.LastIndexByte(prefix, '.')+1:]
+
+		for k, c := range x {
+			if dataObjects[parent] {
+				k = "*"
+			}
+
+			if prefix != "" {
+				k = prefix + "." + k
+			}
+
+			jsonFields(k, c, zero, fields)
+		}
+	case []any:
+		for _, c := range x {
+			jsonFields(prefix+"[]", c, zero, fields)
+		}
+	case string:
+		if zero || x != "" {
+			addField(fields, prefix, "string")
+		}
+	case float64:
+		if zero || x != 0 {
+			addField(fields, prefix, "number")
+		}
+	case bool:
+		if zero || x {
+			addField(fields, prefix, "bool")
+		}
+	}
+}
+
+// addField records that there is a field of kind at path
+func addField(fields map[string]map[string]bool, path, kind string) {
+	if fields[path] == nil {
+		fields[path] = make(map[string]bool)
+	}
+
+	fields[path][kind] = true
+}
+
+// jsonValue returns the value at a dotted path of a decoded JSON object
+func jsonValue(v any, path string) (any, bool) {
+	for _, k := range strings.Split(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		if v, ok = m[k]; !ok {
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+// assertGolden fails the test if event has a field that the real event in
+// testdata/name does not have, or of a different kind, or if any of the
+// fields at paths differ between them
+func assertGolden(t *testing.T, name string, event any, paths ...string) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var golden, got any
+
+	if err := json.Unmarshal(data, &golden); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	goldenFields := make(map[string]map[string]bool)
+	gotFields := make(map[string]map[string]bool)
+
+	jsonFields("", golden, true, goldenFields)
+	jsonFields("", got, false, gotFields)
+
+	for path, kinds := range gotFields {
+		if goldenFields[path] == nil {
+			t.Errorf("%s: field %s is not in real events", name, path)
+			continue
+		}
+
+		for kind := range kinds {
+			if !goldenFields[path][kind] {
+				t.Errorf("%s: expected field %s to be a %v, got a %s", name, path, goldenFields[path], kind)
+			}
+		}
+	}
+
+	for _, path := range paths {
+		want, ok := jsonValue(golden, path)
+		if !ok {
+			t.Errorf("%s: field %s is not in the real event", name, path)
+			continue
+		}
+
+		if v, _ := jsonValue(got, path); !reflect.DeepEqual(v, want) {
+			t.Errorf("%s: expected %s %v, got %v", name, path, want, v)
+		}
+	}
+}
+
+func TestGoldenProxyRequest(t *testing.T) {
+	r, err := http.NewRequest(http.MethodPost, "http://localhost/hello/world?name=me", strings.NewReader("{\r\n\t\"a\": 1\r\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("User-Agent", "PostmanRuntime/2.4.5")
+	r.RemoteAddr = "192.168.196.186:41234"
+
+	event, err := NewProxyRequest(r,
+		WithStage("testStage"),
+		WithStageVariables(map[string]string{"stageVariableName": "stageVariableValue"}),
+		WithClock(func() time.Time { return time.UnixMilli(1589522469693) }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertGolden(t, "apigw-request.json", event,
+		"path", "httpMethod", "body",
+		"headers.Content-Type", "headers.User-Agent", "multiValueHeaders.User-Agent",
+		"queryStringParameters", "multiValueQueryStringParameters",
+		"stageVariables",
+		"requestContext.stage", "requestContext.protocol",
+		"requestContext.httpMethod",
+		"requestContext.requestTime", "requestContext.requestTimeEpoch",
+		"requestContext.identity.sourceIp", "requestContext.identity.userAgent",
+	)
+}
+
+func TestGoldenV2HTTPRequest(t *testing.T) {
+	r, err := http.NewRequest(http.MethodGet, "http://localhost/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Header.Set("Accept", "*/*")
+	r.Header.Set("User-Agent", "curl/7.58.0")
+	r.RemoteAddr = "1.2.3.4:41234"
+
+	event, err := NewV2HTTPRequest(r, WithClock(func() time.Time { return time.UnixMilli(1587481701067) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertGolden(t, "apigw-v2-request-no-authorizer.json", event,
+		"version", "routeKey", "rawQueryString", "isBase64Encoded",
+		"headers.accept", "headers.user-agent",
+		"requestContext.routeKey", "requestContext.stage",
+		"requestContext.time", "requestContext.timeEpoch",
+		"requestContext.http.method", "requestContext.http.sourceIp", "requestContext.http.userAgent",
+	)
+}
+
+func TestGoldenV1Request(t *testing.T) {
+	event := NewV1Request().
+		Method("POST").
+		Path("/hello/world?name=me").
+		Header("Content-Type", "application/json").
+		Header("User-Agent", "PostmanRuntime/2.4.5").
+		Stage("testStage").
+		StageVariable("stageVariableName", "stageVariableValue").
+		SourceIP("192.168.196.186").
+		Authorizer(map[string]interface{}{"principalId": "admin", "clientId": 1, "clientName": "Exata"}).
+		Time(time.UnixMilli(1589522469693)).
+		Body([]byte("{\r\n\t\"a\": 1\r\n}")).
+		Build()
+
+	assertGolden(t, "apigw-request.json", event,
+		"resource", "path", "httpMethod", "body",
+		"headers.Content-Type", "headers.User-Agent", "multiValueHeaders.User-Agent",
+		"queryStringParameters", "multiValueQueryStringParameters",
+		"pathParameters", "stageVariables",
+		"requestContext.stage", "requestContext.protocol", "requestContext.resourcePath",
+		"requestContext.httpMethod", "requestContext.authorizer",
+		"requestContext.requestTime", "requestContext.requestTimeEpoch",
+		"requestContext.identity.sourceIp", "requestContext.identity.userAgent",
+	)
+}
+
+func TestGoldenV2Request(t *testing.T) {
+	event := NewV2Request().
+		Header("Accept", "*/*").
+		Header("User-Agent", "curl/7.58.0").
+		SourceIP("1.2.3.4").
+		Time(time.UnixMilli(1587481701067)).
+		Build()
+
+	assertGolden(t, "apigw-v2-request-no-authorizer.json", event,
+		"version", "routeKey", "rawPath", "rawQueryString", "isBase64Encoded",
+		"headers.accept", "headers.user-agent",
+		"requestContext.routeKey", "requestContext.stage", "requestContext.http",
+		"requestContext.time", "requestContext.timeEpoch",
+	)
+}
+
+func TestGoldenWebsocketRequest(t *testing.T) {
+	message := NewWebsocketRequest().
+		Stage("prod").
+		SourceIP("49.105.91.154").
+		Time(time.UnixMilli(1705751700453)).
+		Body([]byte("{\r\n\t\"a\": 1\r\n}")).
+		Build()
+
+	assertGolden(t, "apigw-websocket-request-send-message.json", message,
+		"body", "isBase64Encoded",
+		"requestContext.routeKey", "requestContext.eventType", "requestContext.messageDirection",
+		"requestContext.stage", "requestContext.requestTime", "requestContext.requestTimeEpoch",
+		"requestContext.identity.sourceIp",
+	)
+
+	connect := NewWebsocketRequest().
+		EventType("connect").
+		Query("name", "me").
+		Header("User-Agent", "PostmanRuntime/2.4.5").
+		Build()
+
+	assertGolden(t, "apigw-websocket-request.json", connect,
+		"queryStringParameters", "multiValueQueryStringParameters",
+		"requestContext.routeKey", "requestContext.eventType", "requestContext.messageDirection",
+		"requestContext.identity.userAgent",
+	)
+}
+
+func TestGoldenALBRequest(t *testing.T) {
+	single := NewALBRequest().
+		Query("key", "hello").
+		Header("Accept", "*/*").
+		Header("User-Agent", "curl/7.54.0").
+		Build()
+
+	assertGolden(t, "alb-lambda-target-request-headers-only.json", single,
+		"httpMethod", "path", "body", "isBase64Encoded", "queryStringParameters",
+		"headers.accept", "headers.user-agent",
+	)
+
+	multi := NewALBRequest().
+		Query("key", "hello").
+		Header("Accept", "*/*").
+		Header("User-Agent", "curl/7.54.0").
+		MultiValue().
+		Build()
+
+	assertGolden(t, "alb-lambda-target-request-multivalue-headers.json", multi,
+		"httpMethod", "path", "body", "isBase64Encoded", "multiValueQueryStringParameters",
+		"multiValueHeaders.accept", "multiValueHeaders.user-agent",
+	)
+}
+
+func TestGoldenFunctionURLRequest(t *testing.T) {
+	event := NewFunctionURLRequest().
+		Method("POST").
+		Path("/my/path?parameter1=value1&parameter1=value2&parameter2=value").
+		Header("header1", "value1").
+		Header("header2", "value1").
+		Header("header2", "value2").
+		Header("User-Agent", "agent").
+		Cookie("cookie1", "a").
+		Cookie("cookie2", "b").
+		SourceIP("123.123.123.123").
+		Body([]byte("Hello from client!")).
+		Build()
+
+	assertGolden(t, "lambda-urls-request.json", event,
+		"version", "rawPath", "rawQueryString", "queryStringParameters",
+		"headers.header1", "headers.header2", "body", "isBase64Encoded",
+		"requestContext.http",
+	)
+
+	if want := []string{"cookie1=a", "cookie2=b"}; !slices.Equal(event.Cookies, want) {
+		t.Errorf("Expected cookies %q, got %q", want, event.Cookies)
+	}
+}
+
+func TestServer(t *testing.T) {
+	binary := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}
+	got := make(chan events.APIGatewayProxyRequest, 1)
+
+	s := NewServer(func(_ context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		got <- req
+
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusCreated,
+			Headers:    map[string]string{"Content-Type": "image/png", "X-Single": "one"},
+			MultiValueHeaders: map[string][]string{
+				"X-Multi":    {"a", "b"},
+				"Set-Cookie": {"session=abc; HttpOnly", "theme=dark"},
+			},
+			Body:            base64.StdEncoding.EncodeToString(binary),
+			IsBase64Encoded: true,
+		}, nil
+	}, WithBinaryMediaTypes("image/*"), WithStage("prod"))
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodPut, s.URL+"/images/1?tag=a&tag=b", bytes.NewReader(binary))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Content-Type", "image/png")
+	req.Header.Add("X-Repeated", "1")
+	req.Header.Add("X-Repeated", "2")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "xyz"})
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	event := <-got
+
+	if event.HTTPMethod != http.MethodPut || event.Path != "/images/1" || event.PathParameters["proxy"] != "images/1" {
+		t.Errorf("Expected PUT /images/1, got %s %s %v", event.HTTPMethod, event.Path, event.PathParameters)
+	}
+
+	if event.RequestContext.Stage != "prod" || event.RequestContext.Path != "/prod/images/1" {
+		t.Errorf("Expected stage prod, got %s %s", event.RequestContext.Stage, event.RequestContext.Path)
+	}
+
+	if !slices.Equal(event.MultiValueHeaders["X-Repeated"], []string{"1", "2"}) || event.Headers["X-Repeated"] != "2" {
+		t.Errorf("Expected repeated header, got %q and %q", event.MultiValueHeaders["X-Repeated"], event.Headers["X-Repeated"])
+	}
+
+	if !slices.Equal(event.MultiValueQueryStringParameters["tag"], []string{"a", "b"}) || event.QueryStringParameters["tag"] != "b" {
+		t.Errorf("Expected repeated query parameter, got %v", event.MultiValueQueryStringParameters)
+	}
+
+	if event.Headers["Cookie"] != "session=xyz" {
+		t.Errorf("Expected Cookie header, got %q", event.Headers["Cookie"])
+	}
+
+	if body, _ := base64.StdEncoding.DecodeString(event.Body); !event.IsBase64Encoded || !bytes.Equal(body, binary) {
+		t.Errorf("Expected base64 encoded binary body, got %v %q", event.IsBase64Encoded, event.Body)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, resp.StatusCode)
+	}
+
+	if got := resp.Header.Values("X-Multi"); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Expected multi-value header, got %q", got)
+	}
+
+	if got := resp.Header.Get("X-Single"); got != "one" {
+		t.Errorf("Expected header one, got %q", got)
+	}
+
+	if cookies := resp.Cookies(); len(cookies) != 2 || cookies[0].Name != "session" || !cookies[0].HttpOnly || cookies[1].Value != "dark" {
+		t.Errorf("Expected two cookies, got %v", cookies)
+	}
+
+	if body, _ := io.ReadAll(resp.Body); !bytes.Equal(body, binary) {
+		t.Errorf("Expected decoded binary body, got %q", body)
+	}
+}
+
+func TestV2Server(t *testing.T) {
+	got := make(chan events.APIGatewayV2HTTPRequest, 1)
+
+	s := NewV2Server(func(_ context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		got <- req
+
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode:        http.StatusOK,
+			MultiValueHeaders: map[string][]string{"X-Multi": {"a", "b"}},
+			Cookies:           []string{"a=1; Path=/", "b=2"},
+			Body:              base64.StdEncoding.EncodeToString([]byte("hello")),
+			IsBase64Encoded:   true,
+		}, nil
+	})
+	defer s.Close()
+
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/items?tag=a&tag=b", strings.NewReader(`{"name":"x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("X-Repeated", "1")
+	req.Header.Add("X-Repeated", "2")
+	req.AddCookie(&http.Cookie{Name: "c1", Value: "v1"})
+	req.AddCookie(&http.Cookie{Name: "c2", Value: "v2"})
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	event := <-got
+
+	if event.RawPath != "/items" || event.RawQueryString != "tag=a&tag=b" || event.QueryStringParameters["tag"] != "a,b" {
+		t.Errorf("Expected /items?tag=a&tag=b, got %s?%s %v", event.RawPath, event.RawQueryString, event.QueryStringParameters)
+	}
+
+	if event.Headers["x-repeated"] != "1,2" {
+		t.Errorf("Expected joined lower case header, got %v", event.Headers)
+	}
+
+	if _, ok := event.Headers["cookie"]; ok || !slices.Equal(event.Cookies, []string{"c1=v1", "c2=v2"}) {
+		t.Errorf("Expected cookies in their own field, got %q and %v", event.Cookies, event.Headers)
+	}
+
+	if event.IsBase64Encoded || event.Body != `{"name":"x"}` {
+		t.Errorf("Expected text body, got %v %q", event.IsBase64Encoded, event.Body)
+	}
+
+	if got := resp.Header.Values("X-Multi"); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Expected multi-value header, got %q", got)
+	}
+
+	if cookies := resp.Cookies(); len(cookies) != 2 || cookies[0].Name != "a" || cookies[1].Value != "2" {
+		t.Errorf("Expected two cookies, got %v", cookies)
+	}
+
+	if body, _ := io.ReadAll(resp.Body); string(body) != "hello" {
+		t.Errorf("Expected decoded body, got %q", body)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	testStructs := []struct {
+		Response events.APIGatewayProxyResponse
+		Err      error
+		Expected int
+	}{
+		{Err: errors.New("failed"), Expected: http.StatusInternalServerError},
+		{Response: events.APIGatewayProxyResponse{}, Expected: http.StatusBadGateway},
+		{Response: events.APIGatewayProxyResponse{StatusCode: 200, Body: "!", IsBase64Encoded: true}, Expected: http.StatusBadGateway},
+	}
+
+	for i, testStruct := range testStructs {
+		s := NewServer(func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			return testStruct.Response, testStruct.Err
+		})
+
+		resp, err := http.Get(s.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		s.Close()
+
+		if resp.StatusCode != testStruct.Expected || string(body) != internalServerErrorBody {
+			t.Errorf("Expected %d %s, got %d %s on iteration %d", testStruct.Expected, internalServerErrorBody, resp.StatusCode, body, i)
+		}
+	}
+}
+
+func TestIsTextMediaType(t *testing.T) {
+	testStructs := []struct {
+		Input    string
+		Expected bool
+	}{
+		{Input: "", Expected: true},
+		{Input: "text/html; charset=utf-8", Expected: true},
+		{Input: "application/json", Expected: true},
+		{Input: "application/vnd.api+json", Expected: true},
+		{Input: "application/x-www-form-urlencoded", Expected: true},
+		{Input: "application/octet-stream", Expected: false},
+		{Input: "image/png", Expected: false},
+		{Input: "not a media type", Expected: false},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := IsTextMediaType(testStruct.Input); got != testStruct.Expected {
+			t.Errorf("Expected %v, got %v on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+}
+
+func TestRequestBuilder(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	v1 := NewV1Request().
+		Method("post").
+		Path("/users/42?expand=true").
+		Query("expand", "roles").
+		Cookie("session", "abc").
+		JSONBody(map[string]int{"a": 1}).
+		Time(at).
+		Build()
+
+	if v1.HTTPMethod != http.MethodPost || v1.Path != "/users/42" || v1.PathParameters["proxy"] != "users/42" {
+		t.Errorf("Expected POST /users/42, got %s %s %v", v1.HTTPMethod, v1.Path, v1.PathParameters)
+	}
+
+	if !slices.Equal(v1.MultiValueQueryStringParameters["expand"], []string{"true", "roles"}) {
+		t.Errorf("Expected query from path and Query, got %v", v1.MultiValueQueryStringParameters)
+	}
+
+	if v1.Headers["Cookie"] != "session=abc" || v1.Headers["Content-Type"] != "application/json" || v1.Headers["User-Agent"] != UserAgent {
+		t.Errorf("Expected cookie, content type and user agent headers, got %v", v1.Headers)
+	}
+
+	if v1.Body != `{"a":1}` || v1.IsBase64Encoded {
+		t.Errorf("Expected JSON body, got %q", v1.Body)
+	}
+
+	if v1.RequestContext.RequestTime != "01/Mar/2024:12:30:00 +0000" || v1.RequestContext.RequestTimeEpoch != at.UnixMilli() {
+		t.Errorf("Expected request time %v, got %s", at, v1.RequestContext.RequestTime)
+	}
+
+	if v1.RequestContext.Stage != "test" || v1.RequestContext.Path != "/test/users/42" || v1.RequestContext.RequestID == "" {
+		t.Errorf("Expected default stage and a request id, got %+v", v1.RequestContext)
+	}
+
+	withParams := NewV1Request().PathParameter("id", "42").Body([]byte{0xff}).Base64().Build()
+
+	if len(withParams.PathParameters) != 1 || withParams.PathParameters["id"] != "42" {
+		t.Errorf("Expected only the id path parameter, got %v", withParams.PathParameters)
+	}
+
+	if withParams.Body != "/w==" || !withParams.IsBase64Encoded {
+		t.Errorf("Expected base64 body, got %q", withParams.Body)
+	}
+
+	v2 := NewV2Request().
+		RouteKey("GET /users/{id}").
+		Header("X-Repeated", "1").
+		Header("X-Repeated", "2").
+		Cookie("a", "1").
+		Authorizer(map[string]interface{}{"user": "bob"}).
+		Build()
+
+	if v2.RouteKey != "GET /users/{id}" || v2.RequestContext.RouteKey != v2.RouteKey || v2.RequestContext.Stage != "$default" {
+		t.Errorf("Expected route key and default stage, got %s %s", v2.RouteKey, v2.RequestContext.Stage)
+	}
+
+	if v2.Headers["x-repeated"] != "1,2" || v2.Headers["cookie"] != "" || !slices.Equal(v2.Cookies, []string{"a=1"}) {
+		t.Errorf("Expected joined headers and separate cookies, got %v %q", v2.Headers, v2.Cookies)
+	}
+
+	if v2.RequestContext.Authorizer == nil || v2.RequestContext.Authorizer.Lambda["user"] != "bob" {
+		t.Errorf("Expected Lambda authorizer context, got %+v", v2.RequestContext.Authorizer)
+	}
+
+	fn := NewFunctionURLRequest().Build()
+	if fn.RequestContext.DomainName != APIID+".lambda-url."+Region+".on.aws" || fn.Headers["host"] != fn.RequestContext.DomainName {
+		t.Errorf("Expected Function URL domain, got %s", fn.RequestContext.DomainName)
+	}
+}
+
+func TestWebsocketRequestBuilder(t *testing.T) {
+	testStructs := []struct {
+		EventType string
+		RouteKey  string
+		Body      bool
+		Headers   bool
+	}{
+		{EventType: "CONNECT", RouteKey: "$connect", Headers: true},
+		{EventType: "MESSAGE", RouteKey: "$default", Body: true},
+		{EventType: "DISCONNECT", RouteKey: "$disconnect"},
+	}
+
+	for i, testStruct := range testStructs {
+		req := NewWebsocketRequest().
+			EventType(testStruct.EventType).
+			ConnectionID("conn-1").
+			TextBody("hi").
+			Build()
+
+		if req.RequestContext.RouteKey != testStruct.RouteKey || req.RequestContext.ConnectionID != "conn-1" {
+			t.Errorf("Expected route %s, got %s on iteration %d", testStruct.RouteKey, req.RequestContext.RouteKey, i)
+		}
+
+		if (req.Body == "hi") != testStruct.Body || (req.RequestContext.MessageID != nil) != testStruct.Body {
+			t.Errorf("Expected body %v, got %q on iteration %d", testStruct.Body, req.Body, i)
+		}
+
+		if (req.Headers != nil) != testStruct.Headers {
+			t.Errorf("Expected headers %v, got %v on iteration %d", testStruct.Headers, req.Headers, i)
+		}
+	}
+
+	if req := NewWebsocketRequest().EventType("DISCONNECT").Build(); req.RequestContext.DisconnectStatusCode != 1000 {
+		t.Errorf("Expected disconnect status 1000, got %d", req.RequestContext.DisconnectStatusCode)
+	}
+}
+
+func TestALBRequestBuilder(t *testing.T) {
+	req := NewALBRequest().
+		Query("q", "a b+c/d").
+		Query("tag", "x").
+		Query("tag", "y").
+		SourceIP("198.51.100.7").
+		Build()
+
+	if got := req.QueryStringParameters["q"]; got != "a%20b%2Bc%2Fd" {
+		t.Errorf("Expected %s, got %s", "a%20b%2Bc%2Fd", got)
+	}
+
+	for _, unescape := range []func(string) (string, error){url.PathUnescape, url.QueryUnescape} {
+		if got, err := unescape(req.QueryStringParameters["q"]); err != nil || got != "a b+c/d" {
+			t.Errorf("Expected %s, got %s (%v)", "a b+c/d", got, err)
+		}
+	}
+
+	if req.QueryStringParameters["tag"] != "y" || req.MultiValueQueryStringParameters != nil {
+		t.Errorf("Expected single value query parameters, got %v", req.QueryStringParameters)
+	}
+
+	if req.Headers["x-forwarded-for"] != "198.51.100.7" || req.MultiValueHeaders != nil {
+		t.Errorf("Expected single value x-forwarded-for, got %v", req.Headers)
+	}
+
+	multi := NewALBRequest().Query("tag", "x").Query("tag", "y").MultiValue().Build()
+
+	if !slices.Equal(multi.MultiValueQueryStringParameters["tag"], []string{"x", "y"}) || multi.QueryStringParameters != nil || multi.Headers != nil {
+		t.Errorf("Expected multi-value fields only, got %v", multi.MultiValueQueryStringParameters)
+	}
+
+	if multi.RequestContext.ELB.TargetGroupArn != TargetGroupArn {
+		t.Errorf("Expected target group %s, got %s", TargetGroupArn, multi.RequestContext.ELB.TargetGroupArn)
+	}
+}
+
+// fakeTB records the failures reported to it, so that assertions can be
+// tested failing
+type fakeTB struct {
+	testing.TB
+	failures []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.failures = append(f.failures, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// run calls fn with f in a goroutine of its own, which Fatalf can exit
+func (f *fakeTB) run(fn func(tb testing.TB)) {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		fn(f)
+	}()
+
+	<-done
+}
+
+func TestDecodeResponse(t *testing.T) {
+	body := base64.StdEncoding.EncodeToString([]byte("hello"))
+
+	testStructs := []struct {
+		Response any
+		Cookies  []string
+	}{
+		{Response: events.APIGatewayProxyResponse{StatusCode: 200, Headers: map[string]string{"x-single": "1"}, MultiValueHeaders: map[string][]string{"x-multi": {"a", "b"}}, Body: body, IsBase64Encoded: true}},
+		{Response: &events.APIGatewayProxyResponse{StatusCode: 200, Headers: map[string]string{"x-single": "1"}, MultiValueHeaders: map[string][]string{"x-multi": {"a", "b"}}, Body: "hello"}},
+		{Response: events.APIGatewayV2HTTPResponse{StatusCode: 200, Headers: map[string]string{"x-single": "1"}, MultiValueHeaders: map[string][]string{"x-multi": {"a", "b"}}, Cookies: []string{"a=1"}, Body: body, IsBase64Encoded: true}, Cookies: []string{"a=1"}},
+		{Response: events.ALBTargetGroupResponse{StatusCode: 200, Headers: map[string]string{"x-single": "1"}, MultiValueHeaders: map[string][]string{"x-multi": {"a", "b"}}, Body: "hello"}},
+		{Response: &events.LambdaFunctionURLResponse{StatusCode: 200, Headers: map[string]string{"x-single": "1", "x-multi": "a"}, Cookies: []string{"a=1"}, Body: body, IsBase64Encoded: true}, Cookies: []string{"a=1"}},
+	}
+
+	for i, testStruct := range testStructs {
+		r := DecodeResponse(t, testStruct.Response)
+
+		if r.StatusCode != 200 || string(r.Body) != "hello" || r.Header.Get("X-Single") != "1" || r.Header.Get("X-Multi") != "a" {
+			t.Errorf("Expected decoded response, got %+v on iteration %d", r, i)
+		}
+
+		if !slices.Equal(r.Cookies, testStruct.Cookies) {
+			t.Errorf("Expected cookies %q, got %q on iteration %d", testStruct.Cookies, r.Cookies, i)
+		}
+	}
+
+	if r := DecodeResponse(t, testStructs[0].Response); !slices.Equal(r.Header.Values("X-Multi"), []string{"a", "b"}) {
+		t.Errorf("Expected multi-value header, got %q", r.Header.Values("X-Multi"))
+	}
+
+	for i, resp := range []any{"not a response", events.APIGatewayProxyResponse{Body: "!", IsBase64Encoded: true}} {
+		f := &fakeTB{TB: t}
+		f.run(func(tb testing.TB) { DecodeResponse(tb, resp) })
+
+		if len(f.failures) != 1 {
+			t.Errorf("Expected one failure, got %q on iteration %d", f.failures, i)
+		}
+	}
+}
+
+func TestResponseAssertions(t *testing.T) {
+	resp := apigateway.ProxyResponse(http.StatusOK, nil, []byte(`{"b":2,"a":[1,2]}`))
+	resp.MultiValueHeaders["X-Multi"] = []string{"a", "b"}
+
+	r := DecodeResponse(t, resp)
+
+	r.AssertStatus(t, http.StatusOK).
+		AssertHeader(t, "Cache-Control", "no-store, max-age=0").
+		AssertHeaders(t, http.Header{"X-Multi": {"a", "b"}}).
+		AssertDefaultHTTPHeaders(t).
+		AssertBody(t, []byte(`{"b":2,"a":[1,2]}`)).
+		AssertJSONBody(t, map[string]any{"a": []int{1, 2}, "b": 2})
+
+	var v struct{ B int }
+	if r.DecodeJSON(t, &v); v.B != 2 {
+		t.Errorf("Expected 2, got %d", v.B)
+	}
+
+	testStructs := []func(tb testing.TB){
+		func(tb testing.TB) { r.AssertStatus(tb, http.StatusNotFound) },
+		func(tb testing.TB) { r.AssertHeader(tb, "Cache-Control", "public") },
+		func(tb testing.TB) { r.AssertHeaders(tb, http.Header{"X-Multi": {"a"}}) },
+		func(tb testing.TB) { r.AssertHeaders(tb, http.Header{"X-Multi": {"b", "a"}}) },
+		func(tb testing.TB) { DecodeResponse(tb, events.APIGatewayProxyResponse{}).AssertDefaultHTTPHeaders(tb) },
+		func(tb testing.TB) { r.AssertBody(tb, []byte("other")) },
+		func(tb testing.TB) { r.AssertJSONBody(tb, map[string]any{"b": 3}) },
+		func(tb testing.TB) {
+			DecodeResponse(tb, events.APIGatewayProxyResponse{Body: "text"}).AssertJSONBody(tb, "text")
+		},
+		func(tb testing.TB) {
+			DecodeResponse(tb, events.APIGatewayProxyResponse{Body: "text"}).DecodeJSON(tb, &v)
+		},
+	}
+
+	for i, fn := range testStructs {
+		f := &fakeTB{TB: t}
+		f.run(fn)
+
+		if len(f.failures) == 0 {
+			t.Errorf("Expected a failure on iteration %d", i)
+		}
+	}
+}
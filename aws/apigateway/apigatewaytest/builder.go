@@ -0,0 +1,613 @@
+package apigatewaytest
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Additional request context values used by the event builders.
+const (
+	Region         = "us-east-1"
+	SourceIP       = "203.0.113.10"
+	UserAgent      = "apigatewaytest/1.0"
+	TargetGroupArn = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/lambda-target/abcdef0123456789"
+)
+
+// RequestBuilder builds realistic Lambda events for tests. Create one with
+// NewV1Request, NewV2Request, NewWebsocketRequest, NewALBRequest or
+// NewFunctionURLRequest, chain the setters, then call Build.
+//
+// Setters that do not apply to an event type are ignored by its Build. Like
+// httptest.NewRequest, the builder panics on invalid input since it is
+// intended for tests.
+type RequestBuilder[E any] struct {
+	method            string
+	path              string
+	query             url.Values
+	header            http.Header
+	cookies           []string
+	body              []byte
+	isBase64Encoded   bool
+	pathParameters    map[string]string
+	stageVariables    map[string]string
+	stage             string
+	routeKey          string
+	connectionID      string
+	eventType         string
+	sourceIP          string
+	authorizer        map[string]interface{}
+	multiValue        bool
+	requestTime       time.Time
+	build             func(*RequestBuilder[E]) E
+	domainName        string
+	defaultStage      string
+	defaultRouteKey   string
+	lowerCaseHeaders  bool
+	separateCookieHdr bool
+}
+
+func newRequestBuilder[E any](build func(*RequestBuilder[E]) E) *RequestBuilder[E] {
+	return &RequestBuilder[E]{
+		method:     http.MethodGet,
+		path:       "/",
+		query:      make(url.Values),
+		header:     make(http.Header),
+		sourceIP:   SourceIP,
+		build:      build,
+		domainName: APIID + ".execute-api." + Region + ".amazonaws.com",
+	}
+}
+
+// NewV1Request returns a builder for API Gateway REST API (payload format
+// version 1.0) events. The defaults are a GET request for "/" on the "test"
+// stage through a greedy "/{proxy+}" resource.
+func NewV1Request() *RequestBuilder[events.APIGatewayProxyRequest] {
+	b := newRequestBuilder(buildV1Request)
+	b.defaultStage = "test"
+
+	return b
+}
+
+// NewV2Request returns a builder for API Gateway HTTP API (payload format
+// version 2.0) events. The defaults are a GET request for "/" on the
+// "$default" stage and route.
+func NewV2Request() *RequestBuilder[events.APIGatewayV2HTTPRequest] {
+	b := newRequestBuilder(buildV2Request)
+	b.defaultStage = "$default"
+	b.defaultRouteKey = "$default"
+	b.lowerCaseHeaders = true
+	b.separateCookieHdr = true
+
+	return b
+}
+
+// NewWebsocketRequest returns a builder for API Gateway Websocket API events.
+// The defaults are a MESSAGE event on the "$default" route of the "test"
+// stage.
+func NewWebsocketRequest() *RequestBuilder[events.APIGatewayWebsocketProxyRequest] {
+	b := newRequestBuilder(buildWebsocketRequest)
+	b.defaultStage = "test"
+	b.defaultRouteKey = "$default"
+	b.eventType = "MESSAGE"
+
+	return b
+}
+
+// NewALBRequest returns a builder for Application Load Balancer target group
+// events. The defaults are a GET request for "/" with multi-value headers
+// disabled on the target group.
+func NewALBRequest() *RequestBuilder[events.ALBTargetGroupRequest] {
+	b := newRequestBuilder(buildALBRequest)
+	b.lowerCaseHeaders = true
+
+	return b
+}
+
+// NewFunctionURLRequest returns a builder for Lambda Function URL events. The
+// default is a GET request for "/".
+func NewFunctionURLRequest() *RequestBuilder[events.LambdaFunctionURLRequest] {
+	b := newRequestBuilder(buildFunctionURLRequest)
+	b.domainName = APIID + ".lambda-url." + Region + ".on.aws"
+	b.lowerCaseHeaders = true
+	b.separateCookieHdr = true
+
+	return b
+}
+
+// Method sets the HTTP method.
+func (b *RequestBuilder[E]) Method(method string) *RequestBuilder[E] {
+	b.method = strings.ToUpper(method)
+	return b
+}
+
+// Path sets the request path. A query string in p is parsed and added to the
+// query parameters.
+func (b *RequestBuilder[E]) Path(p string) *RequestBuilder[E] {
+	u, err := url.Parse(p)
+	if err != nil {
+		panic(fmt.Sprintf("apigatewaytest: invalid path %q: %v", p, err))
+	}
+
+	b.path = u.Path
+	if b.path == "" {
+		b.path = "/"
+	}
+
+	for k, v := range u.Query() {
+		b.query[k] = append(b.query[k], v...)
+	}
+
+	return b
+}
+
+// Query adds a query string parameter.
+func (b *RequestBuilder[E]) Query(key, value string) *RequestBuilder[E] {
+	b.query.Add(key, value)
+	return b
+}
+
+// Header adds a request header.
+func (b *RequestBuilder[E]) Header(key, value string) *RequestBuilder[E] {
+	b.header.Add(key, value)
+	return b
+}
+
+// Cookie adds a request cookie.
+func (b *RequestBuilder[E]) Cookie(name, value string) *RequestBuilder[E] {
+	b.cookies = append(b.cookies, (&http.Cookie{Name: name, Value: value}).String())
+	return b
+}
+
+// Body sets the raw request body.
+func (b *RequestBuilder[E]) Body(body []byte) *RequestBuilder[E] {
+	b.body = body
+	return b
+}
+
+// TextBody sets the request body and a text/plain Content-Type.
+func (b *RequestBuilder[E]) TextBody(body string) *RequestBuilder[E] {
+	b.header.Set("Content-Type", "text/plain; charset=utf-8")
+	b.body = []byte(body)
+
+	return b
+}
+
+// JSONBody sets the request body to the JSON encoding of v and an
+// application/json Content-Type.
+func (b *RequestBuilder[E]) JSONBody(v any) *RequestBuilder[E] {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("apigatewaytest: marshal JSON body: %v", err))
+	}
+
+	b.header.Set("Content-Type", "application/json")
+	b.body = body
+
+	return b
+}
+
+// Base64 base64 encodes the body and sets IsBase64Encoded, as API Gateway
+// does for binary payloads.
+func (b *RequestBuilder[E]) Base64() *RequestBuilder[E] {
+	b.isBase64Encoded = true
+	return b
+}
+
+// PathParameter sets a path parameter. Path parameters replace the default
+// "proxy" parameter of REST API events.
+func (b *RequestBuilder[E]) PathParameter(key, value string) *RequestBuilder[E] {
+	if b.pathParameters == nil {
+		b.pathParameters = make(map[string]string)
+	}
+
+	b.pathParameters[key] = value
+
+	return b
+}
+
+// StageVariable sets a stage variable.
+func (b *RequestBuilder[E]) StageVariable(key, value string) *RequestBuilder[E] {
+	if b.stageVariables == nil {
+		b.stageVariables = make(map[string]string)
+	}
+
+	b.stageVariables[key] = value
+
+	return b
+}
+
+// Stage sets the stage name.
+func (b *RequestBuilder[E]) Stage(stage string) *RequestBuilder[E] {
+	b.stage = stage
+	return b
+}
+
+// RouteKey sets the route key of HTTP API and Websocket API events.
+func (b *RequestBuilder[E]) RouteKey(routeKey string) *RequestBuilder[E] {
+	b.routeKey = routeKey
+	return b
+}
+
+// ConnectionID sets the connection id of Websocket API events.
+func (b *RequestBuilder[E]) ConnectionID(id string) *RequestBuilder[E] {
+	b.connectionID = id
+	return b
+}
+
+// EventType sets the event type (CONNECT, MESSAGE or DISCONNECT) of
+// Websocket API events.
+func (b *RequestBuilder[E]) EventType(eventType string) *RequestBuilder[E] {
+	b.eventType = strings.ToUpper(eventType)
+	return b
+}
+
+// SourceIP sets the caller IP address.
+func (b *RequestBuilder[E]) SourceIP(ip string) *RequestBuilder[E] {
+	b.sourceIP = ip
+	return b
+}
+
+// Authorizer sets the context returned by a Lambda authorizer. It is placed
+// in RequestContext.Authorizer of REST API and Websocket API events and in
+// RequestContext.Authorizer.Lambda of HTTP API events.
+func (b *RequestBuilder[E]) Authorizer(context map[string]interface{}) *RequestBuilder[E] {
+	b.authorizer = context
+	return b
+}
+
+// MultiValue fills the multi-value header and query string fields of ALB
+// events instead of the single value ones, as when multi-value headers are
+// enabled on the target group.
+func (b *RequestBuilder[E]) MultiValue() *RequestBuilder[E] {
+	b.multiValue = true
+	return b
+}
+
+// Time sets the request time. The default is the time Build is called.
+func (b *RequestBuilder[E]) Time(t time.Time) *RequestBuilder[E] {
+	b.requestTime = t
+	return b
+}
+
+// Build returns the event.
+func (b *RequestBuilder[E]) Build() E {
+	return b.build(b)
+}
+
+func (b *RequestBuilder[E]) now() time.Time {
+	if b.requestTime.IsZero() {
+		return time.Now()
+	}
+
+	return b.requestTime
+}
+
+func (b *RequestBuilder[E]) stageOrDefault() string {
+	if b.stage == "" {
+		return b.defaultStage
+	}
+
+	return b.stage
+}
+
+func (b *RequestBuilder[E]) routeKeyOrDefault() string {
+	if b.routeKey == "" {
+		return b.defaultRouteKey
+	}
+
+	return b.routeKey
+}
+
+func (b *RequestBuilder[E]) encodedBody() string {
+	body, _ := encodeBody(b.body, b.isBase64Encoded)
+	return body
+}
+
+// headers returns the request headers with cookies folded into a Cookie
+// header unless the event carries them in a separate field.
+func (b *RequestBuilder[E]) headers() http.Header {
+	h := b.header.Clone()
+	h.Set("Host", b.domainName)
+
+	if h.Get("User-Agent") == "" {
+		h.Set("User-Agent", UserAgent)
+	}
+
+	if len(b.cookies) > 0 && !b.separateCookieHdr {
+		h.Set("Cookie", strings.Join(b.cookies, "; "))
+	}
+
+	if !b.lowerCaseHeaders {
+		return h
+	}
+
+	lower := make(http.Header, len(h))
+	for k, v := range h {
+		lower[strings.ToLower(k)] = v
+	}
+
+	return lower
+}
+
+func (b *RequestBuilder[E]) rawQuery() string {
+	return b.query.Encode()
+}
+
+// userAgent returns the User-Agent header regardless of the case of the
+// header names in h
+func userAgent(h http.Header) string {
+	if v, ok := h["user-agent"]; ok {
+		return v[0]
+	}
+
+	return h.Get("User-Agent")
+}
+
+func singleValues(m map[string][]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v[len(v)-1]
+	}
+
+	return nilIfEmpty(out)
+}
+
+func joinedValues(m map[string][]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = strings.Join(v, ",")
+	}
+
+	return nilIfEmpty(out)
+}
+
+func copyValues(m map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(m))
+	for k, v := range m {
+		out[k] = append([]string(nil), v...)
+	}
+
+	return nilIfEmpty(out)
+}
+
+func buildV1Request(b *RequestBuilder[events.APIGatewayProxyRequest]) events.APIGatewayProxyRequest {
+	now := b.now()
+	requestID := newRequestID()
+	headers := b.headers()
+
+	pathParameters := b.pathParameters
+	if pathParameters == nil {
+		pathParameters = map[string]string{"proxy": strings.TrimPrefix(b.path, "/")}
+	}
+
+	return events.APIGatewayProxyRequest{
+		Resource:                        "/{proxy+}",
+		Path:                            b.path,
+		HTTPMethod:                      b.method,
+		Headers:                         singleValues(headers),
+		MultiValueHeaders:               copyValues(headers),
+		QueryStringParameters:           singleValues(b.query),
+		MultiValueQueryStringParameters: copyValues(b.query),
+		PathParameters:                  pathParameters,
+		StageVariables:                  b.stageVariables,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			AccountID:         AccountID,
+			ResourceID:        ResourceID,
+			Stage:             b.stageOrDefault(),
+			DomainName:        b.domainName,
+			DomainPrefix:      APIID,
+			RequestID:         requestID,
+			ExtendedRequestID: requestID,
+			Protocol:          "HTTP/1.1",
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  b.sourceIP,
+				UserAgent: userAgent(headers),
+			},
+			ResourcePath:     "/{proxy+}",
+			Path:             "/" + b.stageOrDefault() + b.path,
+			Authorizer:       b.authorizer,
+			HTTPMethod:       b.method,
+			RequestTime:      now.UTC().Format("02/Jan/2006:15:04:05 -0700"),
+			RequestTimeEpoch: now.UnixMilli(),
+			APIID:            APIID,
+		},
+		Body:            b.encodedBody(),
+		IsBase64Encoded: b.isBase64Encoded,
+	}
+}
+
+func buildV2Request(b *RequestBuilder[events.APIGatewayV2HTTPRequest]) events.APIGatewayV2HTTPRequest {
+	now := b.now()
+	headers := b.headers()
+
+	var authorizer *events.APIGatewayV2HTTPRequestContextAuthorizerDescription
+	if b.authorizer != nil {
+		authorizer = &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+			Lambda: b.authorizer,
+		}
+	}
+
+	return events.APIGatewayV2HTTPRequest{
+		Version:               "2.0",
+		RouteKey:              b.routeKeyOrDefault(),
+		RawPath:               b.path,
+		RawQueryString:        b.rawQuery(),
+		Cookies:               b.cookies,
+		Headers:               joinedValues(headers),
+		QueryStringParameters: joinedValues(b.query),
+		PathParameters:        b.pathParameters,
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			RouteKey:     b.routeKeyOrDefault(),
+			AccountID:    AccountID,
+			Stage:        b.stageOrDefault(),
+			RequestID:    newRequestID(),
+			Authorizer:   authorizer,
+			APIID:        APIID,
+			DomainName:   b.domainName,
+			DomainPrefix: APIID,
+			Time:         now.UTC().Format("02/Jan/2006:15:04:05 -0700"),
+			TimeEpoch:    now.UnixMilli(),
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+				Method:    b.method,
+				Path:      b.path,
+				Protocol:  "HTTP/1.1",
+				SourceIP:  b.sourceIP,
+				UserAgent: userAgent(headers),
+			},
+		},
+		StageVariables:  b.stageVariables,
+		Body:            b.encodedBody(),
+		IsBase64Encoded: b.isBase64Encoded,
+	}
+}
+
+func buildWebsocketRequest(b *RequestBuilder[events.APIGatewayWebsocketProxyRequest]) events.APIGatewayWebsocketProxyRequest {
+	now := b.now()
+	requestID := newRequestID()
+
+	connectionID := b.connectionID
+	if connectionID == "" {
+		connectionID = newConnectionID()
+	}
+
+	req := events.APIGatewayWebsocketProxyRequest{
+		StageVariables: b.stageVariables,
+		RequestContext: events.APIGatewayWebsocketProxyRequestContext{
+			Stage:     b.stageOrDefault(),
+			RequestID: requestID,
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP: b.sourceIP,
+			},
+			APIID:             APIID,
+			ConnectedAt:       now.UnixMilli(),
+			ConnectionID:      connectionID,
+			DomainName:        b.domainName,
+			EventType:         b.eventType,
+			ExtendedRequestID: requestID,
+			MessageDirection:  "IN",
+			RequestTime:       now.UTC().Format("02/Jan/2006:15:04:05 -0700"),
+			RequestTimeEpoch:  now.UnixMilli(),
+			RouteKey:          b.routeKeyOrDefault(),
+		},
+		IsBase64Encoded: b.isBase64Encoded,
+	}
+
+	if b.authorizer != nil {
+		req.RequestContext.Authorizer = b.authorizer
+	}
+
+	switch b.eventType {
+	case "CONNECT":
+		// only the $connect route receives the HTTP upgrade request
+		headers := b.headers()
+		req.RequestContext.RouteKey = "$connect"
+		req.RequestContext.Identity.UserAgent = userAgent(headers)
+		req.Headers = singleValues(headers)
+		req.MultiValueHeaders = copyValues(headers)
+		req.QueryStringParameters = singleValues(b.query)
+		req.MultiValueQueryStringParameters = copyValues(b.query)
+	case "DISCONNECT":
+		req.RequestContext.RouteKey = "$disconnect"
+		req.RequestContext.DisconnectStatusCode = 1000
+	default:
+		req.RequestContext.MessageID = newConnectionID()
+		req.Body = b.encodedBody()
+	}
+
+	return req
+}
+
+func buildALBRequest(b *RequestBuilder[events.ALBTargetGroupRequest]) events.ALBTargetGroupRequest {
+	headers := b.headers()
+
+	req := events.ALBTargetGroupRequest{
+		HTTPMethod: b.method,
+		Path:       b.path,
+		RequestContext: events.ALBTargetGroupRequestContext{
+			ELB: events.ELBContext{
+				TargetGroupArn: TargetGroupArn,
+			},
+		},
+		IsBase64Encoded: b.isBase64Encoded,
+		Body:            b.encodedBody(),
+	}
+
+	headers["x-forwarded-for"] = []string{b.sourceIP}
+	headers["x-forwarded-port"] = []string{"443"}
+	headers["x-forwarded-proto"] = []string{"https"}
+
+	// ALB passes query string parameters as the client sent them, still
+	// percent-encoded
+	query := make(map[string][]string, len(b.query))
+	for k, v := range b.query {
+		for _, s := range v {
+			query[albEscape(k)] = append(query[albEscape(k)], albEscape(s))
+		}
+	}
+
+	if b.multiValue {
+		req.MultiValueHeaders = copyValues(headers)
+		req.MultiValueQueryStringParameters = copyValues(query)
+	} else {
+		req.Headers = singleValues(headers)
+		req.QueryStringParameters = singleValues(query)
+	}
+
+	return req
+}
+
+func buildFunctionURLRequest(b *RequestBuilder[events.LambdaFunctionURLRequest]) events.LambdaFunctionURLRequest {
+	now := b.now()
+	headers := b.headers()
+
+	return events.LambdaFunctionURLRequest{
+		Version:               "2.0",
+		RawPath:               b.path,
+		RawQueryString:        b.rawQuery(),
+		Cookies:               b.cookies,
+		Headers:               joinedValues(headers),
+		QueryStringParameters: joinedValues(b.query),
+		RequestContext: events.LambdaFunctionURLRequestContext{
+			AccountID:    AccountID,
+			RequestID:    newRequestID(),
+			APIID:        APIID,
+			DomainName:   b.domainName,
+			DomainPrefix: APIID,
+			Time:         now.UTC().Format("02/Jan/2006:15:04:05 -0700"),
+			TimeEpoch:    now.UnixMilli(),
+			HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{
+				Method:    b.method,
+				Path:      b.path,
+				Protocol:  "HTTP/1.1",
+				SourceIP:  b.sourceIP,
+				UserAgent: userAgent(headers),
+			},
+		},
+		Body:            b.encodedBody(),
+		IsBase64Encoded: b.isBase64Encoded,
+	}
+}
+
+// albEscape percent-encodes s as clients send query strings, with spaces as
+// %20 rather than the + of url.QueryEscape, so that url.PathUnescape and
+// url.QueryUnescape both decode it
+func albEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// newConnectionID returns a random id in the format API Gateway uses for
+// Websocket connection and message ids
+func newConnectionID() string {
+	b := make([]byte, 11)
+	_, _ = rand.Read(b)
+
+	return base64.StdEncoding.EncodeToString(b)
+}
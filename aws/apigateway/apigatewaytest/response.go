@@ -0,0 +1,194 @@
+package apigatewaytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	httputil "github.com/Vitality-South/goutil/http"
+)
+
+// Response is a Lambda HTTP response normalized for assertions. Header holds
+// both Headers and MultiValueHeaders of the original response, and Body is
+// base64 decoded if necessary.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Cookies    []string
+	Body       []byte
+}
+
+// DecodeResponse normalizes resp, which must be one of
+// events.APIGatewayProxyResponse, events.APIGatewayV2HTTPResponse,
+// events.ALBTargetGroupResponse, events.LambdaFunctionURLResponse or a pointer
+// to one of them. The test fails immediately if resp is of another type or
+// its body cannot be decoded.
+func DecodeResponse(tb testing.TB, resp any) *Response {
+	tb.Helper()
+
+	var (
+		status            int
+		headers           map[string]string
+		multiValueHeaders map[string][]string
+		cookies           []string
+		body              string
+		isBase64Encoded   bool
+	)
+
+	switch r := resp.(type) {
+	case *events.APIGatewayProxyResponse:
+		return DecodeResponse(tb, *r)
+	case *events.APIGatewayV2HTTPResponse:
+		return DecodeResponse(tb, *r)
+	case *events.ALBTargetGroupResponse:
+		return DecodeResponse(tb, *r)
+	case *events.LambdaFunctionURLResponse:
+		return DecodeResponse(tb, *r)
+	case events.APIGatewayProxyResponse:
+		status, headers, multiValueHeaders = r.StatusCode, r.Headers, r.MultiValueHeaders
+		body, isBase64Encoded = r.Body, r.IsBase64Encoded
+	case events.APIGatewayV2HTTPResponse:
+		status, headers, multiValueHeaders, cookies = r.StatusCode, r.Headers, r.MultiValueHeaders, r.Cookies
+		body, isBase64Encoded = r.Body, r.IsBase64Encoded
+	case events.ALBTargetGroupResponse:
+		status, headers, multiValueHeaders = r.StatusCode, r.Headers, r.MultiValueHeaders
+		body, isBase64Encoded = r.Body, r.IsBase64Encoded
+	case events.LambdaFunctionURLResponse:
+		status, headers, cookies = r.StatusCode, r.Headers, r.Cookies
+		body, isBase64Encoded = r.Body, r.IsBase64Encoded
+	default:
+		tb.Fatalf("apigatewaytest: unsupported response type %T", resp)
+	}
+
+	b, err := ResponseBody(isBase64Encoded, body)
+	if err != nil {
+		tb.Fatalf("apigatewaytest: %v", err)
+	}
+
+	h := make(http.Header)
+	for k, v := range headers {
+		h.Set(k, v)
+	}
+
+	for k, v := range multiValueHeaders {
+		h[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+	}
+
+	return &Response{
+		StatusCode: status,
+		Header:     h,
+		Cookies:    cookies,
+		Body:       b,
+	}
+}
+
+// AssertStatus fails the test if the response status code is not want.
+func (r *Response) AssertStatus(tb testing.TB, want int) *Response {
+	tb.Helper()
+
+	if r.StatusCode != want {
+		tb.Errorf("expected status %d, got %d", want, r.StatusCode)
+	}
+
+	return r
+}
+
+// AssertHeader fails the test if the response header key is not want.
+func (r *Response) AssertHeader(tb testing.TB, key, want string) *Response {
+	tb.Helper()
+
+	if got := r.Header.Get(key); got != want {
+		tb.Errorf("expected header %s [%s], got [%s]", key, want, got)
+	}
+
+	return r
+}
+
+// AssertHeaders fails the test for every header in want that is missing from
+// the response or has different values. Extra response headers are allowed.
+func (r *Response) AssertHeaders(tb testing.TB, want http.Header) *Response {
+	tb.Helper()
+
+	for k, v := range want {
+		got := r.Header.Values(k)
+
+		if len(got) != len(v) {
+			tb.Errorf("expected header %s %q, got %q", k, v, got)
+			continue
+		}
+
+		for i := range v {
+			if got[i] != v[i] {
+				tb.Errorf("expected header %s %q, got %q", k, v, got)
+				break
+			}
+		}
+	}
+
+	return r
+}
+
+// AssertDefaultHTTPHeaders fails the test if the response is missing any of
+// the security headers returned by DefaultHTTPHeaders(). Content-Type is not
+// checked since responses commonly override it.
+func (r *Response) AssertDefaultHTTPHeaders(tb testing.TB) *Response {
+	tb.Helper()
+
+	want := httputil.DefaultHTTPHeaders()
+	want.Del("Content-Type")
+
+	return r.AssertHeaders(tb, want)
+}
+
+// AssertBody fails the test if the decoded response body is not want.
+func (r *Response) AssertBody(tb testing.TB, want []byte) *Response {
+	tb.Helper()
+
+	if !bytes.Equal(r.Body, want) {
+		tb.Errorf("expected body [%s], got [%s]", want, r.Body)
+	}
+
+	return r
+}
+
+// AssertJSONBody fails the test if the decoded response body is not JSON
+// equivalent to the JSON encoding of want.
+func (r *Response) AssertJSONBody(tb testing.TB, want any) *Response {
+	tb.Helper()
+
+	w, err := json.Marshal(want)
+	if err != nil {
+		tb.Fatalf("apigatewaytest: marshal expected body: %v", err)
+	}
+
+	var gotValue, wantValue any
+
+	if err := json.Unmarshal(r.Body, &gotValue); err != nil {
+		tb.Errorf("expected JSON body, got [%s]: %v", r.Body, err)
+		return r
+	}
+
+	_ = json.Unmarshal(w, &wantValue)
+
+	g, _ := json.Marshal(gotValue)
+	w, _ = json.Marshal(wantValue)
+
+	if !bytes.Equal(g, w) {
+		tb.Errorf("expected body %s, got %s", w, g)
+	}
+
+	return r
+}
+
+// DecodeJSON unmarshals the decoded response body into v, failing the test
+// immediately on error.
+func (r *Response) DecodeJSON(tb testing.TB, v any) {
+	tb.Helper()
+
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		tb.Fatalf("apigatewaytest: unmarshal response body [%s]: %v", r.Body, err)
+	}
+}
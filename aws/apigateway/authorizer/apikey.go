@@ -0,0 +1,54 @@
+package authorizer
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+// APIKeys verifies API keys against a fixed set. It is safe for concurrent
+// use.
+type APIKeys struct {
+	keys []apiKey
+}
+
+type apiKey struct {
+	hash      [sha256.Size]byte
+	principal string
+}
+
+// NewAPIKeys returns an APIKeys for the given map of API key to principal id.
+// Keys are stored as SHA-256 hashes.
+func NewAPIKeys(keys map[string]string) *APIKeys {
+	a := &APIKeys{keys: make([]apiKey, 0, len(keys))}
+
+	for k, p := range keys {
+		if k == "" {
+			continue
+		}
+
+		a.keys = append(a.keys, apiKey{hash: sha256.Sum256([]byte(k)), principal: p})
+	}
+
+	return a
+}
+
+// Verify returns the principal id of key and true if key is valid. Every
+// known key is compared in constant time so the result does not leak which,
+// or how much of a, key matched.
+func (a *APIKeys) Verify(key string) (string, bool) {
+	h := sha256.Sum256([]byte(key))
+
+	principal := ""
+	found := 0
+
+	for _, k := range a.keys {
+		match := subtle.ConstantTimeCompare(h[:], k.hash[:])
+		if match == 1 {
+			principal = k.principal
+		}
+
+		found |= match
+	}
+
+	return principal, found == 1 && key != ""
+}
@@ -0,0 +1,175 @@
+// Package authorizer implements helper functions for writing API Gateway
+// Lambda authorizers and reading their results in downstream handlers
+package authorizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Policy effects for AuthorizerResponse.
+const (
+	EffectAllow = "Allow"
+	EffectDeny  = "Deny"
+)
+
+// AuthorizerResponse returns a REST API (or HTTP API payload format 1.0)
+// authorizer response with an IAM policy granting or denying
+// execute-api:Invoke on the given resources. If no resources are provided,
+// the policy applies to all of them ("*").
+//
+// Context values must be strings, numbers or booleans; use ClaimsContext to
+// convert token claims.
+func AuthorizerResponse(principalID, effect string, context map[string]interface{}, resources ...string) events.APIGatewayCustomAuthorizerResponse {
+	if len(resources) == 0 {
+		resources = []string{"*"}
+	}
+
+	return events.APIGatewayCustomAuthorizerResponse{
+		PrincipalID: principalID,
+		PolicyDocument: events.APIGatewayCustomAuthorizerPolicy{
+			Version: "2012-10-17",
+			Statement: []events.IAMPolicyStatement{
+				{
+					Action:   []string{"execute-api:Invoke"},
+					Effect:   effect,
+					Resource: resources,
+				},
+			},
+		},
+		Context: context,
+	}
+}
+
+// Allow returns an authorizer response allowing principalID to invoke
+// methodArn.
+//
+// API Gateway caches the policy for the authorization token, so when caching
+// is enabled pass WildcardMethodArn(methodArn) to allow the other methods of
+// the API as well.
+func Allow(principalID, methodArn string, context map[string]interface{}) events.APIGatewayCustomAuthorizerResponse {
+	return AuthorizerResponse(principalID, EffectAllow, context, methodArn)
+}
+
+// Deny returns an authorizer response denying principalID from invoking
+// methodArn. API Gateway responds 403 Forbidden.
+//
+// To respond 401 Unauthorized instead, return an error with the message
+// "Unauthorized" from the authorizer.
+func Deny(principalID, methodArn string) events.APIGatewayCustomAuthorizerResponse {
+	return AuthorizerResponse(principalID, EffectDeny, nil, methodArn)
+}
+
+// SimpleResponse returns an HTTP API (payload format 2.0) simple authorizer
+// response.
+func SimpleResponse(isAuthorized bool, context map[string]interface{}) events.APIGatewayV2CustomAuthorizerSimpleResponse {
+	return events.APIGatewayV2CustomAuthorizerSimpleResponse{
+		IsAuthorized: isAuthorized,
+		Context:      context,
+	}
+}
+
+// WildcardMethodArn returns methodArn with the HTTP method and resource path
+// replaced by wildcards, covering every method of the same API stage.
+//
+// arn:aws:execute-api:us-east-1:123456789012:abcdef1234/prod/GET/pets becomes
+// arn:aws:execute-api:us-east-1:123456789012:abcdef1234/prod/*/*
+func WildcardMethodArn(methodArn string) string {
+	parts := strings.SplitN(methodArn, "/", 3)
+	if len(parts) < 2 {
+		return methodArn
+	}
+
+	return parts[0] + "/" + parts[1] + "/*/*"
+}
+
+// ClaimsContext converts claims into an authorizer context. API Gateway only
+// accepts string, number and boolean context values, so other values, such
+// as arrays and objects, are JSON encoded.
+func ClaimsContext(claims Claims) map[string]interface{} {
+	ctx := make(map[string]interface{}, len(claims))
+
+	for k, v := range claims {
+		switch v.(type) {
+		case string, bool, float64, float32, int, int64, int32, json.Number:
+			ctx[k] = v
+		case nil:
+			continue
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				ctx[k] = fmt.Sprint(v)
+				continue
+			}
+
+			ctx[k] = string(b)
+		}
+	}
+
+	return ctx
+}
+
+// ClaimsFromProxyRequest returns the claims placed in the request context by
+// an authorizer of a REST API.
+//
+// For Cognito user pool authorizers these are the token claims. For Lambda
+// authorizers these are the context values returned by the authorizer along
+// with "principalId".
+func ClaimsFromProxyRequest(request *events.APIGatewayProxyRequest) Claims {
+	return claimsFromAuthorizer(request.RequestContext.Authorizer)
+}
+
+// ClaimsFromWebsocketRequest returns the claims placed in the request context
+// by the authorizer of a Websocket API.
+func ClaimsFromWebsocketRequest(request *events.APIGatewayWebsocketProxyRequest) Claims {
+	a, _ := request.RequestContext.Authorizer.(map[string]interface{})
+	return claimsFromAuthorizer(a)
+}
+
+// ClaimsFromV2HTTPRequest returns the claims placed in the request context by
+// an authorizer of an HTTP API.
+//
+// For JWT authorizers these are the token claims, all as strings. For Lambda
+// authorizers these are the context values returned by the authorizer.
+func ClaimsFromV2HTTPRequest(request *events.APIGatewayV2HTTPRequest) Claims {
+	a := request.RequestContext.Authorizer
+	if a == nil {
+		return Claims{}
+	}
+
+	claims := make(Claims)
+
+	if a.JWT != nil {
+		for k, v := range a.JWT.Claims {
+			claims[k] = v
+		}
+	}
+
+	for k, v := range a.Lambda {
+		claims[k] = v
+	}
+
+	return claims
+}
+
+func claimsFromAuthorizer(a map[string]interface{}) Claims {
+	claims := make(Claims, len(a))
+
+	// Cognito user pool authorizers nest the token claims
+	if nested, ok := a["claims"].(map[string]interface{}); ok {
+		for k, v := range nested {
+			claims[k] = v
+		}
+
+		return claims
+	}
+
+	for k, v := range a {
+		claims[k] = v
+	}
+
+	return claims
+}
@@ -0,0 +1,205 @@
+package authorizer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const testMethodArn = "arn:aws:execute-api:us-east-1:123456789012:abcdef1234/prod/GET/pets/1"
+
+func TestAuthorizerResponses(t *testing.T) {
+	testStructs := []struct {
+		Response any
+		Expected string
+	}{
+		{
+			Response: Allow("user-1", testMethodArn, map[string]interface{}{"scope": "read"}),
+			Expected: `{"principalId":"user-1","policyDocument":{"Version":"2012-10-17","Statement":[{"Action":["execute-api:Invoke"],"Effect":"Allow","Resource":["` + testMethodArn + `"]}]},"context":{"scope":"read"}}`,
+		},
+		{
+			Response: Allow("user-1", WildcardMethodArn(testMethodArn), nil),
+			Expected: `{"principalId":"user-1","policyDocument":{"Version":"2012-10-17","Statement":[{"Action":["execute-api:Invoke"],"Effect":"Allow","Resource":["arn:aws:execute-api:us-east-1:123456789012:abcdef1234/prod/*/*"]}]}}`,
+		},
+		{
+			Response: Deny("user-1", testMethodArn),
+			Expected: `{"principalId":"user-1","policyDocument":{"Version":"2012-10-17","Statement":[{"Action":["execute-api:Invoke"],"Effect":"Deny","Resource":["` + testMethodArn + `"]}]}}`,
+		},
+		{
+			Response: AuthorizerResponse("user-1", EffectDeny, nil),
+			Expected: `{"principalId":"user-1","policyDocument":{"Version":"2012-10-17","Statement":[{"Action":["execute-api:Invoke"],"Effect":"Deny","Resource":["*"]}]}}`,
+		},
+		{
+			Response: AuthorizerResponse("user-1", EffectAllow, nil, "arn:a", "arn:b"),
+			Expected: `{"principalId":"user-1","policyDocument":{"Version":"2012-10-17","Statement":[{"Action":["execute-api:Invoke"],"Effect":"Allow","Resource":["arn:a","arn:b"]}]}}`,
+		},
+		{
+			Response: SimpleResponse(true, map[string]interface{}{"sub": "user-1", "admin": false}),
+			Expected: `{"isAuthorized":true,"context":{"admin":false,"sub":"user-1"}}`,
+		},
+		{
+			Response: SimpleResponse(false, nil),
+			Expected: `{"isAuthorized":false}`,
+		},
+	}
+
+	for i, testStruct := range testStructs {
+		b, err := json.Marshal(testStruct.Response)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := string(b); got != testStruct.Expected {
+			t.Errorf("Expected %s, got %s on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+}
+
+func TestWildcardMethodArn(t *testing.T) {
+	testStructs := []struct {
+		Input    string
+		Expected string
+	}{
+		{Input: testMethodArn, Expected: "arn:aws:execute-api:us-east-1:123456789012:abcdef1234/prod/*/*"},
+		{Input: "arn:aws:execute-api:us-east-1:123456789012:abcdef1234/prod/GET", Expected: "arn:aws:execute-api:us-east-1:123456789012:abcdef1234/prod/*/*"},
+		{Input: "arn:aws:execute-api:us-east-1:123456789012:abcdef1234", Expected: "arn:aws:execute-api:us-east-1:123456789012:abcdef1234"},
+		{Input: "", Expected: ""},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := WildcardMethodArn(testStruct.Input); got != testStruct.Expected {
+			t.Errorf("Expected %s, got %s on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+}
+
+func TestClaimsContext(t *testing.T) {
+	var claims Claims
+
+	if err := json.Unmarshal([]byte(`{"sub":"user-1","exp":1709294400,"admin":true,"groups":["a","b"],"address":{"country":"US"},"nickname":null}`), &claims); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(ClaimsContext(claims))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"address":"{\"country\":\"US\"}","admin":true,"exp":1709294400,"groups":"[\"a\",\"b\"]","sub":"user-1"}`
+	if got := string(b); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestClaimsFromRequests(t *testing.T) {
+	testStructs := []struct {
+		Claims   Claims
+		Expected map[string]string
+	}{
+		{
+			Claims: ClaimsFromProxyRequest(&events.APIGatewayProxyRequest{
+				RequestContext: events.APIGatewayProxyRequestContext{
+					Authorizer: map[string]interface{}{"principalId": "user-1", "scope": "read"},
+				},
+			}),
+			Expected: map[string]string{"principalId": "user-1", "scope": "read"},
+		},
+		{
+			Claims: ClaimsFromProxyRequest(&events.APIGatewayProxyRequest{
+				RequestContext: events.APIGatewayProxyRequestContext{
+					Authorizer: map[string]interface{}{"claims": map[string]interface{}{"sub": "user-1", "email": "a@example.com"}},
+				},
+			}),
+			Expected: map[string]string{"sub": "user-1", "email": "a@example.com"},
+		},
+		{
+			Claims:   ClaimsFromProxyRequest(&events.APIGatewayProxyRequest{}),
+			Expected: map[string]string{},
+		},
+		{
+			Claims: ClaimsFromWebsocketRequest(&events.APIGatewayWebsocketProxyRequest{
+				RequestContext: events.APIGatewayWebsocketProxyRequestContext{
+					Authorizer: map[string]interface{}{"principalId": "user-1"},
+				},
+			}),
+			Expected: map[string]string{"principalId": "user-1"},
+		},
+		{
+			Claims:   ClaimsFromWebsocketRequest(&events.APIGatewayWebsocketProxyRequest{}),
+			Expected: map[string]string{},
+		},
+		{
+			Claims: ClaimsFromV2HTTPRequest(&events.APIGatewayV2HTTPRequest{
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					Authorizer: &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+						JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{
+							Claims: map[string]string{"sub": "user-1", "iss": "https://issuer.example.com"},
+						},
+					},
+				},
+			}),
+			Expected: map[string]string{"sub": "user-1", "iss": "https://issuer.example.com"},
+		},
+		{
+			Claims: ClaimsFromV2HTTPRequest(&events.APIGatewayV2HTTPRequest{
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					Authorizer: &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+						Lambda: map[string]interface{}{"sub": "user-1"},
+					},
+				},
+			}),
+			Expected: map[string]string{"sub": "user-1"},
+		},
+		{
+			Claims:   ClaimsFromV2HTTPRequest(&events.APIGatewayV2HTTPRequest{}),
+			Expected: map[string]string{},
+		},
+	}
+
+	for i, testStruct := range testStructs {
+		if len(testStruct.Claims) != len(testStruct.Expected) {
+			t.Errorf("Expected %v, got %v on iteration %d", testStruct.Expected, testStruct.Claims, i)
+			continue
+		}
+
+		for k, v := range testStruct.Expected {
+			if got := testStruct.Claims.String(k); got != v {
+				t.Errorf("Expected %s, got %s on iteration %d", v, got, i)
+			}
+		}
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	keys := NewAPIKeys(map[string]string{
+		"key-one": "client-1",
+		"key-two": "client-2",
+		"":        "nobody",
+	})
+
+	testStructs := []struct {
+		Input     string
+		Principal string
+		Valid     bool
+	}{
+		{Input: "key-one", Principal: "client-1", Valid: true},
+		{Input: "key-two", Principal: "client-2", Valid: true},
+		{Input: "", Principal: "", Valid: false},
+		{Input: "key-three", Principal: "", Valid: false},
+		{Input: "key-on", Principal: "", Valid: false},
+		{Input: "KEY-ONE", Principal: "", Valid: false},
+		{Input: "key-one ", Principal: "", Valid: false},
+	}
+
+	for i, testStruct := range testStructs {
+		principal, valid := keys.Verify(testStruct.Input)
+		if principal != testStruct.Principal || valid != testStruct.Valid {
+			t.Errorf("Expected %s %t, got %s %t on iteration %d", testStruct.Principal, testStruct.Valid, principal, valid, i)
+		}
+	}
+
+	if _, valid := NewAPIKeys(nil).Verify("key-one"); valid {
+		t.Errorf("Expected no keys to be valid")
+	}
+}
@@ -0,0 +1,166 @@
+package authorizer
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// JWK is a JSON Web Key as defined by RFC 7517. Only the members needed to
+// verify HS256, RS256 and ES256 signatures are decoded.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+
+	// RSA public key members
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC public key members
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+
+	// Symmetric key member
+	K string `json:"k,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set as defined by RFC 7517.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// key is a JWK decoded into a form usable for signature verification
+type key struct {
+	kid    string
+	alg    string
+	public any
+}
+
+// ParseJWKS parses a JSON Web Key Set, such as the contents of a
+// .well-known/jwks.json file.
+func ParseJWKS(data []byte) (*JWKS, error) {
+	var set JWKS
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse JWKS: %w", err)
+	}
+
+	return &set, nil
+}
+
+// decode returns the usable verification keys of the set. Keys intended for
+// encryption or of an unsupported type are skipped.
+func (s *JWKS) decode() ([]key, error) {
+	keys := make([]key, 0, len(s.Keys))
+
+	for i, k := range s.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		pub, err := k.decode()
+		if errors.Is(err, errUnsupportedKey) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("decode JWK %d (kid %q): %w", i, k.Kid, err)
+		}
+
+		keys = append(keys, key{kid: k.Kid, alg: k.Alg, public: pub})
+	}
+
+	return keys, nil
+}
+
+var errUnsupportedKey = errors.New("unsupported key type")
+
+// minRSAKeySize is the smallest RSA modulus, in bits, accepted for RS256
+const minRSAKeySize = 2048
+
+func (k JWK) decode() (any, error) {
+	switch k.Kty {
+	case "oct":
+		secret, err := decodeSegment(k.K)
+		if err != nil {
+			return nil, fmt.Errorf("invalid k: %w", err)
+		}
+
+		if len(secret) == 0 {
+			return nil, errors.New("empty symmetric key")
+		}
+
+		return secret, nil
+	case "RSA":
+		n, err := decodeSegment(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid n: %w", err)
+		}
+
+		e, err := decodeSegment(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid e: %w", err)
+		}
+
+		exponent := new(big.Int).SetBytes(e)
+		if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA public key")
+		}
+
+		modulus := new(big.Int).SetBytes(n)
+		if modulus.BitLen() < minRSAKeySize {
+			return nil, fmt.Errorf("RSA key is %d bits, at least %d are required", modulus.BitLen(), minRSAKeySize)
+		}
+
+		return &rsa.PublicKey{
+			N: modulus,
+			E: int(exponent.Int64()),
+		}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, errUnsupportedKey
+		}
+
+		x, err := decodeSegment(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x: %w", err)
+		}
+
+		y, err := decodeSegment(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y: %w", err)
+		}
+
+		if len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid EC public key")
+		}
+
+		// crypto/ecdh rejects points that are not on the curve
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, fmt.Errorf("invalid EC public key: %w", err)
+		}
+
+		pub := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+
+		return pub, nil
+	}
+
+	return nil, errUnsupportedKey
+}
+
+func decodeSegment(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
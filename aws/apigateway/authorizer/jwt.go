@@ -0,0 +1,387 @@
+package authorizer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Vitality-South/goutil/slice"
+)
+
+// Errors returned when a token fails verification. Verify wraps them with
+// additional detail, so compare with errors.Is.
+var (
+	ErrMalformedToken       = errors.New("malformed token")
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrUnknownKey           = errors.New("no matching verification key")
+	ErrInvalidSignature     = errors.New("invalid token signature")
+	ErrTokenExpired         = errors.New("token is expired")
+	ErrTokenNotYetValid     = errors.New("token is not valid yet")
+	ErrInvalidIssuer        = errors.New("invalid token issuer")
+	ErrInvalidAudience      = errors.New("invalid token audience")
+	ErrMissingClaim         = errors.New("missing required claim")
+)
+
+// Supported JWS signing algorithms.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+)
+
+// Claims are the claims of a verified token.
+type Claims map[string]interface{}
+
+// String returns the claim name as a string, or an empty string if it is
+// missing or not a string.
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// Subject returns the "sub" claim.
+func (c Claims) Subject() string {
+	return c.String("sub")
+}
+
+// Issuer returns the "iss" claim.
+func (c Claims) Issuer() string {
+	return c.String("iss")
+}
+
+// Audience returns the "aud" claim, which may be a single string or an array
+// of strings.
+func (c Claims) Audience() []string {
+	switch v := c["aud"].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		out := make([]string, 0, len(v))
+
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				out = append(out, s)
+			}
+		}
+
+		return out
+	case []string:
+		return v
+	}
+
+	return nil
+}
+
+// Time returns a NumericDate claim, such as "exp", as a time.Time. The
+// second return value is false if the claim is missing or not a number.
+func (c Claims) Time(name string) (time.Time, bool) {
+	var f float64
+
+	switch v := c[name].(type) {
+	case float64:
+		f = v
+	case json.Number:
+		n, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		f = n
+	default:
+		return time.Time{}, false
+	}
+
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return time.Time{}, false
+	}
+
+	sec, frac := math.Modf(f)
+
+	return time.Unix(int64(sec), int64(frac*1e9)), true
+}
+
+type Option struct {
+	issuers    []string
+	audiences  []string
+	algorithms []string
+	leeway     time.Duration
+	required   []string
+	now        func() time.Time
+}
+
+// WithIssuer requires the "iss" claim to equal one of the given issuers.
+func WithIssuer(iss ...string) func(o *Option) {
+	return func(o *Option) {
+		o.issuers = iss
+	}
+}
+
+// WithAudience requires the "aud" claim to contain one of the given
+// audiences.
+func WithAudience(aud ...string) func(o *Option) {
+	return func(o *Option) {
+		o.audiences = aud
+	}
+}
+
+// WithAlgorithms restricts the accepted signing algorithms. The default is
+// HS256, RS256 and ES256.
+func WithAlgorithms(alg ...string) func(o *Option) {
+	return func(o *Option) {
+		o.algorithms = alg
+	}
+}
+
+// WithLeeway sets the allowed clock skew when checking "exp", "nbf" and
+// "iat". The default is one minute.
+func WithLeeway(d time.Duration) func(o *Option) {
+	return func(o *Option) {
+		o.leeway = d
+	}
+}
+
+// WithRequiredClaims requires the given claims to be present. "exp" is
+// always required.
+func WithRequiredClaims(names ...string) func(o *Option) {
+	return func(o *Option) {
+		o.required = names
+	}
+}
+
+// WithClock sets the function used to get the current time.
+func WithClock(now func() time.Time) func(o *Option) {
+	return func(o *Option) {
+		o.now = now
+	}
+}
+
+// Verifier verifies JWTs against a locally supplied key set. It is safe for
+// concurrent use.
+type Verifier struct {
+	keys []key
+	op   Option
+}
+
+// NewVerifier returns a Verifier for tokens signed by one of the keys in set.
+//
+// The key set is supplied by the caller rather than fetched, so a Lambda
+// authorizer can bundle it or load it once during initialization.
+func NewVerifier(set *JWKS, options ...func(*Option)) (*Verifier, error) {
+	op := Option{
+		algorithms: []string{HS256, RS256, ES256},
+		leeway:     time.Minute,
+		now:        time.Now,
+	}
+
+	for _, o := range options {
+		o(&op)
+	}
+
+	keys, err := set.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS contains no usable verification keys")
+	}
+
+	return &Verifier{keys: keys, op: op}, nil
+}
+
+// Verify checks the signature and registered claims of a compact serialized
+// JWT and returns its claims. A "Bearer " prefix is removed from token.
+func (v *Verifier) Verify(token string) (Claims, error) {
+	token = BearerToken(token)
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 segments, got %d", ErrMalformedToken, len(parts))
+	}
+
+	var header struct {
+		Alg  string `json:"alg"`
+		Kid  string `json:"kid"`
+		Crit []any  `json:"crit"`
+	}
+
+	if err := decodeJSONSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrMalformedToken, err)
+	}
+
+	if len(header.Crit) > 0 {
+		return nil, fmt.Errorf("%w: unsupported critical header", ErrMalformedToken)
+	}
+
+	if !slice.Contains(v.op.algorithms, header.Alg) {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, header.Alg)
+	}
+
+	sig, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrMalformedToken, err)
+	}
+
+	if err := v.verifySignature(header.Alg, header.Kid, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+
+	if err := decodeJSONSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrMalformedToken, err)
+	}
+
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (v *Verifier) verifySignature(alg, kid string, signed, sig []byte) error {
+	matched := false
+
+	for _, k := range v.keys {
+		if kid != "" && k.kid != kid {
+			continue
+		}
+
+		// a key bound to an algorithm is never used for another, which
+		// prevents algorithm confusion attacks
+		if k.alg != "" && k.alg != alg {
+			continue
+		}
+
+		ok, usable := verifyWithKey(alg, k.public, signed, sig)
+		if !usable {
+			continue
+		}
+
+		matched = true
+
+		if ok {
+			return nil
+		}
+	}
+
+	if !matched {
+		return fmt.Errorf("%w: alg %q kid %q", ErrUnknownKey, alg, kid)
+	}
+
+	return ErrInvalidSignature
+}
+
+// verifyWithKey reports whether sig is valid, and whether pub can be used
+// with alg at all
+func verifyWithKey(alg string, pub any, signed, sig []byte) (ok bool, usable bool) {
+	digest := sha256.Sum256(signed)
+
+	switch alg {
+	case HS256:
+		secret, isSecret := pub.([]byte)
+		if !isSecret {
+			return false, false
+		}
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signed)
+
+		return hmac.Equal(mac.Sum(nil), sig), true
+	case RS256:
+		rsaKey, isRSA := pub.(*rsa.PublicKey)
+		if !isRSA {
+			return false, false
+		}
+
+		return rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], sig) == nil, true
+	case ES256:
+		ecKey, isEC := pub.(*ecdsa.PublicKey)
+		if !isEC {
+			return false, false
+		}
+
+		// JWS uses the fixed width R || S encoding rather than ASN.1
+		if len(sig) != 64 {
+			return false, true
+		}
+
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+
+		return ecdsa.Verify(ecKey, digest[:], r, s), true
+	}
+
+	return false, false
+}
+
+func (v *Verifier) validateClaims(c Claims) error {
+	now := v.op.now()
+
+	exp, ok := c.Time("exp")
+	if !ok {
+		return fmt.Errorf("%w: exp", ErrMissingClaim)
+	}
+
+	if !now.Before(exp.Add(v.op.leeway)) {
+		return fmt.Errorf("%w: expired at %s", ErrTokenExpired, exp.UTC().Format(time.RFC3339))
+	}
+
+	if nbf, ok := c.Time("nbf"); ok && now.Add(v.op.leeway).Before(nbf) {
+		return fmt.Errorf("%w: valid from %s", ErrTokenNotYetValid, nbf.UTC().Format(time.RFC3339))
+	}
+
+	if iat, ok := c.Time("iat"); ok && now.Add(v.op.leeway).Before(iat) {
+		return fmt.Errorf("%w: issued in the future at %s", ErrTokenNotYetValid, iat.UTC().Format(time.RFC3339))
+	}
+
+	if len(v.op.issuers) > 0 && !slice.Contains(v.op.issuers, c.Issuer()) {
+		return fmt.Errorf("%w: %q", ErrInvalidIssuer, c.Issuer())
+	}
+
+	if len(v.op.audiences) > 0 {
+		aud := c.Audience()
+
+		if !slice.ContainsFunc(aud, func(a string) bool { return slice.Contains(v.op.audiences, a) }) {
+			return fmt.Errorf("%w: %q", ErrInvalidAudience, aud)
+		}
+	}
+
+	for _, name := range v.op.required {
+		if _, ok := c[name]; !ok {
+			return fmt.Errorf("%w: %s", ErrMissingClaim, name)
+		}
+	}
+
+	return nil
+}
+
+// BearerToken returns the token from an Authorization header value, removing
+// a case insensitive "Bearer " prefix if present.
+func BearerToken(authorization string) string {
+	s := strings.TrimSpace(authorization)
+
+	if len(s) > 7 && strings.EqualFold(s[:7], "bearer ") {
+		return strings.TrimSpace(s[7:])
+	}
+
+	return s
+}
+
+func decodeJSONSegment(s string, v any) error {
+	b, err := decodeSegment(s)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
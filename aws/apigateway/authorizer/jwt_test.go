@@ -0,0 +1,381 @@
+package authorizer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+var (
+	testSecret = []byte("a very secret HMAC key of 32 b!!")
+	testRSAKey *rsa.PrivateKey
+	testECKey  *ecdsa.PrivateKey
+	keysOnce   sync.Once
+)
+
+// testKeys returns the test signing keys, generating them once
+func testKeys(t *testing.T) (*rsa.PrivateKey, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	keysOnce.Do(func() {
+		var err error
+
+		if testRSAKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			t.Fatal(err)
+		}
+
+		if testECKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	return testRSAKey, testECKey
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func octJWK(kid string, secret []byte) JWK {
+	return JWK{Kty: "oct", Kid: kid, K: b64(secret)}
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) JWK {
+	return JWK{Kty: "RSA", Kid: kid, Alg: RS256, Use: "sig", N: b64(key.N.Bytes()), E: b64([]byte{1, 0, 1})}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) JWK {
+	return JWK{Kty: "EC", Kid: kid, Crv: "P-256", X: b64(key.X.FillBytes(make([]byte, 32))), Y: b64(key.Y.FillBytes(make([]byte, 32)))}
+}
+
+// sign returns a compact serialized JWT of header and claims signed with
+// signer, which is a []byte HMAC secret, an RSA or an ECDSA private key, or
+// nil for an empty signature
+func sign(t *testing.T, header map[string]any, claims map[string]any, signer any) string {
+	t.Helper()
+
+	h, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := b64(h) + "." + b64(c)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+
+	switch k := signer.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+
+	return signed + "." + b64(sig)
+}
+
+// validClaims returns claims valid at testNow
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub": "user-1",
+		"iss": "https://issuer.example.com",
+		"aud": "api",
+		"iat": testNow.Add(-time.Minute).Unix(),
+		"exp": testNow.Add(time.Hour).Unix(),
+	}
+}
+
+// with returns a copy of claims with the given claim set, or removed if v is
+// nil
+func with(claims map[string]any, name string, v any) map[string]any {
+	out := make(map[string]any, len(claims))
+
+	for k, c := range claims {
+		out[k] = c
+	}
+
+	if v == nil {
+		delete(out, name)
+	} else {
+		out[name] = v
+	}
+
+	return out
+}
+
+// tamper returns token with its claims replaced but its signature kept
+func tamper(token string) string {
+	parts := strings.Split(token, ".")
+	claims, _ := json.Marshal(with(validClaims(), "sub", "admin"))
+
+	return parts[0] + "." + b64(claims) + "." + parts[2]
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey, ecKey := testKeys(t)
+
+	otherRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := &JWKS{Keys: []JWK{
+		octJWK("hmac", testSecret),
+		rsaJWK("rsa", &rsaKey.PublicKey),
+		ecJWK("ec", &ecKey.PublicKey),
+	}}
+
+	// an HS256 token signed with the PEM encoded RSA public key as the
+	// secret, as in the classic algorithm confusion attack
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	hs := map[string]any{"alg": HS256, "typ": "JWT", "kid": "hmac"}
+	rs := map[string]any{"alg": RS256, "typ": "JWT", "kid": "rsa"}
+	es := map[string]any{"alg": ES256, "typ": "JWT", "kid": "ec"}
+
+	claims := validClaims()
+
+	testStructs := []struct {
+		Options  []func(*Option)
+		Token    string
+		Expected error
+	}{
+		// valid and tampered tokens
+		{Token: sign(t, hs, claims, testSecret)},
+		{Token: sign(t, rs, claims, rsaKey)},
+		{Token: sign(t, es, claims, ecKey)},
+		{Token: "Bearer " + sign(t, rs, claims, rsaKey)},
+		{Token: sign(t, map[string]any{"alg": ES256}, claims, ecKey)},
+		{Token: tamper(sign(t, hs, claims, testSecret)), Expected: ErrInvalidSignature},
+		{Token: tamper(sign(t, rs, claims, rsaKey)), Expected: ErrInvalidSignature},
+		{Token: tamper(sign(t, es, claims, ecKey)), Expected: ErrInvalidSignature},
+		{Token: sign(t, hs, claims, []byte("wrong secret")), Expected: ErrInvalidSignature},
+		{Token: sign(t, rs, claims, otherRSA), Expected: ErrInvalidSignature},
+		{Token: sign(t, rs, claims, nil), Expected: ErrInvalidSignature},
+		{Token: sign(t, es, claims, nil), Expected: ErrInvalidSignature},
+		{Token: "a.b", Expected: ErrMalformedToken},
+		{Token: "!!.e30.", Expected: ErrMalformedToken},
+
+		// algorithm confusion
+		{Token: sign(t, map[string]any{"alg": HS256, "kid": "rsa"}, claims, rsaPEM), Expected: ErrUnknownKey},
+		{Token: sign(t, map[string]any{"alg": HS256}, claims, rsaPEM), Expected: ErrInvalidSignature},
+		{Token: sign(t, map[string]any{"alg": HS256}, claims, der), Expected: ErrInvalidSignature},
+		{Token: sign(t, map[string]any{"alg": RS256, "kid": "hmac"}, claims, rsaKey), Expected: ErrUnknownKey},
+		{Token: sign(t, map[string]any{"alg": ES256, "kid": "rsa"}, claims, ecKey), Expected: ErrUnknownKey},
+		{Options: []func(*Option){WithAlgorithms(RS256)}, Token: sign(t, hs, claims, testSecret), Expected: ErrUnsupportedAlgorithm},
+		{Token: sign(t, map[string]any{"alg": "none"}, claims, nil), Expected: ErrUnsupportedAlgorithm},
+		{Token: sign(t, map[string]any{"alg": "None"}, claims, nil), Expected: ErrUnsupportedAlgorithm},
+		{Options: []func(*Option){WithAlgorithms("none")}, Token: sign(t, map[string]any{"alg": "none"}, claims, nil), Expected: ErrUnknownKey},
+
+		// unknown keys and critical headers
+		{Token: sign(t, map[string]any{"alg": RS256, "kid": "rotated"}, claims, rsaKey), Expected: ErrUnknownKey},
+		{Token: sign(t, map[string]any{"alg": RS256, "kid": "rsa", "crit": []string{"exp"}, "exp": 1}, claims, rsaKey), Expected: ErrMalformedToken},
+		{Token: sign(t, map[string]any{"alg": RS256, "kid": "rsa", "crit": []string{"b64"}, "b64": false}, claims, rsaKey), Expected: ErrMalformedToken},
+
+		// exp, nbf and iat with the default leeway of one minute
+		{Token: sign(t, rs, with(claims, "exp", nil), rsaKey), Expected: ErrMissingClaim},
+		{Token: sign(t, rs, with(claims, "exp", "tomorrow"), rsaKey), Expected: ErrMissingClaim},
+		{Token: sign(t, rs, with(claims, "exp", testNow.Add(-30*time.Second).Unix()), rsaKey)},
+		{Token: sign(t, rs, with(claims, "exp", testNow.Add(-time.Minute).Unix()), rsaKey), Expected: ErrTokenExpired},
+		{Token: sign(t, rs, with(claims, "nbf", testNow.Add(30*time.Second).Unix()), rsaKey)},
+		{Token: sign(t, rs, with(claims, "nbf", testNow.Add(2*time.Minute).Unix()), rsaKey), Expected: ErrTokenNotYetValid},
+		{Token: sign(t, rs, with(claims, "iat", testNow.Add(30*time.Second).Unix()), rsaKey)},
+		{Token: sign(t, rs, with(claims, "iat", testNow.Add(2*time.Minute).Unix()), rsaKey), Expected: ErrTokenNotYetValid},
+
+		// exp, nbf and iat without leeway
+		{Options: []func(*Option){WithLeeway(0)}, Token: sign(t, rs, with(claims, "exp", testNow.Add(time.Second).Unix()), rsaKey)},
+		{Options: []func(*Option){WithLeeway(0)}, Token: sign(t, rs, with(claims, "exp", testNow.Unix()), rsaKey), Expected: ErrTokenExpired},
+		{Options: []func(*Option){WithLeeway(0)}, Token: sign(t, rs, with(claims, "exp", testNow.Add(-30*time.Second).Unix()), rsaKey), Expected: ErrTokenExpired},
+		{Options: []func(*Option){WithLeeway(0)}, Token: sign(t, rs, with(claims, "nbf", testNow.Unix()), rsaKey)},
+		{Options: []func(*Option){WithLeeway(0)}, Token: sign(t, rs, with(claims, "nbf", testNow.Add(30*time.Second).Unix()), rsaKey), Expected: ErrTokenNotYetValid},
+		{Options: []func(*Option){WithLeeway(0)}, Token: sign(t, rs, with(claims, "iat", testNow.Add(30*time.Second).Unix()), rsaKey), Expected: ErrTokenNotYetValid},
+		{Options: []func(*Option){WithLeeway(5 * time.Minute)}, Token: sign(t, rs, with(claims, "exp", testNow.Add(-4*time.Minute).Unix()), rsaKey)},
+
+		// iss and aud
+		{Options: []func(*Option){WithIssuer("https://issuer.example.com")}, Token: sign(t, rs, claims, rsaKey)},
+		{Options: []func(*Option){WithIssuer("https://other.example.com", "https://issuer.example.com")}, Token: sign(t, rs, claims, rsaKey)},
+		{Options: []func(*Option){WithIssuer("https://other.example.com")}, Token: sign(t, rs, claims, rsaKey), Expected: ErrInvalidIssuer},
+		{Options: []func(*Option){WithIssuer("https://issuer.example.com")}, Token: sign(t, rs, with(claims, "iss", nil), rsaKey), Expected: ErrInvalidIssuer},
+		{Options: []func(*Option){WithAudience("api")}, Token: sign(t, rs, claims, rsaKey)},
+		{Options: []func(*Option){WithAudience("web")}, Token: sign(t, rs, claims, rsaKey), Expected: ErrInvalidAudience},
+		{Options: []func(*Option){WithAudience("api")}, Token: sign(t, rs, with(claims, "aud", []string{"web", "api"}), rsaKey)},
+		{Options: []func(*Option){WithAudience("admin", "api")}, Token: sign(t, rs, with(claims, "aud", []string{"web", "api"}), rsaKey)},
+		{Options: []func(*Option){WithAudience("admin")}, Token: sign(t, rs, with(claims, "aud", []string{"web", "api"}), rsaKey), Expected: ErrInvalidAudience},
+		{Options: []func(*Option){WithAudience("api")}, Token: sign(t, rs, with(claims, "aud", []string{}), rsaKey), Expected: ErrInvalidAudience},
+		{Options: []func(*Option){WithAudience("api")}, Token: sign(t, rs, with(claims, "aud", nil), rsaKey), Expected: ErrInvalidAudience},
+
+		// required claims
+		{Options: []func(*Option){WithRequiredClaims("sub", "iat")}, Token: sign(t, rs, claims, rsaKey)},
+		{Options: []func(*Option){WithRequiredClaims("scope")}, Token: sign(t, rs, claims, rsaKey), Expected: ErrMissingClaim},
+	}
+
+	for i, testStruct := range testStructs {
+		options := append([]func(*Option){WithClock(func() time.Time { return testNow })}, testStruct.Options...)
+
+		v, err := NewVerifier(set, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := v.Verify(testStruct.Token)
+
+		if testStruct.Expected == nil {
+			if err != nil {
+				t.Errorf("Expected no error, got %v on iteration %d", err, i)
+			} else if got.Subject() != "user-1" {
+				t.Errorf("Expected %s, got %s on iteration %d", "user-1", got.Subject(), i)
+			}
+
+			continue
+		}
+
+		if !errors.Is(err, testStruct.Expected) || got != nil {
+			t.Errorf("Expected %v, got %v (%v) on iteration %d", testStruct.Expected, err, got, i)
+		}
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	rsaKey, ecKey := testKeys(t)
+
+	smallRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testStructs := []struct {
+		Input string
+		Valid bool
+	}{
+		{Input: `{"keys":[]}`, Valid: false},
+		{Input: `{"keys":[{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`, Valid: false},
+		{Input: `{"keys":[{"kty":"oct","use":"enc","k":"c2VjcmV0"}]}`, Valid: false},
+		{Input: `{"keys":[{"kty":"oct","k":""}]}`, Valid: false},
+		{Input: `{"keys":[{"kty":"RSA","n":"AQAB","e":"AQ"}]}`, Valid: false},
+		{Input: `{"keys":[{"kty":"RSA","n":"` + base64.RawURLEncoding.EncodeToString(smallRSA.N.Bytes()) + `","e":"AQAB"}]}`, Valid: false},
+		{Input: `{"keys":[{"kty":"EC","crv":"P-256","x":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA","y":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"}]}`, Valid: false},
+		{Input: `{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`, Valid: true},
+	}
+
+	for i, testStruct := range testStructs {
+		set, err := ParseJWKS([]byte(testStruct.Input))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := NewVerifier(set); (err == nil) != testStruct.Valid {
+			t.Errorf("Expected valid %t, got %v on iteration %d", testStruct.Valid, err, i)
+		}
+	}
+
+	data, err := json.Marshal(JWKS{Keys: []JWK{rsaJWK("rsa", &rsaKey.PublicKey), ecJWK("ec", &ecKey.PublicKey)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := ParseJWKS(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewVerifier(set); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if _, err := ParseJWKS([]byte("{")); err == nil {
+		t.Errorf("Expected an error parsing invalid JSON")
+	}
+}
+
+func TestClaims(t *testing.T) {
+	var claims Claims
+
+	if err := json.Unmarshal([]byte(`{"sub":"user-1","iss":"https://issuer.example.com","aud":["a","b",3],"exp":1709294400.5,"nbf":"soon"}`), &claims); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := claims.Subject(); got != "user-1" {
+		t.Errorf("Expected %s, got %s", "user-1", got)
+	}
+
+	if got := claims.Issuer(); got != "https://issuer.example.com" {
+		t.Errorf("Expected %s, got %s", "https://issuer.example.com", got)
+	}
+
+	if got := claims.Audience(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("Expected %v, got %v", []string{"a", "b"}, got)
+	}
+
+	if got := (Claims{"aud": "a"}).Audience(); len(got) != 1 || got[0] != "a" {
+		t.Errorf("Expected %v, got %v", []string{"a"}, got)
+	}
+
+	if got, ok := claims.Time("exp"); !ok || !got.Equal(testNow.Add(500*time.Millisecond)) {
+		t.Errorf("Expected %s, got %s", testNow.Add(500*time.Millisecond), got)
+	}
+
+	if _, ok := claims.Time("nbf"); ok {
+		t.Errorf("Expected a string nbf to not be a time")
+	}
+
+	if _, ok := claims.Time("iat"); ok {
+		t.Errorf("Expected a missing iat to not be a time")
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	testStructs := []struct {
+		Input    string
+		Expected string
+	}{
+		{Input: "", Expected: ""},
+		{Input: "abc.def.ghi", Expected: "abc.def.ghi"},
+		{Input: "Bearer abc.def.ghi", Expected: "abc.def.ghi"},
+		{Input: "  bearer   abc.def.ghi ", Expected: "abc.def.ghi"},
+		{Input: "BEARER abc", Expected: "abc"},
+		{Input: "Bearer", Expected: "Bearer"},
+		{Input: "Basic dXNlcjpwYXNz", Expected: "Basic dXNlcjpwYXNz"},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := BearerToken(testStruct.Input); got != testStruct.Expected {
+			t.Errorf("Expected %s, got %s on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+}
@@ -0,0 +1,445 @@
+package apigateway
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// HostCookiePrefix is the cookie name prefix browsers only accept on cookies
+// that are Secure, have Path "/" and no Domain, locking the cookie to the
+// host that set it.
+const HostCookiePrefix = "__Host-"
+
+// Errors returned by CookieCodec.
+var (
+	ErrInvalidCookie  = errors.New("invalid cookie value")
+	ErrExpiredCookie  = errors.New("expired cookie value")
+	ErrCookieTooLarge = errors.New("cookie value too large")
+)
+
+const (
+	// maxCookieSize is the smallest per cookie limit enforced by browsers
+	maxCookieSize = 4096
+
+	// maxCookieClockSkew is how far in the future a value may have been
+	// created, allowing for clocks of servers sharing keys to differ
+	maxCookieClockSkew = time.Minute
+)
+
+// cookieEncoding rejects non-canonical encodings so every value has exactly
+// one encoding
+var cookieEncoding = base64.RawURLEncoding.Strict()
+
+// ProxyRequestCookies returns the cookies sent with an API Gateway Proxy
+// request, which carries them in the Cookie header.
+func ProxyRequestCookies(request *events.APIGatewayProxyRequest) []*http.Cookie {
+	var values []string
+
+	for k, v := range request.MultiValueHeaders {
+		if strings.EqualFold(k, "Cookie") {
+			values = append(values, v...)
+		}
+	}
+
+	// Headers duplicates the last value of MultiValueHeaders
+	if len(values) == 0 {
+		for k, v := range request.Headers {
+			if strings.EqualFold(k, "Cookie") {
+				values = append(values, v)
+			}
+		}
+	}
+
+	return parseCookies(values)
+}
+
+// V2HTTPRequestCookies returns the cookies sent with an API Gateway HTTP
+// request, which carries them in the Cookies field.
+func V2HTTPRequestCookies(request *events.APIGatewayV2HTTPRequest) []*http.Cookie {
+	return parseCookies(request.Cookies)
+}
+
+// ProxyRequestCookie returns the named cookie of an API Gateway Proxy
+// request or http.ErrNoCookie if not found. If multiple cookies match the
+// given name, only one cookie will be returned.
+func ProxyRequestCookie(request *events.APIGatewayProxyRequest, name string) (*http.Cookie, error) {
+	return findCookie(ProxyRequestCookies(request), name)
+}
+
+// V2HTTPRequestCookie returns the named cookie of an API Gateway HTTP request
+// or http.ErrNoCookie if not found. If multiple cookies match the given name,
+// only one cookie will be returned.
+func V2HTTPRequestCookie(request *events.APIGatewayV2HTTPRequest, name string) (*http.Cookie, error) {
+	return findCookie(V2HTTPRequestCookies(request), name)
+}
+
+// SetProxyResponseCookies adds a Set-Cookie header to the response for each
+// cookie. An error is returned, and no cookie is added, if any cookie is
+// invalid.
+func SetProxyResponseCookies(response *events.APIGatewayProxyResponse, cookies ...*http.Cookie) error {
+	values, err := cookieStrings(cookies)
+	if err != nil {
+		return err
+	}
+
+	if response.MultiValueHeaders == nil {
+		response.MultiValueHeaders = make(map[string][]string)
+	}
+
+	response.MultiValueHeaders["Set-Cookie"] = append(response.MultiValueHeaders["Set-Cookie"], values...)
+
+	return nil
+}
+
+// SetV2HTTPResponseCookies adds each cookie to the Cookies field of the
+// response, which API Gateway HTTP APIs turn into Set-Cookie headers. An
+// error is returned, and no cookie is added, if any cookie is invalid.
+func SetV2HTTPResponseCookies(response *events.APIGatewayV2HTTPResponse, cookies ...*http.Cookie) error {
+	values, err := cookieStrings(cookies)
+	if err != nil {
+		return err
+	}
+
+	response.Cookies = append(response.Cookies, values...)
+
+	return nil
+}
+
+// NewCookie returns a cookie with secure by default attributes:
+//
+// Path: /
+// Secure: true
+// HttpOnly: true
+// SameSite: Lax
+//
+// A client can then choose to override specific attributes as necessary.
+func NewCookie(name, value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// NewHostCookie returns NewCookie with the name prefixed by "__Host-". Do not
+// set Domain or change Path on the returned cookie or browsers will reject
+// it.
+func NewHostCookie(name, value string) *http.Cookie {
+	return NewCookie(HostCookiePrefix+name, value)
+}
+
+// ExpireCookie returns a cookie that deletes the named cookie when sent to
+// the browser. Path and Domain must match the cookie being deleted.
+func ExpireCookie(c *http.Cookie) *http.Cookie {
+	e := *c
+	e.Value = ""
+	e.MaxAge = -1
+	e.Expires = time.Unix(0, 0)
+
+	return &e
+}
+
+type CookieOption struct {
+	maxAge  time.Duration
+	encrypt bool
+	now     func() time.Time
+}
+
+// WithCookieMaxAge limits how long an encoded value is accepted and sets
+// MaxAge on cookies returned by CookieCodec.NewCookie. The default is 24
+// hours. Zero disables the limit.
+func WithCookieMaxAge(d time.Duration) func(o *CookieOption) {
+	return func(o *CookieOption) {
+		o.maxAge = d
+	}
+}
+
+// WithCookieEncryption encrypts values with AES-256-GCM rather than only
+// signing them with HMAC-SHA256, so clients can not read them.
+func WithCookieEncryption() func(o *CookieOption) {
+	return func(o *CookieOption) {
+		o.encrypt = true
+	}
+}
+
+// WithCookieClock sets the function used to get the current time.
+func WithCookieClock(now func() time.Time) func(o *CookieOption) {
+	return func(o *CookieOption) {
+		o.now = now
+	}
+}
+
+// CookieCodec signs, and optionally encrypts, cookie values so they can hold
+// stateless session data. It is safe for concurrent use.
+//
+// Values are bound to the cookie name and carry their creation time, so a
+// value can not be moved to another cookie or used after the max age.
+type CookieCodec struct {
+	keys []cookieKey
+	op   CookieOption
+}
+
+type cookieKey struct {
+	mac  []byte
+	aead cipher.AEAD
+}
+
+// NewCookieCodec returns a CookieCodec for the given keys, each at least 32
+// bytes long and kept secret. Values are always encoded with the first key
+// and decoded with any of them, so keys can be rotated by adding the new key
+// first and removing the old one once its values have expired.
+func NewCookieCodec(keys [][]byte, options ...func(*CookieOption)) (*CookieCodec, error) {
+	op := CookieOption{
+		maxAge: 24 * time.Hour,
+		now:    time.Now,
+	}
+
+	for _, o := range options {
+		o(&op)
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("cookie codec requires at least one key")
+	}
+
+	c := &CookieCodec{op: op}
+
+	for i, k := range keys {
+		if len(k) < 32 {
+			return nil, fmt.Errorf("cookie key %d is %d bytes, need at least 32", i, len(k))
+		}
+
+		// derive independent keys for signing and encryption
+		ck := cookieKey{mac: deriveKey(k, "goutil cookie mac")}
+
+		block, err := aes.NewCipher(deriveKey(k, "goutil cookie aead"))
+		if err != nil {
+			return nil, err
+		}
+
+		ck.aead, err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		c.keys = append(c.keys, ck)
+	}
+
+	return c, nil
+}
+
+// Encode returns value encoded for the named cookie.
+func (c *CookieCodec) Encode(name, value string) (string, error) {
+	payload := make([]byte, 8, 8+len(value))
+	binary.BigEndian.PutUint64(payload, uint64(c.op.now().Unix()))
+	payload = append(payload, value...)
+
+	k := c.keys[0]
+
+	var encoded string
+
+	if c.op.encrypt {
+		nonce := make([]byte, k.aead.NonceSize(), k.aead.NonceSize()+len(payload)+k.aead.Overhead())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+
+		encoded = cookieEncoding.EncodeToString(k.aead.Seal(nonce, nonce, payload, []byte(name)))
+	} else {
+		encoded = cookieEncoding.EncodeToString(payload) + "." +
+			cookieEncoding.EncodeToString(sign(k.mac, name, payload))
+	}
+
+	if len(name)+len(encoded)+1 > maxCookieSize {
+		return "", ErrCookieTooLarge
+	}
+
+	return encoded, nil
+}
+
+// Decode returns the value of the named cookie encoded by Encode. Values
+// created more than a minute in the future are rejected as invalid.
+func (c *CookieCodec) Decode(name, encoded string) (string, error) {
+	var (
+		payload []byte
+		ok      bool
+	)
+
+	if c.op.encrypt {
+		payload, ok = c.open(name, encoded)
+	} else {
+		payload, ok = c.verify(name, encoded)
+	}
+
+	if !ok || len(payload) < 8 {
+		return "", ErrInvalidCookie
+	}
+
+	created := time.Unix(int64(binary.BigEndian.Uint64(payload[:8])), 0)
+	age := c.op.now().Sub(created)
+
+	if age < -maxCookieClockSkew {
+		return "", ErrInvalidCookie
+	}
+
+	if c.op.maxAge > 0 && age > c.op.maxAge {
+		return "", ErrExpiredCookie
+	}
+
+	return string(payload[8:]), nil
+}
+
+// EncodeJSON returns the JSON encoding of v encoded for the named cookie.
+func (c *CookieCodec) EncodeJSON(name string, v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return c.Encode(name, string(b))
+}
+
+// DecodeJSON decodes a value encoded by EncodeJSON into v.
+func (c *CookieCodec) DecodeJSON(name, encoded string, v any) error {
+	s, err := c.Decode(name, encoded)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(s), v)
+}
+
+// NewCookie returns NewCookie(name, value) with value encoded and MaxAge set
+// to the codec max age.
+func (c *CookieCodec) NewCookie(name, value string) (*http.Cookie, error) {
+	encoded, err := c.Encode(name, value)
+	if err != nil {
+		return nil, err
+	}
+
+	cookie := NewCookie(name, encoded)
+	cookie.MaxAge = int(c.op.maxAge / time.Second)
+
+	return cookie, nil
+}
+
+// Value returns the decoded value of cookie.
+func (c *CookieCodec) Value(cookie *http.Cookie) (string, error) {
+	return c.Decode(cookie.Name, cookie.Value)
+}
+
+func (c *CookieCodec) verify(name, encoded string) ([]byte, bool) {
+	p, s, found := strings.Cut(encoded, ".")
+	if !found {
+		return nil, false
+	}
+
+	payload, err := cookieEncoding.DecodeString(p)
+	if err != nil {
+		return nil, false
+	}
+
+	sig, err := cookieEncoding.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+
+	for _, k := range c.keys {
+		if hmac.Equal(sig, sign(k.mac, name, payload)) {
+			return payload, true
+		}
+	}
+
+	return nil, false
+}
+
+func (c *CookieCodec) open(name, encoded string) ([]byte, bool) {
+	b, err := cookieEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false
+	}
+
+	for _, k := range c.keys {
+		if len(b) < k.aead.NonceSize() {
+			return nil, false
+		}
+
+		payload, err := k.aead.Open(nil, b[:k.aead.NonceSize()], b[k.aead.NonceSize():], []byte(name))
+		if err == nil {
+			return payload, true
+		}
+	}
+
+	return nil, false
+}
+
+func sign(key []byte, name string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
+
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+
+	return mac.Sum(nil)
+}
+
+func parseCookies(values []string) []*http.Cookie {
+	if len(values) == 0 {
+		return nil
+	}
+
+	// net/http only exposes its cookie parser through http.Request
+	r := http.Request{Header: http.Header{"Cookie": values}}
+
+	return r.Cookies()
+}
+
+func findCookie(cookies []*http.Cookie, name string) (*http.Cookie, error) {
+	for _, c := range cookies {
+		if c.Name == name {
+			return c, nil
+		}
+	}
+
+	return nil, http.ErrNoCookie
+}
+
+func cookieStrings(cookies []*http.Cookie) ([]string, error) {
+	values := make([]string, 0, len(cookies))
+
+	for _, c := range cookies {
+		if err := c.Valid(); err != nil {
+			return nil, err
+		}
+
+		if strings.HasPrefix(c.Name, HostCookiePrefix) && (!c.Secure || c.Path != "/" || c.Domain != "") {
+			return nil, fmt.Errorf("cookie %q requires Secure, Path \"/\" and no Domain", c.Name)
+		}
+
+		values = append(values, c.String())
+	}
+
+	return values, nil
+}
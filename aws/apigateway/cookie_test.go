@@ -0,0 +1,400 @@
+package apigateway
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+var (
+	testCookieKey    = bytes.Repeat([]byte("k"), 32)
+	testCookieNewKey = bytes.Repeat([]byte("n"), 32)
+	testCookieNow    = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+)
+
+// newTestCodec returns a CookieCodec for keys whose clock is the value of
+// now when called
+func newTestCodec(t *testing.T, keys [][]byte, now *time.Time, options ...func(*CookieOption)) *CookieCodec {
+	t.Helper()
+
+	options = append(options, WithCookieClock(func() time.Time { return *now }))
+
+	c, err := NewCookieCodec(keys, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+// tamperCookie returns encoded with the rune at i changed
+func tamperCookie(encoded string, i int) string {
+	b := []byte(encoded)
+
+	if b[i] == 'A' {
+		b[i] = 'B'
+	} else {
+		b[i] = 'A'
+	}
+
+	return string(b)
+}
+
+func TestCookieCodec(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		var options []func(*CookieOption)
+		if encrypt {
+			options = append(options, WithCookieEncryption())
+		}
+
+		now := testCookieNow
+		codec := newTestCodec(t, [][]byte{testCookieKey}, &now, options...)
+
+		testStructs := []string{"", "user-1", "a value; with = special, characters", "ünïcødé ✓", strings.Repeat("x", 1000)}
+
+		for i, testStruct := range testStructs {
+			encoded, err := codec.Encode("session", testStruct)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if encrypt && testStruct != "" && strings.Contains(encoded, testStruct) {
+				t.Errorf("Expected %s to be encrypted, got %s on iteration %d", testStruct, encoded, i)
+			}
+
+			if err := (&http.Cookie{Name: "session", Value: encoded}).Valid(); err != nil {
+				t.Errorf("Expected a valid cookie value, got %v on iteration %d", err, i)
+			}
+
+			got, err := codec.Decode("session", encoded)
+			if err != nil || got != testStruct {
+				t.Errorf("Expected %s, got %s (%v) on iteration %d", testStruct, got, err, i)
+			}
+
+			// a value moved to another cookie is rejected
+			if _, err := codec.Decode("other", encoded); !errors.Is(err, ErrInvalidCookie) {
+				t.Errorf("Expected %v, got %v on iteration %d", ErrInvalidCookie, err, i)
+			}
+
+			// changing any part of the value is detected
+			for _, j := range []int{0, len(encoded) / 2, len(encoded) - 1} {
+				if _, err := codec.Decode("session", tamperCookie(encoded, j)); !errors.Is(err, ErrInvalidCookie) {
+					t.Errorf("Expected %v, got %v tampering %d on iteration %d", ErrInvalidCookie, err, j, i)
+				}
+			}
+		}
+
+		for i, invalid := range []string{"", ".", "not base64!", "YWJj", "YWJj.YWJj", "YWJjZGVmZ2hpams.x"} {
+			if _, err := codec.Decode("session", invalid); !errors.Is(err, ErrInvalidCookie) {
+				t.Errorf("Expected %v, got %v on iteration %d", ErrInvalidCookie, err, i)
+			}
+		}
+
+		// values encoded with one mode are not accepted by the other
+		other := newTestCodec(t, [][]byte{testCookieKey}, &now)
+		if !encrypt {
+			other = newTestCodec(t, [][]byte{testCookieKey}, &now, WithCookieEncryption())
+		}
+
+		encoded, err := other.Encode("session", "user-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := codec.Decode("session", encoded); !errors.Is(err, ErrInvalidCookie) {
+			t.Errorf("Expected %v, got %v with encryption %t", ErrInvalidCookie, err, encrypt)
+		}
+	}
+}
+
+func TestCookieCodecKeyRotation(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		var options []func(*CookieOption)
+		if encrypt {
+			options = append(options, WithCookieEncryption())
+		}
+
+		now := testCookieNow
+
+		old := newTestCodec(t, [][]byte{testCookieKey}, &now, options...)
+		rotating := newTestCodec(t, [][]byte{testCookieNewKey, testCookieKey}, &now, options...)
+		rotated := newTestCodec(t, [][]byte{testCookieNewKey}, &now, options...)
+
+		oldValue, err := old.Encode("session", "user-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		newValue, err := rotating.Encode("session", "user-2")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		testStructs := []struct {
+			Codec    *CookieCodec
+			Encoded  string
+			Expected string
+			Err      error
+		}{
+			{Codec: rotating, Encoded: oldValue, Expected: "user-1"},
+			{Codec: rotating, Encoded: newValue, Expected: "user-2"},
+			{Codec: rotated, Encoded: newValue, Expected: "user-2"},
+			{Codec: rotated, Encoded: oldValue, Err: ErrInvalidCookie},
+			{Codec: old, Encoded: newValue, Err: ErrInvalidCookie},
+		}
+
+		for i, testStruct := range testStructs {
+			got, err := testStruct.Codec.Decode("session", testStruct.Encoded)
+			if !errors.Is(err, testStruct.Err) || got != testStruct.Expected {
+				t.Errorf("Expected %s (%v), got %s (%v) with encryption %t on iteration %d", testStruct.Expected, testStruct.Err, got, err, encrypt, i)
+			}
+		}
+	}
+}
+
+func TestCookieCodecMaxAge(t *testing.T) {
+	testStructs := []struct {
+		Options []func(*CookieOption)
+		Age     time.Duration
+		Err     error
+	}{
+		{Age: 0},
+		{Age: 24 * time.Hour},
+		{Age: 24*time.Hour + time.Second, Err: ErrExpiredCookie},
+		{Options: []func(*CookieOption){WithCookieMaxAge(time.Hour)}, Age: 59 * time.Minute},
+		{Options: []func(*CookieOption){WithCookieMaxAge(time.Hour)}, Age: 61 * time.Minute, Err: ErrExpiredCookie},
+		{Options: []func(*CookieOption){WithCookieMaxAge(time.Hour), WithCookieEncryption()}, Age: 61 * time.Minute, Err: ErrExpiredCookie},
+		{Options: []func(*CookieOption){WithCookieMaxAge(0)}, Age: 365 * 24 * time.Hour},
+
+		// values from the future, allowing a minute of clock skew
+		{Age: -30 * time.Second},
+		{Age: -time.Minute},
+		{Age: -2 * time.Minute, Err: ErrInvalidCookie},
+		{Options: []func(*CookieOption){WithCookieMaxAge(0)}, Age: -time.Hour, Err: ErrInvalidCookie},
+		{Options: []func(*CookieOption){WithCookieEncryption()}, Age: -time.Hour, Err: ErrInvalidCookie},
+	}
+
+	for i, testStruct := range testStructs {
+		now := testCookieNow
+		codec := newTestCodec(t, [][]byte{testCookieKey}, &now, testStruct.Options...)
+
+		encoded, err := codec.Encode("session", "user-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		now = now.Add(testStruct.Age)
+
+		if _, err := codec.Decode("session", encoded); !errors.Is(err, testStruct.Err) {
+			t.Errorf("Expected %v, got %v on iteration %d", testStruct.Err, err, i)
+		}
+	}
+}
+
+func TestCookieCodecSizeLimit(t *testing.T) {
+	testStructs := []struct {
+		Options []func(*CookieOption)
+		Size    int
+		Err     error
+	}{
+		{Size: 2900},
+		{Size: 3100, Err: ErrCookieTooLarge},
+		{Options: []func(*CookieOption){WithCookieEncryption()}, Size: 2900},
+		{Options: []func(*CookieOption){WithCookieEncryption()}, Size: 3100, Err: ErrCookieTooLarge},
+		{Size: 10000, Err: ErrCookieTooLarge},
+	}
+
+	for i, testStruct := range testStructs {
+		now := testCookieNow
+		codec := newTestCodec(t, [][]byte{testCookieKey}, &now, testStruct.Options...)
+
+		encoded, err := codec.Encode("session", strings.Repeat("x", testStruct.Size))
+		if !errors.Is(err, testStruct.Err) {
+			t.Errorf("Expected %v, got %v on iteration %d", testStruct.Err, err, i)
+		}
+
+		if err == nil && len("session")+len(encoded)+1 > maxCookieSize {
+			t.Errorf("Expected at most %d bytes, got %d on iteration %d", maxCookieSize, len(encoded), i)
+		}
+	}
+}
+
+func TestNewCookieCodec(t *testing.T) {
+	testStructs := []struct {
+		Keys  [][]byte
+		Valid bool
+	}{
+		{Keys: [][]byte{testCookieKey}, Valid: true},
+		{Keys: [][]byte{testCookieKey, bytes.Repeat([]byte("k"), 64)}, Valid: true},
+		{Keys: nil, Valid: false},
+		{Keys: [][]byte{bytes.Repeat([]byte("k"), 31)}, Valid: false},
+		{Keys: [][]byte{testCookieKey, []byte("short")}, Valid: false},
+	}
+
+	for i, testStruct := range testStructs {
+		if _, err := NewCookieCodec(testStruct.Keys); (err == nil) != testStruct.Valid {
+			t.Errorf("Expected valid %t, got %v on iteration %d", testStruct.Valid, err, i)
+		}
+	}
+}
+
+func TestCookieCodecJSON(t *testing.T) {
+	now := testCookieNow
+	codec := newTestCodec(t, [][]byte{testCookieKey}, &now, WithCookieMaxAge(time.Hour))
+
+	type session struct {
+		User  string   `json:"user"`
+		Roles []string `json:"roles"`
+	}
+
+	cookie, err := codec.NewCookie("session", "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cookie.MaxAge != 3600 || !cookie.Secure || !cookie.HttpOnly || cookie.Path != "/" {
+		t.Errorf("Expected a secure cookie with MaxAge 3600, got %s", cookie)
+	}
+
+	if got, err := codec.Value(cookie); err != nil || got != "user-1" {
+		t.Errorf("Expected %s, got %s (%v)", "user-1", got, err)
+	}
+
+	encoded, err := codec.EncodeJSON("session", session{User: "user-1", Roles: []string{"admin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got session
+
+	if err := codec.DecodeJSON("session", encoded, &got); err != nil || got.User != "user-1" || len(got.Roles) != 1 || got.Roles[0] != "admin" {
+		t.Errorf("Expected %s, got %v (%v)", "user-1 [admin]", got, err)
+	}
+
+	if err := codec.DecodeJSON("other", encoded, &got); !errors.Is(err, ErrInvalidCookie) {
+		t.Errorf("Expected %v, got %v", ErrInvalidCookie, err)
+	}
+}
+
+func TestRequestCookies(t *testing.T) {
+	testStructs := []struct {
+		Request  events.APIGatewayProxyRequest
+		Expected string
+	}{
+		{Request: events.APIGatewayProxyRequest{}, Expected: ""},
+		{Request: events.APIGatewayProxyRequest{Headers: map[string]string{"cookie": "a=1; session=abc"}}, Expected: "abc"},
+		{Request: events.APIGatewayProxyRequest{Headers: map[string]string{"Cookie": "session=abc"}}, Expected: "abc"},
+		{
+			Request: events.APIGatewayProxyRequest{
+				Headers:           map[string]string{"Cookie": "b=2"},
+				MultiValueHeaders: map[string][]string{"cookie": {"a=1", "session=abc; b=2"}},
+			},
+			Expected: "abc",
+		},
+		{Request: events.APIGatewayProxyRequest{Headers: map[string]string{"Cookie": "a=1"}}, Expected: ""},
+	}
+
+	for i, testStruct := range testStructs {
+		c, err := ProxyRequestCookie(&testStruct.Request, "session")
+
+		if testStruct.Expected == "" {
+			if !errors.Is(err, http.ErrNoCookie) {
+				t.Errorf("Expected %v, got %v on iteration %d", http.ErrNoCookie, err, i)
+			}
+
+			continue
+		}
+
+		if err != nil || c.Value != testStruct.Expected {
+			t.Errorf("Expected %s, got %v (%v) on iteration %d", testStruct.Expected, c, err, i)
+		}
+	}
+
+	v2 := events.APIGatewayV2HTTPRequest{Cookies: []string{"a=1", "session=abc"}}
+
+	if c, err := V2HTTPRequestCookie(&v2, "session"); err != nil || c.Value != "abc" {
+		t.Errorf("Expected %s, got %v (%v)", "abc", c, err)
+	}
+
+	if cookies := V2HTTPRequestCookies(&v2); len(cookies) != 2 {
+		t.Errorf("Expected %d cookies, got %d", 2, len(cookies))
+	}
+
+	if _, err := V2HTTPRequestCookie(&events.APIGatewayV2HTTPRequest{}, "session"); !errors.Is(err, http.ErrNoCookie) {
+		t.Errorf("Expected %v, got %v", http.ErrNoCookie, err)
+	}
+}
+
+func TestSetResponseCookies(t *testing.T) {
+	session := NewHostCookie("session", "abc")
+	theme := NewCookie("theme", "dark")
+	theme.HttpOnly = false
+
+	expected := []string{
+		"__Host-session=abc; Path=/; HttpOnly; Secure; SameSite=Lax",
+		"theme=dark; Path=/; Secure; SameSite=Lax",
+		"theme=; Path=/; Expires=Thu, 01 Jan 1970 00:00:00 GMT; Max-Age=0; Secure; SameSite=Lax",
+	}
+
+	var resp events.APIGatewayProxyResponse
+
+	if err := SetProxyResponseCookies(&resp, session, theme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetProxyResponseCookies(&resp, ExpireCookie(theme)); err != nil {
+		t.Fatal(err)
+	}
+
+	var respV2 events.APIGatewayV2HTTPResponse
+
+	if err := SetV2HTTPResponseCookies(&respV2, session, theme, ExpireCookie(theme)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, values := range [][]string{resp.MultiValueHeaders["Set-Cookie"], respV2.Cookies} {
+		if len(values) != len(expected) {
+			t.Errorf("Expected %v, got %v on iteration %d", expected, values, i)
+			continue
+		}
+
+		for j := range expected {
+			if values[j] != expected[j] {
+				t.Errorf("Expected %s, got %s on iteration %d", expected[j], values[j], i)
+			}
+		}
+	}
+
+	// the cookies set round trip through a request
+	req := events.APIGatewayProxyRequest{Headers: map[string]string{"Cookie": "__Host-session=abc; theme=dark"}}
+
+	if c, err := ProxyRequestCookie(&req, session.Name); err != nil || c.Value != session.Value {
+		t.Errorf("Expected %s, got %v (%v)", session.Value, c, err)
+	}
+
+	invalid := []*http.Cookie{
+		{Name: "bad name", Value: "x"},
+		{Name: "__Host-session", Value: "x", Path: "/"},
+		{Name: "__Host-session", Value: "x", Path: "/app", Secure: true},
+		func() *http.Cookie { c := NewHostCookie("session", "x"); c.Domain = "example.com"; return c }(),
+	}
+
+	for i, c := range invalid {
+		var resp events.APIGatewayProxyResponse
+
+		if err := SetProxyResponseCookies(&resp, theme, c); err == nil || len(resp.MultiValueHeaders["Set-Cookie"]) != 0 {
+			t.Errorf("Expected an error and no cookies, got %v on iteration %d", resp.MultiValueHeaders, i)
+		}
+
+		var respV2 events.APIGatewayV2HTTPResponse
+
+		if err := SetV2HTTPResponseCookies(&respV2, theme, c); err == nil || len(respV2.Cookies) != 0 {
+			t.Errorf("Expected an error and no cookies, got %v on iteration %d", respV2.Cookies, i)
+		}
+	}
+}
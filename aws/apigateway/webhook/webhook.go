@@ -0,0 +1,381 @@
+// Package webhook implements HMAC signature verification for webhooks
+// received through API Gateway
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/Vitality-South/goutil/aws/apigateway"
+	"github.com/Vitality-South/goutil/aws/lambda"
+	httputil "github.com/Vitality-South/goutil/http"
+)
+
+// Errors returned when a request fails verification.
+var (
+	ErrMissingSignature = errors.New("missing webhook signature")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrMissingTimestamp = errors.New("missing webhook timestamp")
+	ErrInvalidTimestamp = errors.New("webhook timestamp outside tolerance")
+)
+
+// Encoding is the text encoding of a signature in its header.
+type Encoding int
+
+const (
+	Hex Encoding = iota
+	Base64
+)
+
+// Scheme describes how a webhook provider signs its requests.
+type Scheme struct {
+	// Header is the name of the header holding the signature.
+	Header string
+
+	// Hash returns the hash used for the HMAC, such as sha256.New.
+	Hash func() hash.Hash
+
+	// Encoding is the encoding of the signature.
+	Encoding Encoding
+
+	// Prefix is removed from each signature before decoding, such as
+	// "sha256=".
+	Prefix string
+
+	// TimestampHeader is the name of the header holding the Unix time the
+	// request was signed. It is ignored if ParseHeader is set.
+	TimestampHeader string
+
+	// Tolerance is the maximum allowed difference between the signing time
+	// and now, protecting against replayed requests. Zero disables the
+	// check.
+	Tolerance time.Duration
+
+	// ParseHeader optionally splits the signature header into a timestamp
+	// and one or more signatures, for providers that put both in one
+	// header. The default treats the header value as a single signature.
+	ParseHeader func(value string) (timestamp string, signatures []string)
+
+	// Payload optionally returns the bytes that are signed. The default is
+	// the raw body.
+	Payload func(timestamp string, body []byte) []byte
+}
+
+// GitHub signs the body with HMAC-SHA256 in the X-Hub-Signature-256 header.
+var GitHub = Scheme{
+	Header:   "X-Hub-Signature-256",
+	Hash:     sha256.New,
+	Encoding: Hex,
+	Prefix:   "sha256=",
+}
+
+// Stripe signs "timestamp.body" with HMAC-SHA256 in the Stripe-Signature
+// header, which holds the timestamp and one or more v1 signatures.
+var Stripe = Scheme{
+	Header:    "Stripe-Signature",
+	Hash:      sha256.New,
+	Encoding:  Hex,
+	Tolerance: 5 * time.Minute,
+	ParseHeader: func(value string) (string, []string) {
+		var (
+			timestamp  string
+			signatures []string
+		)
+
+		for _, p := range strings.Split(value, ",") {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+
+			switch k {
+			case "t":
+				timestamp = v
+			case "v1":
+				signatures = append(signatures, v)
+			}
+		}
+
+		return timestamp, signatures
+	},
+	Payload: func(timestamp string, body []byte) []byte {
+		return append([]byte(timestamp+"."), body...)
+	},
+}
+
+// Slack signs "v0:timestamp:body" with HMAC-SHA256 in the X-Slack-Signature
+// header.
+var Slack = Scheme{
+	Header:          "X-Slack-Signature",
+	Hash:            sha256.New,
+	Encoding:        Hex,
+	Prefix:          "v0=",
+	TimestampHeader: "X-Slack-Request-Timestamp",
+	Tolerance:       5 * time.Minute,
+	Payload: func(timestamp string, body []byte) []byte {
+		return append([]byte("v0:"+timestamp+":"), body...)
+	},
+}
+
+// Shopify signs the body with HMAC-SHA256 in the X-Shopify-Hmac-Sha256
+// header.
+var Shopify = Scheme{
+	Header:   "X-Shopify-Hmac-Sha256",
+	Hash:     sha256.New,
+	Encoding: Base64,
+}
+
+// GitHubSHA1 signs the body with HMAC-SHA1 in the X-Hub-Signature header.
+// GitHub still sends it for older integrations; prefer GitHub.
+var GitHubSHA1 = Scheme{
+	Header:   "X-Hub-Signature",
+	Hash:     sha1.New,
+	Encoding: Hex,
+	Prefix:   "sha1=",
+}
+
+type Option struct {
+	now func() time.Time
+}
+
+// WithClock sets the function used to get the current time.
+func WithClock(now func() time.Time) func(o *Option) {
+	return func(o *Option) {
+		o.now = now
+	}
+}
+
+// Verifier verifies webhook signatures for one Scheme. It is safe for
+// concurrent use.
+type Verifier struct {
+	scheme  Scheme
+	secrets [][]byte
+	op      Option
+}
+
+// NewVerifier returns a Verifier accepting signatures made with any of the
+// secrets. Pass both the old and the new secret while rotating.
+func NewVerifier(scheme Scheme, secrets []string, options ...func(*Option)) (*Verifier, error) {
+	if scheme.Header == "" || scheme.Hash == nil {
+		return nil, errors.New("webhook scheme requires Header and Hash")
+	}
+
+	op := Option{
+		now: time.Now,
+	}
+
+	for _, o := range options {
+		o(&op)
+	}
+
+	v := &Verifier{scheme: scheme, op: op}
+
+	for _, s := range secrets {
+		if s != "" {
+			v.secrets = append(v.secrets, []byte(s))
+		}
+	}
+
+	if len(v.secrets) == 0 {
+		return nil, errors.New("webhook verifier requires at least one secret")
+	}
+
+	return v, nil
+}
+
+// Verify checks the signature of body, the raw request body, using the
+// request headers.
+func (v *Verifier) Verify(header http.Header, body []byte) error {
+	value := header.Get(v.scheme.Header)
+	if value == "" {
+		return ErrMissingSignature
+	}
+
+	timestamp := header.Get(v.scheme.TimestampHeader)
+	signatures := []string{value}
+
+	if v.scheme.ParseHeader != nil {
+		timestamp, signatures = v.scheme.ParseHeader(value)
+	}
+
+	if len(signatures) == 0 {
+		return ErrMissingSignature
+	}
+
+	if v.scheme.Tolerance > 0 {
+		if err := v.checkTimestamp(timestamp); err != nil {
+			return err
+		}
+	}
+
+	payload := body
+	if v.scheme.Payload != nil {
+		payload = v.scheme.Payload(timestamp, body)
+	}
+
+	// compute every expected MAC up front so that the work done does not
+	// depend on which secret matches
+	expected := make([][]byte, len(v.secrets))
+	for i, s := range v.secrets {
+		mac := hmac.New(v.scheme.Hash, s)
+		mac.Write(payload)
+		expected[i] = mac.Sum(nil)
+	}
+
+	valid := false
+
+	for _, sig := range signatures {
+		got, err := v.decode(sig)
+		if err != nil {
+			continue
+		}
+
+		for _, e := range expected {
+			if hmac.Equal(got, e) {
+				valid = true
+			}
+		}
+	}
+
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (v *Verifier) checkTimestamp(timestamp string) error {
+	if timestamp == "" {
+		return ErrMissingTimestamp
+	}
+
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidTimestamp, timestamp)
+	}
+
+	d := v.op.now().Sub(time.Unix(sec, 0))
+	if d < 0 {
+		d = -d
+	}
+
+	if d > v.scheme.Tolerance {
+		return fmt.Errorf("%w: signed %s ago", ErrInvalidTimestamp, d.Round(time.Second))
+	}
+
+	return nil
+}
+
+func (v *Verifier) decode(sig string) ([]byte, error) {
+	sig = strings.TrimSpace(sig)
+
+	if v.scheme.Prefix != "" {
+		if !strings.HasPrefix(sig, v.scheme.Prefix) {
+			return nil, ErrInvalidSignature
+		}
+
+		sig = strings.TrimPrefix(sig, v.scheme.Prefix)
+	}
+
+	if v.scheme.Encoding == Base64 {
+		return base64.StdEncoding.DecodeString(sig)
+	}
+
+	return hex.DecodeString(sig)
+}
+
+// VerifyProxyRequest verifies an API Gateway REST API request and returns its
+// decoded body. The body is nil if the request fails verification.
+func (v *Verifier) VerifyProxyRequest(request *events.APIGatewayProxyRequest) ([]byte, error) {
+	body, err := lambda.RequestBodyFromFromAPIGatewayProxy(request)
+	if err != nil {
+		return nil, err
+	}
+
+	h := toHeader(request.Headers)
+	for k, vs := range request.MultiValueHeaders {
+		h.Del(k)
+
+		for _, s := range vs {
+			h.Add(k, s)
+		}
+	}
+
+	if err := v.Verify(h, []byte(body)); err != nil {
+		return nil, err
+	}
+
+	return []byte(body), nil
+}
+
+// VerifyV2HTTPRequest verifies an API Gateway HTTP API request and returns its
+// decoded body. The body is nil if the request fails verification.
+func (v *Verifier) VerifyV2HTTPRequest(request *events.APIGatewayV2HTTPRequest) ([]byte, error) {
+	body, err := lambda.RequestBodyFromFromApiGatewayV2HTTP(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.Verify(toHeader(request.Headers), []byte(body)); err != nil {
+		return nil, err
+	}
+
+	return []byte(body), nil
+}
+
+// ProxyHandler wraps a REST API Lambda handler so that it is only invoked for
+// requests with a valid signature. Other requests receive a 401 Unauthorized
+// response.
+func (v *Verifier) ProxyHandler(h func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)) func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if _, err := v.VerifyProxyRequest(&request); err != nil {
+			return UnauthorizedProxyResponse(), nil
+		}
+
+		return h(ctx, request)
+	}
+}
+
+// V2HTTPHandler wraps an HTTP API Lambda handler so that it is only invoked
+// for requests with a valid signature. Other requests receive a 401
+// Unauthorized response.
+func (v *Verifier) V2HTTPHandler(h func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error)) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		if _, err := v.VerifyV2HTTPRequest(&request); err != nil {
+			return UnauthorizedV2HTTPResponse(), nil
+		}
+
+		return h(ctx, request)
+	}
+}
+
+// UnauthorizedProxyResponse returns a 401 Unauthorized REST API response
+// with the Error401Content body.
+func UnauthorizedProxyResponse() events.APIGatewayProxyResponse {
+	return apigateway.ProxyResponse(http.StatusUnauthorized, httputil.DefaultHTTPErrorHeaders(), []byte(apigateway.Error401Content))
+}
+
+// UnauthorizedV2HTTPResponse returns a 401 Unauthorized HTTP API response
+// with the Error401Content body.
+func UnauthorizedV2HTTPResponse() events.APIGatewayV2HTTPResponse {
+	return apigateway.V2HTTPResponse(http.StatusUnauthorized, httputil.DefaultHTTPErrorHeaders(), []byte(apigateway.Error401Content))
+}
+
+func toHeader(m map[string]string) http.Header {
+	h := make(http.Header, len(m))
+	for k, v := range m {
+		h.Set(k, v)
+	}
+
+	return h
+}
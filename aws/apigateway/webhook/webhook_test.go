@@ -0,0 +1,316 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Signatures below are real: the GitHub and Slack ones are the examples
+// from their documentation, the others were computed independently with
+// Python's hmac module.
+const (
+	gitHubSecret    = "It's a Secret to Everybody"
+	gitHubBody      = "Hello, World!"
+	gitHubSignature = "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+	gitHubSHA1      = "sha1=01dc10d0c83e72ed246219cdd91669667fe2ca59"
+
+	slackSecret    = "8f742231b10e8888abcd99yyyzzz85a5"
+	slackTimestamp = "1531420618"
+	slackBody      = "token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c"
+	slackSignature = "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503"
+
+	stripeSecret       = "whsec_test_secret"
+	stripeOldSecret    = "whsec_old_secret"
+	stripeTimestamp    = "1709294400"
+	stripeBody         = `{"id":"evt_1","type":"payment_intent.succeeded"}`
+	stripeSignature    = "a86251f6fc8bf54f1dfef4e99cc828b25c6d55bc53caadde83264328b81cebd9"
+	stripeOldSignature = "a6d6eb8ecb513a2f4e0f24c09fa58bd3c5a441cb58f4e7d03c47c6dd2bc54589"
+
+	shopifySecret       = "shpss_test_secret"
+	shopifyNewSecret    = "shpss_new_secret"
+	shopifyBody         = `{"id":820982911946154508,"email":"jon@example.com"}`
+	shopifyBase64Body   = "eyJpZCI6ODIwOTgyOTExOTQ2MTU0NTA4LCJlbWFpbCI6ImpvbkBleGFtcGxlLmNvbSJ9"
+	shopifySignature    = "LnwdZPLBbdjkBwPD/lDL1/rYEGby/GHPtL1aAH6Dxjc="
+	shopifyNewSignature = "H+kWmTljsCD/BDoV4JUo9zb4q72m/W0japBLjFh4tko="
+)
+
+var (
+	slackTime  = time.Unix(1531420618, 0)
+	stripeTime = time.Unix(1709294400, 0)
+)
+
+func header(kv ...string) http.Header {
+	h := make(http.Header)
+
+	for i := 0; i+1 < len(kv); i += 2 {
+		h.Add(kv[i], kv[i+1])
+	}
+
+	return h
+}
+
+func TestVerify(t *testing.T) {
+	testStructs := []struct {
+		Scheme   Scheme
+		Secrets  []string
+		Now      time.Time
+		Header   http.Header
+		Body     string
+		Expected error
+	}{
+		// GitHub sha256=
+		{Scheme: GitHub, Secrets: []string{gitHubSecret}, Header: header("X-Hub-Signature-256", gitHubSignature), Body: gitHubBody},
+		{Scheme: GitHub, Secrets: []string{gitHubSecret}, Header: header("x-hub-signature-256", gitHubSignature), Body: gitHubBody},
+		{Scheme: GitHub, Secrets: []string{gitHubSecret}, Header: header("X-Hub-Signature-256", gitHubSignature), Body: gitHubBody + "\n", Expected: ErrInvalidSignature},
+		{Scheme: GitHub, Secrets: []string{"another secret"}, Header: header("X-Hub-Signature-256", gitHubSignature), Body: gitHubBody, Expected: ErrInvalidSignature},
+		{Scheme: GitHub, Secrets: []string{gitHubSecret}, Header: header("X-Hub-Signature-256", gitHubSignature[len("sha256="):]), Body: gitHubBody, Expected: ErrInvalidSignature},
+		{Scheme: GitHub, Secrets: []string{gitHubSecret}, Header: header("X-Hub-Signature-256", "sha256=not hex"), Body: gitHubBody, Expected: ErrInvalidSignature},
+		{Scheme: GitHub, Secrets: []string{gitHubSecret}, Header: header("X-Hub-Signature", gitHubSHA1), Body: gitHubBody, Expected: ErrMissingSignature},
+		{Scheme: GitHubSHA1, Secrets: []string{gitHubSecret}, Header: header("X-Hub-Signature", gitHubSHA1), Body: gitHubBody},
+
+		// secret rotation
+		{Scheme: GitHub, Secrets: []string{"new secret", gitHubSecret}, Header: header("X-Hub-Signature-256", gitHubSignature), Body: gitHubBody},
+		{Scheme: GitHub, Secrets: []string{gitHubSecret, "new secret"}, Header: header("X-Hub-Signature-256", gitHubSignature), Body: gitHubBody},
+		{Scheme: Shopify, Secrets: []string{shopifyNewSecret, shopifySecret}, Header: header("X-Shopify-Hmac-Sha256", shopifySignature), Body: shopifyBody},
+		{Scheme: Shopify, Secrets: []string{shopifyNewSecret, shopifySecret}, Header: header("X-Shopify-Hmac-Sha256", shopifyNewSignature), Body: shopifyBody},
+		{Scheme: Shopify, Secrets: []string{shopifyNewSecret}, Header: header("X-Shopify-Hmac-Sha256", shopifySignature), Body: shopifyBody, Expected: ErrInvalidSignature},
+
+		// Shopify base64
+		{Scheme: Shopify, Secrets: []string{shopifySecret}, Header: header("X-Shopify-Hmac-Sha256", shopifySignature), Body: shopifyBody},
+		{Scheme: Shopify, Secrets: []string{shopifySecret}, Header: header("X-Shopify-Hmac-Sha256", "LnwdZPLBbdjkBwPD/lDL1/rYEGby/GHPtL1aAH6Dxjc"), Body: shopifyBody, Expected: ErrInvalidSignature},
+		{Scheme: Shopify, Secrets: []string{shopifySecret}, Header: header("X-Shopify-Hmac-Sha256", shopifySignature), Body: shopifyBase64Body, Expected: ErrInvalidSignature},
+		{Scheme: Shopify, Secrets: []string{shopifySecret}, Body: shopifyBody, Expected: ErrMissingSignature},
+
+		// Stripe t=,v1= with a tolerance of five minutes
+		{Scheme: Stripe, Secrets: []string{stripeSecret}, Now: stripeTime, Header: header("Stripe-Signature", "t="+stripeTimestamp+",v1="+stripeSignature), Body: stripeBody},
+		{Scheme: Stripe, Secrets: []string{stripeSecret}, Now: stripeTime.Add(5 * time.Minute), Header: header("Stripe-Signature", "t="+stripeTimestamp+",v1="+stripeSignature), Body: stripeBody},
+		{Scheme: Stripe, Secrets: []string{stripeSecret}, Now: stripeTime.Add(-5 * time.Minute), Header: header("Stripe-Signature", "t="+stripeTimestamp+",v1="+stripeSignature), Body: stripeBody},
+		{Scheme: Stripe, Secrets: []string{stripeSecret}, Now: stripeTime, Header: header("Stripe-Signature", "t="+stripeTimestamp+",v1="+stripeOldSignature+",v1="+stripeSignature+",v0=6ffbb59b2300aae63f272406069a9788598b792a944a07aba816edb039989a39"), Body: stripeBody},
+		{Scheme: Stripe, Secrets: []string{stripeSecret}, Now: stripeTime, Header: header("Stripe-Signature", "t="+stripeTimestamp+", v1="+stripeSignature), Body: stripeBody},
+		{Scheme: Stripe, Secrets: []string{stripeSecret}, Now: stripeTime, Header: header("Stripe-Signature", "t="+stripeTimestamp+",v1="+stripeOldSignature), Body: stripeBody, Expected: ErrInvalidSignature},
+		{Scheme: Stripe, Secrets: []string{stripeSecret, stripeOldSecret}, Now: stripeTime, Header: header("Stripe-Signature", "t="+stripeTimestamp+",v1="+stripeOldSignature), Body: stripeBody},
+		{Scheme: Stripe, Secrets: []string{stripeSecret}, Now: stripeTime, Header: header("Stripe-Signature", "t="+stripeTimestamp+",v0="+stripeSignature), Body: stripeBody, Expected: ErrMissingSignature},
+		{Scheme: Stripe, Secrets: []string{stripeSecret}, Now: stripeTime, Header: header("Stripe-Signature", "v1="+stripeSignature), Body: stripeBody, Expected: ErrMissingTimestamp},
+		{Scheme: Stripe, Secrets: []string{stripeSecret}, Now: stripeTime, Header: header("Stripe-Signature", "t=yesterday,v1="+stripeSignature), Body: stripeBody, Expected: ErrInvalidTimestamp},
+		{Scheme: Stripe, Secrets: []string{stripeSecret}, Now: stripeTime, Header: header("Stripe-Signature", "t=1709294401,v1="+stripeSignature), Body: stripeBody, Expected: ErrInvalidSignature},
+
+		// Stripe replayed outside the tolerance
+		{Scheme: Stripe, Secrets: []string{stripeSecret}, Now: stripeTime.Add(5*time.Minute + time.Second), Header: header("Stripe-Signature", "t="+stripeTimestamp+",v1="+stripeSignature), Body: stripeBody, Expected: ErrInvalidTimestamp},
+		{Scheme: Stripe, Secrets: []string{stripeSecret}, Now: stripeTime.Add(-6 * time.Minute), Header: header("Stripe-Signature", "t="+stripeTimestamp+",v1="+stripeSignature), Body: stripeBody, Expected: ErrInvalidTimestamp},
+		{Scheme: Stripe, Secrets: []string{stripeSecret}, Now: stripeTime.Add(24 * time.Hour), Header: header("Stripe-Signature", "t="+stripeTimestamp+",v1="+stripeSignature), Body: stripeBody, Expected: ErrInvalidTimestamp},
+
+		// Slack v0: with a timestamp window of five minutes
+		{Scheme: Slack, Secrets: []string{slackSecret}, Now: slackTime, Header: header("X-Slack-Signature", slackSignature, "X-Slack-Request-Timestamp", slackTimestamp), Body: slackBody},
+		{Scheme: Slack, Secrets: []string{slackSecret}, Now: slackTime.Add(4 * time.Minute), Header: header("X-Slack-Signature", slackSignature, "X-Slack-Request-Timestamp", slackTimestamp), Body: slackBody},
+		{Scheme: Slack, Secrets: []string{slackSecret}, Now: slackTime.Add(6 * time.Minute), Header: header("X-Slack-Signature", slackSignature, "X-Slack-Request-Timestamp", slackTimestamp), Body: slackBody, Expected: ErrInvalidTimestamp},
+		{Scheme: Slack, Secrets: []string{slackSecret}, Now: slackTime.Add(6 * time.Minute), Header: header("X-Slack-Signature", slackSignature, "X-Slack-Request-Timestamp", "1531420978"), Body: slackBody, Expected: ErrInvalidSignature},
+		{Scheme: Slack, Secrets: []string{slackSecret}, Now: slackTime, Header: header("X-Slack-Signature", slackSignature), Body: slackBody, Expected: ErrMissingTimestamp},
+		{Scheme: Slack, Secrets: []string{slackSecret}, Now: slackTime, Header: header("X-Slack-Signature", "v1="+slackSignature[len("v0="):], "X-Slack-Request-Timestamp", slackTimestamp), Body: slackBody, Expected: ErrInvalidSignature},
+	}
+
+	for i, testStruct := range testStructs {
+		now := testStruct.Now
+
+		v, err := NewVerifier(testStruct.Scheme, testStruct.Secrets, WithClock(func() time.Time { return now }))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := v.Verify(testStruct.Header, []byte(testStruct.Body)); !errors.Is(err, testStruct.Expected) {
+			t.Errorf("Expected %v, got %v on iteration %d", testStruct.Expected, err, i)
+		}
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	testStructs := []struct {
+		Scheme  Scheme
+		Secrets []string
+		Valid   bool
+	}{
+		{Scheme: GitHub, Secrets: []string{"secret"}, Valid: true},
+		{Scheme: GitHub, Secrets: []string{"", "secret"}, Valid: true},
+		{Scheme: GitHub, Secrets: nil, Valid: false},
+		{Scheme: GitHub, Secrets: []string{""}, Valid: false},
+		{Scheme: Scheme{Header: "X-Signature"}, Secrets: []string{"secret"}, Valid: false},
+		{Scheme: Scheme{Hash: GitHub.Hash}, Secrets: []string{"secret"}, Valid: false},
+	}
+
+	for i, testStruct := range testStructs {
+		if _, err := NewVerifier(testStruct.Scheme, testStruct.Secrets); (err == nil) != testStruct.Valid {
+			t.Errorf("Expected valid %t, got %v on iteration %d", testStruct.Valid, err, i)
+		}
+	}
+}
+
+func TestVerifyProxyRequest(t *testing.T) {
+	v, err := NewVerifier(Shopify, []string{shopifySecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testStructs := []struct {
+		Request  events.APIGatewayProxyRequest
+		Expected error
+	}{
+		{
+			Request: events.APIGatewayProxyRequest{
+				Headers: map[string]string{"x-shopify-hmac-sha256": shopifySignature},
+				Body:    shopifyBody,
+			},
+		},
+		{
+			Request: events.APIGatewayProxyRequest{
+				Headers:         map[string]string{"x-shopify-hmac-sha256": shopifySignature},
+				Body:            shopifyBase64Body,
+				IsBase64Encoded: true,
+			},
+		},
+		{
+			Request: events.APIGatewayProxyRequest{
+				Headers:           map[string]string{"x-shopify-hmac-sha256": "stale"},
+				MultiValueHeaders: map[string][]string{"X-Shopify-Hmac-Sha256": {shopifySignature}},
+				Body:              shopifyBase64Body,
+				IsBase64Encoded:   true,
+			},
+		},
+		{
+			Request: events.APIGatewayProxyRequest{
+				Headers:         map[string]string{"x-shopify-hmac-sha256": shopifyNewSignature},
+				Body:            shopifyBase64Body,
+				IsBase64Encoded: true,
+			},
+			Expected: ErrInvalidSignature,
+		},
+		{
+			Request: events.APIGatewayProxyRequest{
+				Body: shopifyBody,
+			},
+			Expected: ErrMissingSignature,
+		},
+	}
+
+	for i, testStruct := range testStructs {
+		body, err := v.VerifyProxyRequest(&testStruct.Request)
+		if !errors.Is(err, testStruct.Expected) {
+			t.Errorf("Expected %v, got %v on iteration %d", testStruct.Expected, err, i)
+		}
+
+		if err != nil {
+			if body != nil {
+				t.Errorf("Expected no body, got %s on iteration %d", body, i)
+			}
+
+			continue
+		}
+
+		if string(body) != shopifyBody {
+			t.Errorf("Expected %s, got %s on iteration %d", shopifyBody, body, i)
+		}
+	}
+
+	if body, err := v.VerifyProxyRequest(&events.APIGatewayProxyRequest{Body: "!", IsBase64Encoded: true}); err == nil || body != nil {
+		t.Errorf("Expected an error decoding an invalid body, got %s", body)
+	}
+}
+
+func TestVerifyV2HTTPRequest(t *testing.T) {
+	v, err := NewVerifier(Shopify, []string{shopifySecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testStructs := []struct {
+		Request  events.APIGatewayV2HTTPRequest
+		Expected error
+	}{
+		{
+			Request: events.APIGatewayV2HTTPRequest{
+				Headers: map[string]string{"x-shopify-hmac-sha256": shopifySignature},
+				Body:    shopifyBody,
+			},
+		},
+		{
+			Request: events.APIGatewayV2HTTPRequest{
+				Headers:         map[string]string{"x-shopify-hmac-sha256": shopifySignature},
+				Body:            shopifyBase64Body,
+				IsBase64Encoded: true,
+			},
+		},
+		{
+			Request: events.APIGatewayV2HTTPRequest{
+				Headers: map[string]string{"x-shopify-hmac-sha256": shopifySignature},
+				Body:    shopifyBase64Body,
+			},
+			Expected: ErrInvalidSignature,
+		},
+		{
+			Request: events.APIGatewayV2HTTPRequest{
+				Body: shopifyBody,
+			},
+			Expected: ErrMissingSignature,
+		},
+	}
+
+	for i, testStruct := range testStructs {
+		body, err := v.VerifyV2HTTPRequest(&testStruct.Request)
+		if !errors.Is(err, testStruct.Expected) {
+			t.Errorf("Expected %v, got %v on iteration %d", testStruct.Expected, err, i)
+		}
+
+		if err != nil {
+			if body != nil {
+				t.Errorf("Expected no body, got %s on iteration %d", body, i)
+			}
+
+			continue
+		}
+
+		if string(body) != shopifyBody {
+			t.Errorf("Expected %s, got %s on iteration %d", shopifyBody, body, i)
+		}
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	v, err := NewVerifier(GitHub, []string{gitHubSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxy := v.ProxyHandler(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	v2 := v.V2HTTPHandler(func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		return events.APIGatewayV2HTTPResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	testStructs := []struct {
+		Signature string
+		Expected  int
+	}{
+		{Signature: gitHubSignature, Expected: http.StatusOK},
+		{Signature: gitHubSHA1, Expected: http.StatusUnauthorized},
+		{Signature: "", Expected: http.StatusUnauthorized},
+	}
+
+	for i, testStruct := range testStructs {
+		headers := map[string]string{"X-Hub-Signature-256": testStruct.Signature}
+
+		resp, err := proxy(context.Background(), events.APIGatewayProxyRequest{Headers: headers, Body: gitHubBody})
+		if err != nil || resp.StatusCode != testStruct.Expected {
+			t.Errorf("Expected %d, got %d (%v) on iteration %d", testStruct.Expected, resp.StatusCode, err, i)
+		}
+
+		respV2, err := v2(context.Background(), events.APIGatewayV2HTTPRequest{Headers: headers, Body: gitHubBody})
+		if err != nil || respV2.StatusCode != testStruct.Expected {
+			t.Errorf("Expected %d, got %d (%v) on iteration %d", testStruct.Expected, respV2.StatusCode, err, i)
+		}
+	}
+}
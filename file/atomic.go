@@ -0,0 +1,193 @@
+package file
+
+import (
+	"errors"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// ErrAtomicWriterClosed is returned when writing to or committing an
+// AtomicWriter that was already committed or closed.
+var ErrAtomicWriterClosed = errors.New("atomic writer already closed")
+
+// WriteAtomic writes data to the named file so that readers see either the
+// previous contents or all of data, never a partial file, even if the process
+// crashes.
+//
+// The data is written to a temporary file in the same directory, which is
+// synced and renamed over name before the directory itself is synced. Like
+// os.WriteFile, an existing file keeps its permissions and a new file is
+// created with perm (before umask), such as OSUserReadWrite.
+func WriteAtomic(name string, data []byte, perm fs.FileMode) error {
+	w, err := NewAtomicWriter(name, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+
+	return w.Commit()
+}
+
+// AtomicWriter is an io.WriteCloser that streams to a temporary file and
+// replaces the destination only when Commit is called.
+//
+// Close without Commit discards everything written, so the usual pattern is:
+//
+//	w, err := file.NewAtomicWriter(name, file.OSUserRWGroupROtherR)
+//	if err != nil {
+//		return err
+//	}
+//	defer w.Close()
+//
+//	if _, err := io.Copy(w, src); err != nil {
+//		return err
+//	}
+//
+//	return w.Commit()
+type AtomicWriter struct {
+	f    *os.File
+	name string
+	perm fs.FileMode
+	keep bool
+	done bool
+}
+
+// NewAtomicWriter returns an AtomicWriter for the named file. See WriteAtomic
+// for how perm is applied.
+func NewAtomicWriter(name string, perm fs.FileMode) (*AtomicWriter, error) {
+	// keep the permissions of an existing file; a new file gets perm with
+	// the umask applied by the operating system when the temporary file is
+	// created
+	keep := false
+
+	if fi, err := os.Stat(name); err == nil {
+		perm = fi.Mode() & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)
+		keep = true
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	// the temporary file must be in the same directory so that the rename
+	// does not cross file systems
+	f, err := createTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp-", "", perm.Perm())
+	if err != nil {
+		return nil, err
+	}
+
+	return &AtomicWriter{f: f, name: name, perm: perm, keep: keep}, nil
+}
+
+// Write writes p to the temporary file.
+func (w *AtomicWriter) Write(p []byte) (int, error) {
+	if w.done {
+		return 0, ErrAtomicWriterClosed
+	}
+
+	return w.f.Write(p)
+}
+
+// Name returns the name of the destination file.
+func (w *AtomicWriter) Name() string {
+	return w.name
+}
+
+// Commit syncs the temporary file, sets its permissions and renames it over
+// the destination, then syncs the directory. The temporary file is removed if
+// any step fails.
+func (w *AtomicWriter) Commit() error {
+	if w.done {
+		return ErrAtomicWriterClosed
+	}
+
+	w.done = true
+
+	if err := w.commit(); err != nil {
+		_ = os.Remove(w.f.Name())
+		return err
+	}
+
+	return syncDir(filepath.Dir(w.name))
+}
+
+func (w *AtomicWriter) commit() error {
+	if err := w.f.Sync(); err != nil {
+		w.f.Close()
+		return err
+	}
+
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+
+	if w.keep {
+		if err := os.Chmod(w.f.Name(), w.perm); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(w.f.Name(), w.name)
+}
+
+// Close discards the temporary file unless Commit was called. It is safe to
+// call Close after Commit, which makes it suitable for defer.
+func (w *AtomicWriter) Close() error {
+	if w.done {
+		return nil
+	}
+
+	w.done = true
+
+	err := w.f.Close()
+
+	if rerr := os.Remove(w.f.Name()); rerr != nil && err == nil {
+		err = rerr
+	}
+
+	return err
+}
+
+// createTemp creates a new file in dir with a random name between prefix and
+// suffix. Unlike os.CreateTemp, the file is created with perm.
+func createTemp(dir, prefix, suffix string, perm fs.FileMode) (*os.File, error) {
+	for i := 0; i < 10000; i++ {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10)+suffix)
+
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+
+		return f, err
+	}
+
+	return nil, &fs.PathError{Op: "createtemp", Path: filepath.Join(dir, prefix+"*"+suffix), Err: fs.ErrExist}
+}
+
+// syncDir flushes a directory entry change, such as a rename, to disk
+func syncDir(dir string) error {
+	// directories can not be opened for syncing on Windows
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	err = d.Sync()
+
+	if cerr := d.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+
+	return err
+}
@@ -0,0 +1,156 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteAtomic(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "config.json")
+
+	if err := WriteAtomic(name, []byte("first"), OSUserReadWrite); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if b, _ := os.ReadFile(name); string(b) != "first" {
+		t.Errorf("expected first, got %s", b)
+	}
+
+	// new files are created with perm; umasks do not clear user bits
+	if fi, _ := os.Stat(name); fi.Mode().Perm() != OSUserReadWrite {
+		t.Errorf("expected %o, got %o", OSUserReadWrite, fi.Mode().Perm())
+	}
+
+	// existing files keep their permissions
+	if err := os.Chmod(name, OSUserRead|OSGroupRead); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteAtomic(name, []byte("second"), OSAllReadWrite); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if b, _ := os.ReadFile(name); string(b) != "second" {
+		t.Errorf("expected second, got %s", b)
+	}
+
+	if fi, _ := os.Stat(name); fi.Mode().Perm() != OSUserRead|OSGroupRead {
+		t.Errorf("expected %o, got %o", OSUserRead|OSGroupRead, fi.Mode().Perm())
+	}
+
+	assertOnlyFiles(t, dir, "config.json")
+}
+
+func TestWriteAtomicMissingDir(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "missing", "config.json")
+
+	if err := WriteAtomic(name, []byte("data"), OSUserReadWrite); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, got %v", err)
+	}
+}
+
+func TestAtomicWriterClose(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "state")
+
+	if err := os.WriteFile(name, []byte("original"), OSUserReadWrite); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewAtomicWriter(name, OSUserReadWrite)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := w.Write([]byte("partial")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// closing without commit discards the write
+	if err := w.Close(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if b, _ := os.ReadFile(name); string(b) != "original" {
+		t.Errorf("expected original, got %s", b)
+	}
+
+	if _, err := w.Write([]byte("more")); !errors.Is(err, ErrAtomicWriterClosed) {
+		t.Errorf("expected ErrAtomicWriterClosed, got %v", err)
+	}
+
+	if err := w.Commit(); !errors.Is(err, ErrAtomicWriterClosed) {
+		t.Errorf("expected ErrAtomicWriterClosed, got %v", err)
+	}
+
+	assertOnlyFiles(t, dir, "state")
+}
+
+func TestAtomicWriterCommit(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "state")
+
+	w, err := NewAtomicWriter(name, OSUserReadWrite)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	defer w.Close()
+
+	for _, s := range []string{"a", "b", "c"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	// nothing is visible before commit
+	if _, err := os.Stat(name); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, got %v", err)
+	}
+
+	if err := w.Commit(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if b, _ := os.ReadFile(name); string(b) != "abc" {
+		t.Errorf("expected abc, got %s", b)
+	}
+
+	assertOnlyFiles(t, dir, "state")
+}
+
+// assertOnlyFiles fails the test if dir holds anything other than names,
+// such as a leftover temporary file
+func assertOnlyFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != len(names) {
+		t.Errorf("expected %d entries, got %d", len(names), len(entries))
+	}
+
+	for i, e := range entries {
+		if i < len(names) && e.Name() != names[i] {
+			t.Errorf("expected %s, got %s", names[i], e.Name())
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package file
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ViolationKind identifies which check an audited path failed.
+type ViolationKind int
+
+const (
+	// ModeTooPermissive means the path has permission bits outside the
+	// maximum mode allowed by its rule.
+	ModeTooPermissive ViolationKind = iota + 1
+
+	// WrongOwner means the path is not owned by the user id required by its
+	// rule.
+	WrongOwner
+
+	// WrongGroup means the path is not owned by the group id required by its
+	// rule.
+	WrongGroup
+
+	// SymlinkEscapesRoot means the path is a symbolic link pointing outside
+	// of the audited root.
+	SymlinkEscapesRoot
+)
+
+// String returns a short description of k.
+func (k ViolationKind) String() string {
+	switch k {
+	case ModeTooPermissive:
+		return "mode too permissive"
+	case WrongOwner:
+		return "wrong owner"
+	case WrongGroup:
+		return "wrong group"
+	case SymlinkEscapesRoot:
+		return "symlink escapes root"
+	}
+
+	return "unknown"
+}
+
+// AuditRule is the permission policy for the paths matching Pattern.
+type AuditRule struct {
+	// Pattern is a glob matched against the slash separated path relative
+	// to the audited root, using path.Match syntax extended with "**" to
+	// match any number of directories. A pattern without a slash matches
+	// the base name at any depth, so "*.pem" matches "a/b/key.pem".
+	Pattern string
+
+	// MaxMode is the most permissive mode allowed, such as OSUserReadWrite
+	// to forbid any group or other access. Setuid, setgid and sticky bits
+	// must also be allowed here.
+	//
+	// The owner execute bit of directories is not checked, as it only lets
+	// the owner search the directory, so a rule written for files, such as
+	// OSUserReadWrite, can also match the directories holding them without
+	// WithAuditFix making those directories unusable. The group and other
+	// execute bits are checked, as they let other users reach the files
+	// inside.
+	MaxMode Mode
+
+	// UID, if not nil, is the required owner user id.
+	UID *int
+
+	// GID, if not nil, is the required owner group id.
+	GID *int
+}
+
+// ID returns a pointer to id, for use as AuditRule.UID and AuditRule.GID.
+func ID(id int) *int {
+	return &id
+}
+
+// Violation is a path that failed an audit check.
+type Violation struct {
+	// Path is the path relative to the audited root.
+	Path string
+
+	// Kind is the failed check.
+	Kind ViolationKind
+
+	// Pattern is the pattern of the rule that was violated. It is empty for
+	// SymlinkEscapesRoot.
+	Pattern string
+
+	// Mode is the mode of the path when it was audited.
+	Mode Mode
+
+	// MaxMode is the most permissive mode allowed by the rule.
+	MaxMode Mode
+
+	// UID and GID are the owner ids of the path, or -1 if unavailable on
+	// this platform.
+	UID, GID int
+
+	// Target is the destination of a symbolic link.
+	Target string
+
+	// Fixed is true if the violation was corrected by tightening the mode.
+	Fixed bool
+}
+
+// String returns a human readable description of v suitable for logs.
+func (v Violation) String() string {
+	var s string
+
+	switch v.Kind {
+	case ModeTooPermissive:
+		s = fmt.Sprintf("%s: %s: %s exceeds %s (rule %q)", v.Path, v.Kind, v.Mode.Ls(), v.MaxMode.Ls(), v.Pattern)
+	case WrongOwner, WrongGroup:
+		s = fmt.Sprintf("%s: %s: uid %d gid %d (rule %q)", v.Path, v.Kind, v.UID, v.GID, v.Pattern)
+	case SymlinkEscapesRoot:
+		s = fmt.Sprintf("%s: %s: points to %s", v.Path, v.Kind, v.Target)
+	default:
+		s = fmt.Sprintf("%s: %s", v.Path, v.Kind)
+	}
+
+	if v.Fixed {
+		s += " (fixed)"
+	}
+
+	return s
+}
+
+type AuditOption struct {
+	fix bool
+}
+
+// WithAuditFix tightens the mode of paths violating their rule's MaxMode by
+// removing the disallowed bits, keeping the owner execute bit of
+// directories.
+// Ownership and symlinks are never changed.
+func WithAuditFix() func(o *AuditOption) {
+	return func(o *AuditOption) {
+		o.fix = true
+	}
+}
+
+// Audit walks the tree at root and checks every path against the first rule
+// whose pattern matches it, and checks that no symbolic link points outside
+// of root. If root is a symbolic link, the tree it points to is audited;
+// other symbolic links are not followed. Paths no rule matches are only
+// checked for escaping symbolic links.
+//
+// For example, to require that private keys are only readable by their
+// owner:
+//
+//	violations, err := file.Audit("/etc/myapp", []file.AuditRule{
+//		{Pattern: "**/*.key", MaxMode: file.OSUserReadWrite, UID: file.ID(0)},
+//	})
+//
+// The returned error is only for failures to walk the tree; violations are
+// reported in the result.
+func Audit(root string, rules []AuditRule, options ...func(*AuditOption)) ([]Violation, error) {
+	op := AuditOption{}
+
+	for _, o := range options {
+		o(&op)
+	}
+
+	for _, r := range rules {
+		if _, err := path.Match(strings.ReplaceAll(r.Pattern, "**", "*"), ""); err != nil {
+			return nil, fmt.Errorf("invalid audit pattern %q: %w", r.Pattern, err)
+		}
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+
+	realRoot, err = filepath.Abs(realRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []Violation
+
+	err = filepath.WalkDir(realRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(realRoot, p)
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		mode := FromFileMode(fi.Mode())
+		uid, gid, hasOwner := owner(fi)
+
+		if !hasOwner {
+			uid, gid = -1, -1
+		}
+
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			if target, escapes := symlinkEscapes(realRoot, p); escapes {
+				violations = append(violations, Violation{
+					Path:   rel,
+					Kind:   SymlinkEscapesRoot,
+					Mode:   mode,
+					UID:    uid,
+					GID:    gid,
+					Target: target,
+				})
+			}
+
+			// the permissions of a link itself are meaningless
+			return nil
+		}
+
+		r, ok := matchRule(rules, rel)
+		if !ok {
+			return nil
+		}
+
+		base := Violation{
+			Path:    rel,
+			Pattern: r.Pattern,
+			Mode:    mode,
+			MaxMode: r.MaxMode,
+			UID:     uid,
+			GID:     gid,
+		}
+
+		allowed := r.MaxMode
+		if d.IsDir() {
+			allowed |= mode & OSUserExecute
+		}
+
+		if mode&^allowed != 0 {
+			v := base
+			v.Kind = ModeTooPermissive
+
+			if op.fix {
+				if err := os.Chmod(p, (mode & allowed).FileMode()); err != nil {
+					return err
+				}
+
+				v.Fixed = true
+			}
+
+			violations = append(violations, v)
+		}
+
+		if hasOwner && r.UID != nil && *r.UID != uid {
+			v := base
+			v.Kind = WrongOwner
+			violations = append(violations, v)
+		}
+
+		if hasOwner && r.GID != nil && *r.GID != gid {
+			v := base
+			v.Kind = WrongGroup
+			violations = append(violations, v)
+		}
+
+		return nil
+	})
+
+	return violations, err
+}
+
+// matchRule returns the first rule matching the slash separated relative path
+func matchRule(rules []AuditRule, rel string) (AuditRule, bool) {
+	for _, r := range rules {
+		if matchPattern(r.Pattern, rel) {
+			return r, true
+		}
+	}
+
+	return AuditRule{}, false
+}
+
+// matchPattern reports whether rel matches pattern, see AuditRule.Pattern
+func matchPattern(pattern, rel string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// "**" matches zero or more whole segments
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// symlinkEscapes returns the target of the symbolic link at p and whether it
+// resolves outside of realRoot. Dangling links are checked lexically.
+func symlinkEscapes(realRoot, p string) (string, bool) {
+	target, err := os.Readlink(p)
+	if err != nil {
+		return "", false
+	}
+
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		resolved = target
+		if !filepath.IsAbs(resolved) {
+			dir, derr := filepath.EvalSymlinks(filepath.Dir(p))
+			if derr != nil {
+				dir = filepath.Dir(p)
+			}
+
+			resolved = filepath.Join(dir, resolved)
+		}
+	}
+
+	resolved, err = filepath.Abs(resolved)
+	if err != nil {
+		return target, true
+	}
+
+	rel, err := filepath.Rel(realRoot, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return target, true
+	}
+
+	return target, false
+}
@@ -0,0 +1,311 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchPattern(t *testing.T) {
+	t.Parallel()
+
+	testStructs := []struct {
+		Pattern  string
+		Path     string
+		Expected bool
+	}{
+		{Pattern: "*.pem", Path: "key.pem", Expected: true},
+		{Pattern: "*.pem", Path: "a/b/key.pem", Expected: true},
+		{Pattern: "*.pem", Path: "a/key.crt", Expected: false},
+		{Pattern: "secrets/*", Path: "secrets/db", Expected: true},
+		{Pattern: "secrets/*", Path: "secrets/a/db", Expected: false},
+		{Pattern: "secrets/**", Path: "secrets/a/db", Expected: true},
+		{Pattern: "**/*.key", Path: "tls.key", Expected: true},
+		{Pattern: "**/*.key", Path: "a/b/tls.key", Expected: true},
+		{Pattern: "a/**/c", Path: "a/c", Expected: true},
+		{Pattern: "a/**/c", Path: "a/b/b/c", Expected: true},
+		{Pattern: "a/**/c", Path: "a/b/d", Expected: false},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := matchPattern(testStruct.Pattern, testStruct.Path); got != testStruct.Expected {
+			t.Errorf("Expected %t, got %t for %q and %q on iteration %d", testStruct.Expected, got, testStruct.Pattern, testStruct.Path, i)
+		}
+	}
+}
+
+func TestAudit(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	write := func(name string, perm os.FileMode) {
+		p := filepath.Join(root, name)
+
+		if err := os.MkdirAll(filepath.Dir(p), OSUserReadWriteExecute); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(p, []byte(name), perm); err != nil {
+			t.Fatal(err)
+		}
+
+		// bypass the umask
+		if err := os.Chmod(p, perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("secrets/db.key", OSUserReadWrite)
+	write("secrets/api.key", OSUserRWGroupROtherR)
+	write("secrets/readme", OSUserRWGroupROtherR)
+	write("public/index.html", OSUserRWGroupROtherR)
+
+	if err := os.Symlink("../public/index.html", filepath.Join(root, "secrets", "inside")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink("/etc/passwd", filepath.Join(root, "secrets", "outside")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink("../../missing", filepath.Join(root, "secrets", "dangling")); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := []AuditRule{
+		{Pattern: "*.key", MaxMode: OSUserReadWrite},
+		{Pattern: "secrets/*", MaxMode: OSUserReadWrite | OSGroupRead},
+	}
+
+	violations, err := Audit(root, rules)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := map[string]ViolationKind{
+		"secrets/api.key":  ModeTooPermissive,
+		"secrets/readme":   ModeTooPermissive,
+		"secrets/outside":  SymlinkEscapesRoot,
+		"secrets/dangling": SymlinkEscapesRoot,
+	}
+
+	if len(violations) != len(expected) {
+		t.Errorf("expected %d violations, got %v", len(expected), violations)
+	}
+
+	for _, v := range violations {
+		if kind, ok := expected[v.Path]; !ok || kind != v.Kind {
+			t.Errorf("unexpected violation %s", v)
+		}
+
+		if v.Fixed {
+			t.Errorf("expected no fix, got %s", v)
+		}
+	}
+
+	// fixing tightens the mode to the first matching rule
+	violations, err = Audit(root, rules, WithAuditFix())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	fixed := 0
+
+	for _, v := range violations {
+		if v.Fixed {
+			fixed++
+		}
+	}
+
+	if fixed != 2 {
+		t.Errorf("expected 2 fixed violations, got %v", violations)
+	}
+
+	if fi, _ := os.Stat(filepath.Join(root, "secrets", "api.key")); fi.Mode().Perm() != OSUserReadWrite {
+		t.Errorf("expected %o, got %o", OSUserReadWrite, fi.Mode().Perm())
+	}
+
+	if fi, _ := os.Stat(filepath.Join(root, "secrets", "readme")); fi.Mode().Perm() != OSUserReadWrite|OSGroupRead {
+		t.Errorf("expected %o, got %o", OSUserReadWrite|OSGroupRead, fi.Mode().Perm())
+	}
+
+	// only the symbolic links remain
+	violations, err = Audit(root, rules)
+	if err != nil || len(violations) != 2 {
+		t.Errorf("expected 2 violations, got %v (%v)", violations, err)
+	}
+}
+
+func TestAuditDirectories(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	mkdir := func(name string, perm os.FileMode) {
+		if err := os.Mkdir(filepath.Join(root, name), perm); err != nil {
+			t.Fatal(err)
+		}
+
+		// bypass the umask
+		if err := os.Chmod(filepath.Join(root, name), perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write := func(name string, perm os.FileMode) {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(name), perm); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Chmod(filepath.Join(root, name), perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mkdir("private", OSUserRWXGroupRXOtherRX)
+	mkdir("private/certs", OSUserReadWriteExecute|OSGroupReadExecute)
+	mkdir("private/keys", OSUserReadWriteExecute|OSAllExecute)
+	mkdir("private/owner", OSUserReadWriteExecute)
+	write("private/certs/tls.pem", OSUserReadWrite|OSGroupRead)
+	write("private/keys/tls.key", OSUserReadWriteExecute)
+
+	// a rule written for files also matches the directories holding them
+	rules := []AuditRule{
+		{Pattern: "private/**", MaxMode: OSUserReadWrite},
+	}
+
+	violations, err := Audit(root, rules, WithAuditFix())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// the owner execute bit of directories is neither reported nor removed,
+	// the group and other execute bits and those of files are
+	testStructs := []struct {
+		Name     string
+		Expected os.FileMode
+		Violated bool
+	}{
+		{Name: "private", Expected: OSUserReadWriteExecute, Violated: true},
+		{Name: "private/certs", Expected: OSUserReadWriteExecute, Violated: true},
+		{Name: "private/keys", Expected: OSUserReadWriteExecute, Violated: true},
+		{Name: "private/owner", Expected: OSUserReadWriteExecute, Violated: false},
+		{Name: "private/certs/tls.pem", Expected: OSUserReadWrite, Violated: true},
+		{Name: "private/keys/tls.key", Expected: OSUserReadWrite, Violated: true},
+	}
+
+	for i, testStruct := range testStructs {
+		fi, err := os.Stat(filepath.Join(root, testStruct.Name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fi.Mode().Perm() != testStruct.Expected {
+			t.Errorf("Expected %o, got %o on iteration %d", testStruct.Expected, fi.Mode().Perm(), i)
+		}
+
+		violated := false
+
+		for _, v := range violations {
+			if v.Path == testStruct.Name {
+				violated = v.Kind == ModeTooPermissive && v.Fixed
+			}
+		}
+
+		if violated != testStruct.Violated {
+			t.Errorf("Expected violated %t, got %v on iteration %d", testStruct.Violated, violations, i)
+		}
+	}
+
+	violations, err = Audit(root, rules)
+	if err != nil || len(violations) != 0 {
+		t.Errorf("expected no violations, got %v (%v)", violations, err)
+	}
+}
+
+func TestAuditSymlinkRoot(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	secrets := filepath.Join(dir, "secrets")
+
+	if err := os.Mkdir(secrets, OSUserReadWriteExecute); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(secrets, "a.key"), []byte("a"), OSUserRWGroupROtherR); err != nil {
+		t.Fatal(err)
+	}
+
+	// bypass the umask
+	if err := os.Chmod(filepath.Join(secrets, "a.key"), OSUserRWGroupROtherR); err != nil {
+		t.Fatal(err)
+	}
+
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(secrets, link); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := []AuditRule{
+		{Pattern: "*.key", MaxMode: OSUserReadWrite},
+	}
+
+	for _, root := range []string{secrets, link} {
+		violations, err := Audit(root, rules)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(violations) != 1 || violations[0].Path != "a.key" || violations[0].Kind != ModeTooPermissive {
+			t.Errorf("expected a.key to be too permissive through %s, got %v", root, violations)
+		}
+	}
+}
+
+func TestAuditOwner(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(root, "tls.key"), nil, OSUserReadWrite); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(filepath.Join(root, "tls.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uid, gid, ok := owner(fi)
+	if !ok {
+		t.Skip("file ownership is unavailable on this platform")
+	}
+
+	violations, err := Audit(root, []AuditRule{
+		{Pattern: "*.key", MaxMode: OSUserReadWrite, UID: ID(uid), GID: ID(gid)},
+	})
+	if err != nil || len(violations) != 0 {
+		t.Errorf("expected no violations, got %v (%v)", violations, err)
+	}
+
+	violations, err = Audit(root, []AuditRule{
+		{Pattern: "*.key", MaxMode: OSUserReadWrite, UID: ID(uid + 1), GID: ID(gid + 1)},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(violations) != 2 || violations[0].Kind != WrongOwner || violations[1].Kind != WrongGroup {
+		t.Errorf("expected wrong owner and group, got %v", violations)
+	}
+}
+
+func TestAuditInvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Audit(t.TempDir(), []AuditRule{{Pattern: "[", MaxMode: OSUserReadWrite}}); err == nil {
+		t.Errorf("expected error for invalid pattern")
+	}
+}
@@ -0,0 +1,218 @@
+package file
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// SniffLen is how much of the start of a file DetectContentType needs to tell
+// the formats it knows apart. Office documents may need more than the 512
+// bytes http.DetectContentType considers.
+const SniffLen = 4096
+
+// Content types reported by DetectContentType in addition to those of
+// http.DetectContentType.
+const (
+	ContentTypePDF  = "application/pdf"
+	ContentTypeZip  = "application/zip"
+	ContentTypeGzip = "application/gzip"
+
+	ContentTypePNG  = "image/png"
+	ContentTypeJPEG = "image/jpeg"
+	ContentTypeGIF  = "image/gif"
+	ContentTypeWebP = "image/webp"
+	ContentTypeBMP  = "image/bmp"
+	ContentTypeTIFF = "image/tiff"
+	ContentTypeICO  = "image/x-icon"
+	ContentTypeHEIC = "image/heic"
+	ContentTypeAVIF = "image/avif"
+
+	// ContentTypeMSOffice is a legacy Office document, such as .doc, .xls or
+	// .ppt, stored in the OLE compound file format.
+	ContentTypeMSOffice = "application/vnd.ms-office"
+
+	ContentTypeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	ContentTypePPTX = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+
+	ContentTypeODT = "application/vnd.oasis.opendocument.text"
+	ContentTypeODS = "application/vnd.oasis.opendocument.spreadsheet"
+	ContentTypeODP = "application/vnd.oasis.opendocument.presentation"
+)
+
+// ErrContentTypeNotAllowed is returned by CheckContentType when the detected
+// content type is not in the allow-list.
+var ErrContentTypeNotAllowed = errors.New("content type not allowed")
+
+// magic is a signature at a fixed offset
+type magic struct {
+	offset      int
+	sig         []byte
+	contentType string
+}
+
+var magics = []magic{
+	{0, []byte("%PDF-"), ContentTypePDF},
+	{0, []byte("\x89PNG\r\n\x1a\n"), ContentTypePNG},
+	{0, []byte("\xff\xd8\xff"), ContentTypeJPEG},
+	{0, []byte("GIF87a"), ContentTypeGIF},
+	{0, []byte("GIF89a"), ContentTypeGIF},
+	{8, []byte("WEBP"), ContentTypeWebP},
+	{0, []byte("II*\x00"), ContentTypeTIFF},
+	{0, []byte("MM\x00*"), ContentTypeTIFF},
+	{0, []byte("\x00\x00\x01\x00"), ContentTypeICO},
+	{0, []byte("\x1f\x8b"), ContentTypeGzip},
+	{0, []byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1"), ContentTypeMSOffice},
+}
+
+// DetectContentType returns the content type of data, which should be at
+// least the first SniffLen bytes of a file. It recognizes PDF, common image
+// formats, zip, gzip, legacy Office documents and the zip based Office Open
+// XML and OpenDocument formats by their magic bytes, and falls back to
+// http.DetectContentType, so the result is never empty.
+//
+// Never trust the content type or file name a client sends; sniff the
+// content instead.
+func DetectContentType(data []byte) string {
+	for _, m := range magics {
+		if len(data) >= m.offset+len(m.sig) && bytes.Equal(data[m.offset:m.offset+len(m.sig)], m.sig) {
+			// WEBP must be in a RIFF container
+			if m.contentType == ContentTypeWebP && !bytes.HasPrefix(data, []byte("RIFF")) {
+				continue
+			}
+
+			return m.contentType
+		}
+	}
+
+	if t := detectISOBMFF(data); t != "" {
+		return t
+	}
+
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return detectZip(data)
+	}
+
+	return http.DetectContentType(data)
+}
+
+// CheckContentType detects the content type of data and returns it, or
+// ErrContentTypeNotAllowed if it does not match any of allowed. Allowed types
+// are compared without parameters and may end in a wildcard:
+//
+//	t, err := file.CheckContentType(body, file.ContentTypePDF, "image/*")
+func CheckContentType(data []byte, allowed ...string) (string, error) {
+	t := DetectContentType(data)
+
+	mediaType, _, err := mime.ParseMediaType(t)
+	if err != nil {
+		mediaType = t
+	}
+
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSpace(a))
+
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return t, nil
+			}
+
+			continue
+		}
+
+		if a == mediaType {
+			return t, nil
+		}
+	}
+
+	return t, fmt.Errorf("%w: %s", ErrContentTypeNotAllowed, mediaType)
+}
+
+// detectISOBMFF detects HEIC and AVIF images from the brand of their ftyp box
+func detectISOBMFF(data []byte) string {
+	if len(data) < 12 || !bytes.Equal(data[4:8], []byte("ftyp")) {
+		return ""
+	}
+
+	switch string(data[8:12]) {
+	case "heic", "heix", "heim", "heis", "mif1", "msf1":
+		return ContentTypeHEIC
+	case "avif", "avis":
+		return ContentTypeAVIF
+	}
+
+	return ""
+}
+
+// detectZip tells zip based document formats apart by the names of their
+// entries, which are stored uncompressed in each local file header
+func detectZip(data []byte) string {
+	sig := []byte("PK\x03\x04")
+
+	for i := 0; ; {
+		j := bytes.Index(data[i:], sig)
+		if j < 0 {
+			break
+		}
+
+		h := data[i+j:]
+		i += j + len(sig)
+
+		if len(h) < 30 {
+			break
+		}
+
+		nameLen := int(binary.LittleEndian.Uint16(h[26:28]))
+		extraLen := int(binary.LittleEndian.Uint16(h[28:30]))
+
+		if len(h) < 30+nameLen {
+			break
+		}
+
+		name := string(h[30 : 30+nameLen])
+
+		switch {
+		case name == "mimetype":
+			// OpenDocument stores its content type uncompressed first
+			start := 30 + nameLen + extraLen
+			size := uint64(binary.LittleEndian.Uint32(h[18:22]))
+
+			// compare in uint64, as a crafted size may overflow int on 32
+			// bit platforms
+			if start > len(h) || size > uint64(len(h)-start) {
+				continue
+			}
+
+			end := start + int(size)
+
+			// the size follows the data when a data descriptor is used
+			if end == start {
+				if k := bytes.Index(h[start:], []byte("PK")); k > 0 {
+					end = start + k
+				}
+			}
+
+			// only known types are returned, never the entry itself, which
+			// could hold anything
+			switch t := string(h[start:end]); t {
+			case ContentTypeODT, ContentTypeODS, ContentTypeODP:
+				return t
+			}
+
+			return ContentTypeZip
+		case strings.HasPrefix(name, "word/"):
+			return ContentTypeDOCX
+		case strings.HasPrefix(name, "xl/"):
+			return ContentTypeXLSX
+		case strings.HasPrefix(name, "ppt/"):
+			return ContentTypePPTX
+		}
+	}
+
+	return ContentTypeZip
+}
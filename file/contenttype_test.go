@@ -0,0 +1,115 @@
+package file
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+)
+
+// zipWith returns a zip archive holding empty entries with the given names,
+// storing the first uncompressed with the given contents if it is mimetype
+func zipWith(t *testing.T, mimetype string, names ...string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+
+	if mimetype != "" {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w.Write([]byte(mimetype))
+	}
+
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w.Write([]byte("<xml/>"))
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDetectContentType(t *testing.T) {
+	t.Parallel()
+
+	var gz bytes.Buffer
+
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("hello"))
+	zw.Close()
+
+	testStructs := []struct {
+		Data     []byte
+		Expected string
+	}{
+		{Data: []byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3"), Expected: ContentTypePDF},
+		{Data: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), Expected: ContentTypePNG},
+		{Data: []byte("\xff\xd8\xff\xe0\x00\x10JFIF"), Expected: ContentTypeJPEG},
+		{Data: []byte("GIF89a\x01\x00"), Expected: ContentTypeGIF},
+		{Data: []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), Expected: ContentTypeWebP},
+		{Data: []byte("RIFF\x24\x00\x00\x00WAVEfmt "), Expected: "audio/wave"},
+		{Data: []byte("II*\x00\x08\x00"), Expected: ContentTypeTIFF},
+		{Data: []byte("BM\x36\x00\x0c\x00\x00\x00\x00\x00\x36\x00\x00\x00\x28\x00\x00\x00"), Expected: ContentTypeBMP},
+		{Data: []byte("\x00\x00\x00\x18ftypheic\x00\x00"), Expected: ContentTypeHEIC},
+		{Data: []byte("\x00\x00\x00\x1cftypavif\x00\x00"), Expected: ContentTypeAVIF},
+		{Data: []byte("\x00\x00\x00\x10ftypmp42\x00\x00\x00\x00"), Expected: "video/mp4"},
+		{Data: gz.Bytes(), Expected: ContentTypeGzip},
+		{Data: []byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1\x00\x00"), Expected: ContentTypeMSOffice},
+		{Data: zipWith(t, "", "[Content_Types].xml", "_rels/.rels", "word/document.xml"), Expected: ContentTypeDOCX},
+		{Data: zipWith(t, "", "[Content_Types].xml", "xl/workbook.xml"), Expected: ContentTypeXLSX},
+		{Data: zipWith(t, "", "[Content_Types].xml", "ppt/presentation.xml"), Expected: ContentTypePPTX},
+		{Data: zipWith(t, ContentTypeODS, "content.xml"), Expected: ContentTypeODS},
+		{Data: zipWith(t, ContentTypeODT+"\r\nX-Evil: 1", "content.xml"), Expected: ContentTypeZip},
+		{Data: zipWith(t, "application/vnd.oasis.opendocument.graphics", "content.xml"), Expected: ContentTypeZip},
+		{Data: zipWith(t, "", "a.txt", "b.txt"), Expected: ContentTypeZip},
+		{Data: []byte("PK\x03\x04\x14\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xff\xff\xff\xff\x00\x00\x00\x00\x08\x00\x00\x00mimetypeapplication/vnd.oasis.opendocument.text"), Expected: ContentTypeZip},
+		{Data: []byte("plain text"), Expected: "text/plain; charset=utf-8"},
+		{Data: nil, Expected: "text/plain; charset=utf-8"},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := DetectContentType(testStruct.Data); got != testStruct.Expected {
+			t.Errorf("Expected %s, got %s on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+}
+
+func TestCheckContentType(t *testing.T) {
+	t.Parallel()
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	if got, err := CheckContentType(png, ContentTypePDF, "image/*"); err != nil || got != ContentTypePNG {
+		t.Errorf("expected %s, got %s (%v)", ContentTypePNG, got, err)
+	}
+
+	if got, err := CheckContentType([]byte("hello"), "TEXT/PLAIN"); err != nil || got != "text/plain; charset=utf-8" {
+		t.Errorf("expected text/plain, got %s (%v)", got, err)
+	}
+
+	if _, err := CheckContentType([]byte("<html><script>"), ContentTypePDF, "image/*"); !errors.Is(err, ErrContentTypeNotAllowed) {
+		t.Errorf("expected ErrContentTypeNotAllowed, got %v", err)
+	}
+
+	// a crafted OpenDocument mimetype entry is never returned as is
+	if got, err := CheckContentType(zipWith(t, ContentTypeODT+"\r\nX-Evil: 1"), "application/*"); err != nil || got != ContentTypeZip {
+		t.Errorf("expected %s, got %q (%v)", ContentTypeZip, got, err)
+	}
+
+	if _, err := CheckContentType(png); !errors.Is(err, ErrContentTypeNotAllowed) {
+		t.Errorf("expected ErrContentTypeNotAllowed with an empty allow-list, got %v", err)
+	}
+}
@@ -0,0 +1,361 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SymlinkPolicy controls how CopyTree and BuildManifest treat symbolic links.
+type SymlinkPolicy int
+
+const (
+	// SymlinkPreserve recreates symbolic links as links with the same
+	// target. Manifests record the target instead of a checksum.
+	SymlinkPreserve SymlinkPolicy = iota
+
+	// SymlinkFollow treats symbolic links as the file or directory they
+	// point to. Links that form a loop are an error.
+	SymlinkFollow
+
+	// SymlinkSkip ignores symbolic links.
+	SymlinkSkip
+
+	// SymlinkReject fails on the first symbolic link found.
+	SymlinkReject
+)
+
+// ErrSymlinkRejected is returned for a symbolic link under SymlinkReject.
+var ErrSymlinkRejected = errors.New("symbolic link rejected")
+
+type TreeOption struct {
+	symlinks SymlinkPolicy
+	include  []string
+	exclude  []string
+}
+
+// WithTreeSymlinks sets the symbolic link policy. The default is
+// SymlinkPreserve.
+func WithTreeSymlinks(policy SymlinkPolicy) func(o *TreeOption) {
+	return func(o *TreeOption) {
+		o.symlinks = policy
+	}
+}
+
+// WithTreeInclude limits files and symbolic links to those matching at least
+// one of patterns, using the syntax of AuditRule.Pattern. Directories are only
+// created as needed to hold included files.
+func WithTreeInclude(patterns ...string) func(o *TreeOption) {
+	return func(o *TreeOption) {
+		o.include = append(o.include, patterns...)
+	}
+}
+
+// WithTreeExclude skips paths matching any of patterns, using the syntax of
+// AuditRule.Pattern. An excluded directory is skipped with everything in it.
+// Exclusions take precedence over inclusions.
+func WithTreeExclude(patterns ...string) func(o *TreeOption) {
+	return func(o *TreeOption) {
+		o.exclude = append(o.exclude, patterns...)
+	}
+}
+
+// CopyTree copies the directory tree at src to dst, preserving the mode and
+// modification time of every file and directory:
+//
+//	err := file.CopyTree("build", "dist/bundle",
+//		file.WithTreeExclude("*.tmp", ".git"),
+//		file.WithTreeSymlinks(file.SymlinkFollow),
+//	)
+//
+// dst is created if needed. Existing files in dst are replaced and files not
+// in src are left alone. Modification times of preserved symbolic links are
+// not copied. dst must not be inside src.
+func CopyTree(src, dst string, options ...func(*TreeOption)) error {
+	op := treeOptions(options)
+
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+
+	absDst, err := filepath.Abs(dst)
+	if err != nil {
+		return err
+	}
+
+	if rel, err := filepath.Rel(absSrc, absDst); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("copy tree: destination %s is inside source %s", dst, src)
+	}
+
+	// directories are made writable while copying and get their own mode
+	// and modification time once their contents are in place
+	type dir struct {
+		rel string
+		fi  fs.FileInfo
+	}
+
+	var dirs []dir
+
+	infos := map[string]fs.FileInfo{}
+	created := map[string]bool{}
+
+	// ensureDir creates the directory at rel and its missing parents
+	var ensureDir func(rel string) error
+
+	ensureDir = func(rel string) error {
+		if created[rel] {
+			return nil
+		}
+
+		if rel != "." {
+			if err := ensureDir(filepath.Dir(rel)); err != nil {
+				return err
+			}
+		}
+
+		p := filepath.Join(dst, rel)
+
+		if err := os.Mkdir(p, OSUserReadWriteExecute); err != nil {
+			fi, serr := os.Stat(p)
+			if serr != nil || !fi.IsDir() {
+				return err
+			}
+
+			// an existing directory must be writable to copy into it
+			if err := os.Chmod(p, fi.Mode()|OSUserReadWriteExecute); err != nil {
+				return err
+			}
+		}
+
+		created[rel] = true
+		dirs = append(dirs, dir{rel: rel, fi: infos[rel]})
+
+		return nil
+	}
+
+	err = walkTree(src, op, func(rel, p string, fi fs.FileInfo) error {
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case fi.IsDir():
+			infos[rel] = fi
+
+			if len(op.include) > 0 && rel != "." {
+				return nil
+			}
+
+			return ensureDir(rel)
+		case fi.Mode()&fs.ModeSymlink != 0:
+			if err := ensureDir(filepath.Dir(rel)); err != nil {
+				return err
+			}
+
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+
+			if err := removeFile(target); err != nil {
+				return err
+			}
+
+			return os.Symlink(link, target)
+		case fi.Mode().IsRegular():
+			if err := ensureDir(filepath.Dir(rel)); err != nil {
+				return err
+			}
+
+			return copyFile(p, target, fi)
+		}
+
+		// devices, sockets and pipes can not be copied meaningfully
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// deepest first, so setting a directory's time is not undone by changes
+	// to its children
+	slices.Reverse(dirs)
+
+	for _, d := range dirs {
+		p := filepath.Join(dst, d.rel)
+
+		if err := os.Chmod(p, d.fi.Mode()&(fs.ModePerm|fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky)); err != nil {
+			return err
+		}
+
+		if err := os.Chtimes(p, d.fi.ModTime(), d.fi.ModTime()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyFile copies the regular file src to dst with the mode and modification
+// time of fi
+func copyFile(src, dst string, fi fs.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	// replacing rather than truncating works for read only destinations and
+	// does not write through hard links
+	if err := removeFile(dst); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, OSUserReadWrite)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(dst, fi.Mode()&(fs.ModePerm|fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky)); err != nil {
+		return err
+	}
+
+	return os.Chtimes(dst, fi.ModTime(), fi.ModTime())
+}
+
+// removeFile removes a file or symbolic link at name if there is one, but
+// never a directory
+func removeFile(name string) error {
+	fi, err := os.Lstat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if fi.IsDir() {
+		return &fs.PathError{Op: "copy", Path: name, Err: errors.New("is a directory")}
+	}
+
+	return os.Remove(name)
+}
+
+func treeOptions(options []func(*TreeOption)) TreeOption {
+	op := TreeOption{}
+
+	for _, o := range options {
+		o(&op)
+	}
+
+	return op
+}
+
+// walkTree calls fn for every path under root that passes the include and
+// exclude patterns, parents before children, in lexical order. rel is the
+// path relative to root and p the path to open it by. Symbolic links are
+// handled according to the policy; when followed, fi describes the target.
+func walkTree(root string, op TreeOption, fn func(rel, p string, fi fs.FileInfo) error) error {
+	for _, pattern := range append(slices.Clone(op.include), op.exclude...) {
+		if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	fi, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+
+	if !fi.IsDir() {
+		return &fs.PathError{Op: "walk", Path: root, Err: errors.New("not a directory")}
+	}
+
+	return walkTreeDir(root, ".", fi, op, nil, fn)
+}
+
+func walkTreeDir(p, rel string, fi fs.FileInfo, op TreeOption, ancestors []string, fn func(rel, p string, fi fs.FileInfo) error) error {
+	if op.symlinks == SymlinkFollow {
+		real, err := filepath.EvalSymlinks(p)
+		if err != nil {
+			return err
+		}
+
+		if slices.Contains(ancestors, real) {
+			return &fs.PathError{Op: "walk", Path: p, Err: errors.New("symbolic link loop")}
+		}
+
+		ancestors = append(ancestors, real)
+	}
+
+	if err := fn(rel, p, fi); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		childPath := filepath.Join(p, e.Name())
+		childRel := filepath.Join(rel, e.Name())
+		slashRel := filepath.ToSlash(childRel)
+
+		if slices.ContainsFunc(op.exclude, func(pattern string) bool { return matchPattern(pattern, slashRel) }) {
+			continue
+		}
+
+		fi, err := e.Info()
+		if err != nil {
+			return err
+		}
+
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			switch op.symlinks {
+			case SymlinkSkip:
+				continue
+			case SymlinkReject:
+				return &fs.PathError{Op: "walk", Path: childPath, Err: ErrSymlinkRejected}
+			case SymlinkFollow:
+				if fi, err = os.Stat(childPath); err != nil {
+					return err
+				}
+			}
+		}
+
+		if fi.IsDir() {
+			if err := walkTreeDir(childPath, childRel, fi, op, ancestors, fn); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if len(op.include) > 0 && !slices.ContainsFunc(op.include, func(pattern string) bool { return matchPattern(pattern, slashRel) }) {
+			continue
+		}
+
+		if err := fn(childRel, childPath, fi); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
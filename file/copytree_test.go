@@ -0,0 +1,192 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// writeTree creates files under root, keyed by slash separated path, with
+// their contents as data and the given permissions
+func writeTree(t *testing.T, root string, files map[string]os.FileMode) {
+	t.Helper()
+
+	for name, perm := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(p), OSUserRWXGroupRXOtherRX); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(p, []byte(name), perm); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Chmod(p, perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCopyTree(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions and symbolic links are limited on windows")
+	}
+
+	src := filepath.Join(t.TempDir(), "src")
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	writeTree(t, src, map[string]os.FileMode{
+		"bin/run":        OSUserRWXGroupRXOtherRX,
+		"etc/app.conf":   OSUserRWGroupROtherR,
+		"etc/secret.key": OSUserReadWrite,
+		"tmp/scratch":    OSUserReadWrite,
+		"notes.tmp":      OSUserReadWrite,
+	})
+
+	if err := os.Symlink("etc/app.conf", filepath.Join(src, "app.conf")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chmod(filepath.Join(src, "etc"), OSUserReadExecute); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		os.Chmod(filepath.Join(src, "etc"), OSUserReadWriteExecute)
+		os.Chmod(filepath.Join(dst, "etc"), OSUserReadWriteExecute)
+	})
+
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	if err := os.Chtimes(filepath.Join(src, "bin", "run"), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CopyTree(src, dst, WithTreeExclude("tmp", "*.tmp")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	testStructs := []struct {
+		Name string
+		Mode os.FileMode
+	}{
+		{Name: "bin/run", Mode: OSUserRWXGroupRXOtherRX},
+		{Name: "etc/app.conf", Mode: OSUserRWGroupROtherR},
+		{Name: "etc/secret.key", Mode: OSUserReadWrite},
+		{Name: "etc", Mode: OSUserReadExecute},
+	}
+
+	for i, testStruct := range testStructs {
+		fi, err := os.Stat(filepath.Join(dst, testStruct.Name))
+		if err != nil {
+			t.Errorf("Expected no error, got %v on iteration %d", err, i)
+			continue
+		}
+
+		if fi.Mode().Perm() != testStruct.Mode {
+			t.Errorf("Expected %o, got %o for %s on iteration %d", testStruct.Mode, fi.Mode().Perm(), testStruct.Name, i)
+		}
+	}
+
+	if fi, _ := os.Stat(filepath.Join(dst, "bin", "run")); !fi.ModTime().Equal(mtime) {
+		t.Errorf("expected %v, got %v", mtime, fi.ModTime())
+	}
+
+	if target, err := os.Readlink(filepath.Join(dst, "app.conf")); err != nil || target != "etc/app.conf" {
+		t.Errorf("expected etc/app.conf, got %s (%v)", target, err)
+	}
+
+	for _, name := range []string{"tmp", "notes.tmp"} {
+		if _, err := os.Lstat(filepath.Join(dst, name)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected %s to be excluded, got %v", name, err)
+		}
+	}
+
+	// copying again replaces files, including read only ones
+	if err := CopyTree(src, dst, WithTreeExclude("tmp", "*.tmp")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestCopyTreeSymlinks(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links are limited on windows")
+	}
+
+	src := t.TempDir()
+
+	writeTree(t, src, map[string]os.FileMode{"data/a.txt": OSUserReadWrite})
+
+	if err := os.Symlink("data", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "follow")
+
+	if err := CopyTree(src, dst, WithTreeSymlinks(SymlinkFollow)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if fi, err := os.Lstat(filepath.Join(dst, "link", "a.txt")); err != nil || !fi.Mode().IsRegular() {
+		t.Errorf("expected a copied file, got %v (%v)", fi, err)
+	}
+
+	dst = filepath.Join(t.TempDir(), "skip")
+
+	if err := CopyTree(src, dst, WithTreeSymlinks(SymlinkSkip)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := os.Lstat(filepath.Join(dst, "link")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, got %v", err)
+	}
+
+	if err := CopyTree(src, t.TempDir(), WithTreeSymlinks(SymlinkReject)); !errors.Is(err, ErrSymlinkRejected) {
+		t.Errorf("expected ErrSymlinkRejected, got %v", err)
+	}
+
+	// a link to an ancestor loops forever when followed
+	if err := os.Symlink("..", filepath.Join(src, "data", "up")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CopyTree(src, t.TempDir(), WithTreeSymlinks(SymlinkFollow)); err == nil {
+		t.Errorf("expected error for symbolic link loop")
+	}
+}
+
+func TestCopyTreeInclude(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	dst := t.TempDir()
+
+	writeTree(t, src, map[string]os.FileMode{
+		"a/b/keep.json": OSUserReadWrite,
+		"a/drop.txt":    OSUserReadWrite,
+		"c/drop.txt":    OSUserReadWrite,
+	})
+
+	if err := CopyTree(src, dst, WithTreeInclude("*.json")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dst, "a", "b", "keep.json")); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	assertOnlyFiles(t, dst, "a")
+	assertOnlyFiles(t, filepath.Join(dst, "a"), "b")
+
+	if err := CopyTree(src, filepath.Join(src, "a", "copy")); err == nil {
+		t.Errorf("expected error for destination inside source")
+	}
+}
@@ -0,0 +1,267 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrLocked is returned by TryLockShared and TryLockExclusive when the lock
+// is held by someone else.
+var ErrLocked = errors.New("file is locked")
+
+// ErrAlreadyRunning is returned by AcquirePIDFile when another live process
+// holds the PID file.
+var ErrAlreadyRunning = errors.New("another instance is running")
+
+const (
+	lockMinDelay = 5 * time.Millisecond
+	lockMaxDelay = 250 * time.Millisecond
+)
+
+// Lock is an advisory lock on a file, using flock(2) on unix systems. Locks
+// are held per open file, so separate goroutines and separate processes
+// exclude each other alike. The lock is released when the process exits,
+// even if it crashes.
+//
+// Advisory locks only exclude other callers that also lock; they do not stop
+// anyone from reading or writing the file.
+type Lock struct {
+	f *os.File
+}
+
+// LockShared acquires a shared lock on the named file, creating it with
+// OSUserReadWrite if needed. Any number of shared locks can be held at once,
+// but not alongside an exclusive lock. LockShared blocks until the lock is
+// acquired or ctx is done, in which case ctx.Err() is returned.
+func LockShared(ctx context.Context, name string) (*Lock, error) {
+	return lockFile(ctx, name, false, true)
+}
+
+// LockExclusive acquires an exclusive lock on the named file, creating it
+// with OSUserReadWrite if needed. LockExclusive blocks until the lock is
+// acquired or ctx is done, in which case ctx.Err() is returned.
+func LockExclusive(ctx context.Context, name string) (*Lock, error) {
+	return lockFile(ctx, name, true, true)
+}
+
+// TryLockShared is like LockShared but returns ErrLocked instead of waiting.
+func TryLockShared(name string) (*Lock, error) {
+	return lockFile(context.Background(), name, false, false)
+}
+
+// TryLockExclusive is like LockExclusive but returns ErrLocked instead of
+// waiting.
+func TryLockExclusive(name string) (*Lock, error) {
+	return lockFile(context.Background(), name, true, false)
+}
+
+func lockFile(ctx context.Context, name string, exclusive, wait bool) (*Lock, error) {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, OSUserReadWrite)
+	if err != nil && !exclusive && errors.Is(err, os.ErrPermission) {
+		// a shared lock only needs read access
+		f, err = os.Open(name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// flock can not be interrupted, so waiting is done by polling with
+	// backoff to honor ctx
+	delay := lockMinDelay
+
+	for {
+		err := flock(f, exclusive)
+		if err == nil {
+			return &Lock{f: f}, nil
+		}
+
+		if !errors.Is(err, ErrLocked) || !wait {
+			f.Close()
+
+			if errors.Is(err, ErrLocked) {
+				return nil, fmt.Errorf("%w: %s", ErrLocked, name)
+			}
+
+			return nil, err
+		}
+
+		t := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			f.Close()
+
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+
+		delay = min(delay*2, lockMaxDelay)
+	}
+}
+
+// Name returns the name of the locked file.
+func (l *Lock) Name() string {
+	return l.f.Name()
+}
+
+// Unlock releases the lock. It is safe to call Unlock more than once, which
+// makes it suitable for defer.
+func (l *Lock) Unlock() error {
+	if l.f == nil {
+		return nil
+	}
+
+	f := l.f
+	l.f = nil
+
+	// closing the file releases the lock
+	return f.Close()
+}
+
+// PIDFile is a single instance guard: an exclusively locked file holding the
+// process id of its owner.
+type PIDFile struct {
+	lock     *Lock
+	name     string
+	previous int
+}
+
+// AcquirePIDFile creates or takes over the named PID file and writes the
+// current process id to it, so that only one instance of a program runs at a
+// time:
+//
+//	pf, err := file.AcquirePIDFile("/run/myjob.pid")
+//	if errors.Is(err, file.ErrAlreadyRunning) {
+//		log.Println(err) // another instance is running: pid 1234
+//		return
+//	}
+//	if err != nil {
+//		return err
+//	}
+//	defer pf.Release()
+//
+// A PID file left behind by a process that exited without releasing it is
+// stale and taken over, since the lock died with its process; see
+// PreviousPID. ErrAlreadyRunning is returned while the lock is held.
+func AcquirePIDFile(name string) (*PIDFile, error) {
+	for {
+		l, err := TryLockExclusive(name)
+		if errors.Is(err, ErrLocked) {
+			if pid, _ := ReadPIDFile(name); pid > 0 {
+				return nil, fmt.Errorf("%w: pid %d", ErrAlreadyRunning, pid)
+			}
+
+			return nil, fmt.Errorf("%w: %s", ErrAlreadyRunning, name)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		// the previous owner may have removed the file between our open and
+		// lock, in which case we locked an orphan and must start over
+		fi, ferr := l.f.Stat()
+		ni, nerr := os.Stat(name)
+
+		if ferr != nil || nerr != nil || !os.SameFile(fi, ni) {
+			l.Unlock()
+			continue
+		}
+
+		previous, _ := readPID(l.f)
+
+		if previous == os.Getpid() || (previous > 0 && processAlive(previous)) {
+			// a live process without the lock did not write this file, or
+			// the pid has since been reused; either way it is not an owner
+			previous = 0
+		}
+
+		if err := writePID(l.f); err != nil {
+			l.Unlock()
+			return nil, err
+		}
+
+		return &PIDFile{lock: l, name: name, previous: previous}, nil
+	}
+}
+
+// Name returns the name of the PID file.
+func (p *PIDFile) Name() string {
+	return p.name
+}
+
+// PreviousPID returns the process id found in a stale PID file that was taken
+// over, or 0 if the file was new or released cleanly. It lets callers log or
+// clean up after a crashed instance.
+func (p *PIDFile) PreviousPID() int {
+	return p.previous
+}
+
+// Release removes the PID file and releases its lock. It is safe to call
+// Release more than once.
+func (p *PIDFile) Release() error {
+	if p.lock.f == nil {
+		return nil
+	}
+
+	// remove while still holding the lock so no other process can take over
+	// a file that is about to disappear
+	err := os.Remove(p.name)
+
+	if uerr := p.lock.Unlock(); uerr != nil && err == nil {
+		err = uerr
+	}
+
+	return err
+}
+
+// ReadPIDFile returns the process id stored in the named PID file.
+func ReadPIDFile(name string) (int, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+
+	defer f.Close()
+
+	return readPID(f)
+}
+
+func readPID(f *os.File) (int, error) {
+	b, err := io.ReadAll(io.NewSectionReader(f, 0, 64))
+	if err != nil {
+		return 0, err
+	}
+
+	s := strings.TrimSpace(string(b))
+	if s == "" {
+		return 0, nil
+	}
+
+	pid, err := strconv.Atoi(s)
+	if err != nil || pid < 0 {
+		return 0, fmt.Errorf("invalid pid file %s: %q", f.Name(), s)
+	}
+
+	return pid, nil
+}
+
+func writePID(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+
+	if _, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
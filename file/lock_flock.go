@@ -0,0 +1,55 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package file
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// flock tries to lock f without blocking, returning ErrLocked if the lock is
+// held elsewhere
+func flock(f *os.File, exclusive bool) error {
+	how := syscall.LOCK_SH | syscall.LOCK_NB
+	if exclusive {
+		how = syscall.LOCK_EX | syscall.LOCK_NB
+	}
+
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var ferr error
+
+	err = rc.Control(func(fd uintptr) {
+		for {
+			ferr = syscall.Flock(int(fd), how)
+			if !errors.Is(ferr, syscall.EINTR) {
+				return
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if errors.Is(ferr, syscall.EWOULDBLOCK) {
+		return ErrLocked
+	}
+
+	if ferr != nil {
+		return os.NewSyscallError("flock", ferr)
+	}
+
+	return nil
+}
+
+// processAlive reports whether a process with the given id exists
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+
+	// EPERM means the process exists but belongs to another user
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
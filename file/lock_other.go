@@ -0,0 +1,18 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package file
+
+import (
+	"errors"
+	"os"
+)
+
+// flock is unavailable on this platform
+func flock(f *os.File, exclusive bool) error {
+	return errors.ErrUnsupported
+}
+
+// processAlive can not tell on this platform, so assume the process exists
+func processAlive(pid int) bool {
+	return true
+}
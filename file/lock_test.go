@@ -0,0 +1,279 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package file
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// lockHelperEnv makes the test binary act as a separate process holding a
+// lock, see TestLockHelperProcess
+const lockHelperEnv = "GOUTIL_FILE_LOCK_HELPER"
+
+func TestLockExclusive(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "job.lock")
+
+	l, err := LockExclusive(context.Background(), name)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if fi, _ := os.Stat(name); fi.Mode().Perm() != OSUserReadWrite {
+		t.Errorf("expected %o, got %o", OSUserReadWrite, fi.Mode().Perm())
+	}
+
+	if _, err := TryLockExclusive(name); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked, got %v", err)
+	}
+
+	if _, err := TryLockShared(name); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := LockExclusive(ctx, name); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	if err := l.Unlock(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if err := l.Unlock(); err != nil {
+		t.Errorf("expected no error on second unlock, got %v", err)
+	}
+
+	l, err = TryLockExclusive(name)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	l.Unlock()
+}
+
+func TestLockShared(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "job.lock")
+
+	a, err := TryLockShared(name)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	b, err := TryLockShared(name)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := TryLockExclusive(name); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked, got %v", err)
+	}
+
+	a.Unlock()
+	b.Unlock()
+}
+
+func TestLockGoroutines(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "job.lock")
+
+	var (
+		wg      sync.WaitGroup
+		holders atomic.Int32
+		counter atomic.Int32
+	)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 10; j++ {
+				l, err := LockExclusive(context.Background(), name)
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+					return
+				}
+
+				if n := holders.Add(1); n != 1 {
+					t.Errorf("expected 1 holder, got %d", n)
+				}
+
+				counter.Add(1)
+
+				holders.Add(-1)
+				l.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if n := counter.Load(); n != 80 {
+		t.Errorf("expected 80, got %d", n)
+	}
+}
+
+func TestLockSubprocess(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "job.lock")
+	cmd, release := startLockHelper(t, "lock", name)
+
+	if _, err := TryLockExclusive(name); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked, got %v", err)
+	}
+
+	// a blocked waiter acquires the lock once the other process releases it
+	done := make(chan error, 1)
+
+	go func() {
+		l, err := LockExclusive(context.Background(), name)
+		if err == nil {
+			l.Unlock()
+		}
+
+		done <- err
+	}()
+
+	release()
+
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestPIDFile(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "job.pid")
+	cmd, _ := startLockHelper(t, "pid", name)
+
+	_, err := AcquirePIDFile(name)
+	if !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("expected ErrAlreadyRunning, got %v", err)
+	}
+
+	if expected := fmt.Sprintf("%s: pid %d", ErrAlreadyRunning, cmd.Process.Pid); err.Error() != expected {
+		t.Errorf("expected %s, got %s", expected, err)
+	}
+
+	// a crashed owner leaves a stale file behind
+	if err := cmd.Process.Kill(); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd.Wait()
+
+	pf, err := AcquirePIDFile(name)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if pf.PreviousPID() != cmd.Process.Pid {
+		t.Errorf("expected %d, got %d", cmd.Process.Pid, pf.PreviousPID())
+	}
+
+	if pid, err := ReadPIDFile(name); err != nil || pid != os.Getpid() {
+		t.Errorf("expected %d, got %d (%v)", os.Getpid(), pid, err)
+	}
+
+	if err := pf.Release(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if _, err := os.Stat(name); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, got %v", err)
+	}
+
+	// a cleanly released file is not stale
+	pf, err = AcquirePIDFile(name)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if pf.PreviousPID() != 0 {
+		t.Errorf("expected 0, got %d", pf.PreviousPID())
+	}
+
+	pf.Release()
+}
+
+// startLockHelper runs the test binary as a separate process that acquires a
+// lock or PID file and holds it until release is called
+func startLockHelper(t *testing.T, kind, name string) (*exec.Cmd, func()) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLockHelperProcess$")
+	cmd.Env = append(os.Environ(), lockHelperEnv+"="+kind+":"+name)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		stdin.Close()
+		cmd.Process.Kill()
+	})
+
+	if line, err := bufio.NewReader(stdout).ReadString('\n'); err != nil || line != "locked\n" {
+		t.Fatalf("expected locked, got %q (%v)", line, err)
+	}
+
+	return cmd, func() { stdin.Close() }
+}
+
+func TestLockHelperProcess(t *testing.T) {
+	v := os.Getenv(lockHelperEnv)
+	if v == "" {
+		t.Skip("helper process for TestLockSubprocess and TestPIDFile")
+	}
+
+	kind, name, _ := strings.Cut(v, ":")
+
+	if kind == "pid" {
+		if _, err := AcquirePIDFile(name); err != nil {
+			os.Exit(1)
+		}
+	} else if _, err := TryLockExclusive(name); err != nil {
+		os.Exit(1)
+	}
+
+	fmt.Println("locked")
+
+	// hold the lock until the parent closes stdin
+	bufio.NewReader(os.Stdin).ReadString('\n')
+	os.Exit(0)
+}
@@ -0,0 +1,260 @@
+package file
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// ManifestFile is the type of a regular file in a manifest.
+	ManifestFile = "file"
+
+	// ManifestDir is the type of a directory in a manifest.
+	ManifestDir = "dir"
+
+	// ManifestSymlink is the type of a symbolic link in a manifest.
+	ManifestSymlink = "symlink"
+)
+
+// ManifestEntry describes one path of a directory tree.
+type ManifestEntry struct {
+	// Path is the slash separated path relative to the root.
+	Path string `json:"path"`
+
+	// Type is ManifestFile, ManifestDir or ManifestSymlink.
+	Type string `json:"type"`
+
+	// Mode is the permission mode, omitted for symbolic links.
+	Mode Mode `json:"mode,omitempty"`
+
+	// Size is the size of a regular file.
+	Size int64 `json:"size,omitempty"`
+
+	// SHA256 is the hex encoded checksum of a regular file.
+	SHA256 string `json:"sha256,omitempty"`
+
+	// Target is the destination of a symbolic link.
+	Target string `json:"target,omitempty"`
+}
+
+// Manifest is a record of the files in a directory tree, their permissions
+// and checksums, used to detect drift in deployed artifacts.
+type Manifest struct {
+	Entries []ManifestEntry `json:"entries"`
+}
+
+// BuildManifest records every path under root. The options select paths and
+// symbolic link handling as for CopyTree; with SymlinkPreserve links record
+// their target, with SymlinkFollow they are checksummed as the file they
+// point to. Directories are only recorded without WithTreeInclude.
+func BuildManifest(root string, options ...func(*TreeOption)) (*Manifest, error) {
+	op := treeOptions(options)
+	m := &Manifest{}
+
+	err := walkTree(root, op, func(rel, p string, fi fs.FileInfo) error {
+		e := ManifestEntry{Path: filepath.ToSlash(rel)}
+
+		switch {
+		case fi.IsDir():
+			if rel == "." || len(op.include) > 0 {
+				return nil
+			}
+
+			e.Type = ManifestDir
+			e.Mode = FromFileMode(fi.Mode())
+		case fi.Mode()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+
+			e.Type = ManifestSymlink
+			e.Target = filepath.ToSlash(target)
+		case fi.Mode().IsRegular():
+			sum, err := sha256File(p)
+			if err != nil {
+				return err
+			}
+
+			e.Type = ManifestFile
+			e.Mode = FromFileMode(fi.Mode())
+			e.Size = fi.Size()
+			e.SHA256 = sum
+		default:
+			return nil
+		}
+
+		m.Entries = append(m.Entries, e)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// ReadManifest reads a manifest written by Manifest.WriteFile.
+func ReadManifest(name string) (*Manifest, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", name, err)
+	}
+
+	return m, nil
+}
+
+// WriteFile atomically writes m as indented JSON to the named file, created
+// with OSUserRWGroupROtherR.
+func (m *Manifest) WriteFile(name string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return WriteAtomic(name, append(b, '\n'), OSUserRWGroupROtherR)
+}
+
+// DriftKind identifies how a path differs from its manifest.
+type DriftKind int
+
+const (
+	// DriftMissing means a path in the manifest does not exist.
+	DriftMissing DriftKind = iota + 1
+
+	// DriftUnexpected means a path exists that is not in the manifest.
+	DriftUnexpected
+
+	// DriftType means a path changed type, such as a file that became a
+	// directory.
+	DriftType
+
+	// DriftContent means the checksum of a file or the target of a
+	// symbolic link changed.
+	DriftContent
+
+	// DriftMode means the permission mode changed.
+	DriftMode
+)
+
+// String returns a short description of k.
+func (k DriftKind) String() string {
+	switch k {
+	case DriftMissing:
+		return "missing"
+	case DriftUnexpected:
+		return "unexpected"
+	case DriftType:
+		return "type changed"
+	case DriftContent:
+		return "content changed"
+	case DriftMode:
+		return "mode changed"
+	}
+
+	return "unknown"
+}
+
+// Drift is a difference between a directory tree and its manifest.
+type Drift struct {
+	Path string
+	Kind DriftKind
+
+	// Expected is the manifest entry, nil for DriftUnexpected.
+	Expected *ManifestEntry
+
+	// Actual is the entry found on disk, nil for DriftMissing.
+	Actual *ManifestEntry
+}
+
+// String returns a human readable description of d suitable for logs.
+func (d Drift) String() string {
+	switch d.Kind {
+	case DriftType:
+		return fmt.Sprintf("%s: %s: %s became %s", d.Path, d.Kind, d.Expected.Type, d.Actual.Type)
+	case DriftMode:
+		return fmt.Sprintf("%s: %s: %s became %s", d.Path, d.Kind, d.Expected.Mode, d.Actual.Mode)
+	}
+
+	return fmt.Sprintf("%s: %s", d.Path, d.Kind)
+}
+
+// VerifyManifest compares the tree at root with m and returns the
+// differences, in manifest order followed by unexpected paths. The options
+// must match those used to build m. A nil result means no drift.
+func VerifyManifest(root string, m *Manifest, options ...func(*TreeOption)) ([]Drift, error) {
+	actual, err := BuildManifest(root, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]*ManifestEntry, len(actual.Entries))
+
+	for i := range actual.Entries {
+		found[actual.Entries[i].Path] = &actual.Entries[i]
+	}
+
+	var drift []Drift
+
+	seen := make(map[string]bool, len(m.Entries))
+
+	for i := range m.Entries {
+		want := &m.Entries[i]
+		seen[want.Path] = true
+
+		got, ok := found[want.Path]
+
+		switch {
+		case !ok:
+			drift = append(drift, Drift{Path: want.Path, Kind: DriftMissing, Expected: want})
+		case got.Type != want.Type:
+			drift = append(drift, Drift{Path: want.Path, Kind: DriftType, Expected: want, Actual: got})
+		default:
+			if got.SHA256 != want.SHA256 || got.Size != want.Size || got.Target != want.Target {
+				drift = append(drift, Drift{Path: want.Path, Kind: DriftContent, Expected: want, Actual: got})
+			}
+
+			if got.Mode != want.Mode {
+				drift = append(drift, Drift{Path: want.Path, Kind: DriftMode, Expected: want, Actual: got})
+			}
+		}
+	}
+
+	for _, got := range actual.Entries {
+		if !seen[got.Path] {
+			drift = append(drift, Drift{Path: got.Path, Kind: DriftUnexpected, Actual: found[got.Path]})
+		}
+	}
+
+	return drift, nil
+}
+
+func sha256File(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	h := sha256.New()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,99 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	writeTree(t, root, map[string]os.FileMode{
+		"bin/run":      OSUserRWXGroupRXOtherRX,
+		"etc/app.conf": OSUserRWGroupROtherR,
+		"etc/old.conf": OSUserRWGroupROtherR,
+	})
+
+	m, err := BuildManifest(root)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []string{"bin", "bin/run", "etc", "etc/app.conf", "etc/old.conf"}
+
+	if len(m.Entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %v", len(expected), m.Entries)
+	}
+
+	for i, e := range m.Entries {
+		if e.Path != expected[i] {
+			t.Errorf("Expected %s, got %s on iteration %d", expected[i], e.Path, i)
+		}
+	}
+
+	// sha256 of "bin/run"
+	if sum := m.Entries[1].SHA256; sum != "57b94356302c85712d33811d8bda2a57b5181e3b496466db149873f6ed118265" {
+		t.Errorf("expected sha256 of bin/run, got %s", sum)
+	}
+
+	name := filepath.Join(t.TempDir(), "manifest.json")
+
+	if err := m.WriteFile(name); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	m, err = ReadManifest(name)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if drift, err := VerifyManifest(root, m); err != nil || len(drift) != 0 {
+		t.Errorf("expected no drift, got %v (%v)", drift, err)
+	}
+
+	// introduce drift
+	if err := os.WriteFile(filepath.Join(root, "etc", "app.conf"), []byte("changed"), OSUserRWGroupROtherR); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(filepath.Join(root, "etc", "old.conf")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "etc", "new.conf"), nil, OSUserReadWrite); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedDrift := []Drift{
+		{Path: "etc/app.conf", Kind: DriftContent},
+		{Path: "etc/old.conf", Kind: DriftMissing},
+		{Path: "etc/new.conf", Kind: DriftUnexpected},
+	}
+
+	if runtime.GOOS != "windows" {
+		if err := os.Chmod(filepath.Join(root, "bin", "run"), OSUserReadWriteExecute); err != nil {
+			t.Fatal(err)
+		}
+
+		expectedDrift = append([]Drift{{Path: "bin/run", Kind: DriftMode}}, expectedDrift...)
+	}
+
+	drift, err := VerifyManifest(root, m)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(drift) != len(expectedDrift) {
+		t.Fatalf("expected %d differences, got %v", len(expectedDrift), drift)
+	}
+
+	for i, d := range drift {
+		if d.Path != expectedDrift[i].Path || d.Kind != expectedDrift[i].Kind {
+			t.Errorf("Expected %s %s, got %s on iteration %d", expectedDrift[i].Path, expectedDrift[i].Kind, d, i)
+		}
+	}
+}
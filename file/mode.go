@@ -0,0 +1,391 @@
+package file
+
+import (
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+const (
+	// OSSetUID is unix permission 04000.
+	OSSetUID = 04000
+
+	// OSSetGID is unix permission 02000.
+	OSSetGID = 02000
+
+	// OSSticky is unix permission 01000.
+	OSSticky = 01000
+)
+
+// Mode is a unix permission mode, the permission bits along with the setuid,
+// setgid and sticky bits, laid out as chmod expects them. The constants of
+// this package can be used as Mode values.
+//
+// Mode implements flag.Value, encoding.TextMarshaler and
+// encoding.TextUnmarshaler so it can be read directly from command line flags
+// and configuration files.
+type Mode uint32
+
+// modeMask is every bit a Mode can hold
+const modeMask = OSSetUID | OSSetGID | OSSticky | OSAllReadWriteExecute
+
+// ParseMode parses a mode in any of the following forms:
+//
+//	octal:     "640", "0640", "0o640", "4755"
+//	symbolic:  "u=rw,g=r,o=", "a+x", "u=rwxs,go=rx"
+//	ls -l:     "-rw-r-----", "rwsr-xr-x", "drwxrwxrwt"
+//
+// Symbolic modes are applied to an empty mode, see Mode.Apply.
+func ParseMode(s string) (Mode, error) {
+	s = strings.TrimSpace(s)
+
+	if s == "" {
+		return 0, fmt.Errorf("invalid mode %q: empty", s)
+	}
+
+	if isOctal(s) {
+		v, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimPrefix(s, "0o"), "0O"), 8, 32)
+		if err != nil || v&^modeMask != 0 {
+			return 0, fmt.Errorf("invalid octal mode %q", s)
+		}
+
+		return Mode(v), nil
+	}
+
+	if m, ok := parseLs(s); ok {
+		return m, nil
+	}
+
+	m, err := Mode(0).Apply(s)
+	if err != nil {
+		return 0, err
+	}
+
+	return m, nil
+}
+
+// FromFileMode returns the Mode of a fs.FileMode, such as the result of
+// os.FileInfo.Mode(). The file type bits are discarded.
+func FromFileMode(fm fs.FileMode) Mode {
+	m := Mode(fm.Perm())
+
+	if fm&fs.ModeSetuid != 0 {
+		m |= OSSetUID
+	}
+
+	if fm&fs.ModeSetgid != 0 {
+		m |= OSSetGID
+	}
+
+	if fm&fs.ModeSticky != 0 {
+		m |= OSSticky
+	}
+
+	return m
+}
+
+// FileMode returns m as a fs.FileMode suitable for os.Chmod and os.OpenFile.
+func (m Mode) FileMode() fs.FileMode {
+	fm := fs.FileMode(m & OSAllReadWriteExecute)
+
+	if m&OSSetUID != 0 {
+		fm |= fs.ModeSetuid
+	}
+
+	if m&OSSetGID != 0 {
+		fm |= fs.ModeSetgid
+	}
+
+	if m&OSSticky != 0 {
+		fm |= fs.ModeSticky
+	}
+
+	return fm
+}
+
+// Perm returns the permission bits of m, without setuid, setgid and sticky.
+func (m Mode) Perm() Mode {
+	return m & OSAllReadWriteExecute
+}
+
+// String returns m in octal form, such as "0640" or "4755".
+func (m Mode) String() string {
+	return fmt.Sprintf("%04o", uint32(m&modeMask))
+}
+
+// Octal returns m in octal form, such as "0640" or "4755".
+func (m Mode) Octal() string {
+	return m.String()
+}
+
+// Symbolic returns m in chmod symbolic form, such as "u=rw,g=r,o=" or
+// "u=rwxs,g=rx,o=rx".
+func (m Mode) Symbolic() string {
+	classes := [3]struct {
+		who     byte
+		shift   uint
+		special Mode
+		char    byte
+	}{
+		{'u', osUserShift, OSSetUID, 's'},
+		{'g', osGroupShift, OSSetGID, 's'},
+		{'o', osOtherShift, OSSticky, 't'},
+	}
+
+	var sb strings.Builder
+
+	for i, c := range classes {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+
+		sb.WriteByte(c.who)
+		sb.WriteByte('=')
+
+		bits := (m >> c.shift) & 07
+
+		if bits&osRead != 0 {
+			sb.WriteByte('r')
+		}
+
+		if bits&osWrite != 0 {
+			sb.WriteByte('w')
+		}
+
+		if bits&osExecute != 0 {
+			sb.WriteByte('x')
+		}
+
+		if m&c.special != 0 {
+			sb.WriteByte(c.char)
+		}
+	}
+
+	return sb.String()
+}
+
+// Ls returns m as the permission part of ls -l output, such as "rw-r-----"
+// or "rwsr-xr-t". Prefix a file type character such as '-' or 'd' for the
+// full column.
+func (m Mode) Ls() string {
+	b := []byte("---------")
+
+	for i, c := range "rwxrwxrwx" {
+		if m&(1<<(8-i)) != 0 {
+			b[i] = byte(c)
+		}
+	}
+
+	special := func(i int, bit Mode, lower, upper byte) {
+		if m&bit == 0 {
+			return
+		}
+
+		if b[i] == 'x' {
+			b[i] = lower
+		} else {
+			b[i] = upper
+		}
+	}
+
+	special(2, OSSetUID, 's', 'S')
+	special(5, OSSetGID, 's', 'S')
+	special(8, OSSticky, 't', 'T')
+
+	return string(b)
+}
+
+// Apply applies chmod style symbolic clauses, such as "g+w,o-rwx" or
+// "u=rwx,go=u-w", to m and returns the result.
+//
+// Each clause is an optional list of classes (u, g, o or a) followed by one
+// or more operations: an operator (+, - or =) and either permissions
+// (r, w, x, X, s, t) or a class to copy permissions from. Omitting the
+// classes is the same as "a"; unlike chmod, the umask is not consulted. X
+// sets execute only if m already has an execute bit set, since Mode does not
+// know whether it belongs to a directory.
+func (m Mode) Apply(symbolic string) (Mode, error) {
+	invalid := func(reason string) (Mode, error) {
+		return 0, fmt.Errorf("invalid symbolic mode %q: %s", symbolic, reason)
+	}
+
+	if strings.TrimSpace(symbolic) == "" {
+		return invalid("empty")
+	}
+
+	for _, clause := range strings.Split(symbolic, ",") {
+		i := 0
+
+		// classes
+		var who Mode
+
+		for ; i < len(clause) && strings.IndexByte("ugoa", clause[i]) >= 0; i++ {
+			switch clause[i] {
+			case 'u':
+				who |= OSUserReadWriteExecute | OSSetUID
+			case 'g':
+				who |= OSGroupReadWriteExecute | OSSetGID
+			case 'o':
+				who |= OSOtherReadWriteExecute | OSSticky
+			case 'a':
+				who |= modeMask
+			}
+		}
+
+		if who == 0 {
+			who = modeMask
+		}
+
+		if i == len(clause) {
+			return invalid("missing operator")
+		}
+
+		for i < len(clause) {
+			op := clause[i]
+			if op != '+' && op != '-' && op != '=' {
+				return invalid(fmt.Sprintf("unexpected %q", clause[i]))
+			}
+
+			i++
+
+			var perm Mode
+
+			// permissions copied from another class
+			if i < len(clause) && (clause[i] == 'u' || clause[i] == 'g' || clause[i] == 'o') {
+				var bits Mode
+
+				switch clause[i] {
+				case 'u':
+					bits = (m >> osUserShift) & 07
+				case 'g':
+					bits = (m >> osGroupShift) & 07
+				case 'o':
+					bits = (m >> osOtherShift) & 07
+				}
+
+				perm = bits<<osUserShift | bits<<osGroupShift | bits<<osOtherShift
+				i++
+			} else {
+				for ; i < len(clause) && strings.IndexByte("rwxXst", clause[i]) >= 0; i++ {
+					switch clause[i] {
+					case 'r':
+						perm |= OSAllRead
+					case 'w':
+						perm |= OSAllWrite
+					case 'x':
+						perm |= OSAllExecute
+					case 'X':
+						if m&OSAllExecute != 0 {
+							perm |= OSAllExecute
+						}
+					case 's':
+						perm |= OSSetUID | OSSetGID
+					case 't':
+						perm |= OSSticky
+					}
+				}
+			}
+
+			switch op {
+			case '+':
+				m |= perm & who
+			case '-':
+				m &^= perm & who
+			case '=':
+				m = m&^who | perm&who
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// Set parses s with ParseMode and stores the result in m. It implements
+// flag.Value.
+func (m *Mode) Set(s string) error {
+	v, err := ParseMode(s)
+	if err != nil {
+		return err
+	}
+
+	*m = v
+
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler using the octal form.
+func (m Mode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseMode.
+func (m *Mode) UnmarshalText(text []byte) error {
+	return m.Set(string(text))
+}
+
+func isOctal(s string) bool {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0o"), "0O")
+
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		if c < '0' || c > '7' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseLs parses the permission column of ls -l, with or without the file
+// type character
+func parseLs(s string) (Mode, bool) {
+	if len(s) == 10 {
+		if !strings.ContainsRune("-dlcbps", rune(s[0])) {
+			return 0, false
+		}
+
+		s = s[1:]
+	}
+
+	if len(s) != 9 {
+		return 0, false
+	}
+
+	var m Mode
+
+	for i := 0; i < 9; i++ {
+		c := s[i]
+		want := "rwx"[i%3]
+		bit := Mode(1 << (8 - i))
+
+		switch {
+		case c == '-':
+		case c == want:
+			m |= bit
+		case i%3 == 2 && (c == 's' || c == 'S') && i != 8:
+			if c == 's' {
+				m |= bit
+			}
+
+			if i == 2 {
+				m |= OSSetUID
+			} else {
+				m |= OSSetGID
+			}
+		case i == 8 && (c == 't' || c == 'T'):
+			if c == 't' {
+				m |= bit
+			}
+
+			m |= OSSticky
+		default:
+			return 0, false
+		}
+	}
+
+	return m, true
+}
@@ -0,0 +1,177 @@
+package file
+
+import (
+	"encoding/json"
+	"io/fs"
+	"testing"
+)
+
+func TestParseMode(t *testing.T) {
+	t.Parallel()
+
+	testStructs := []struct {
+		Input    string
+		Expected Mode
+		Error    bool
+	}{
+		{Input: "640", Expected: OSUserReadWrite | OSGroupRead},
+		{Input: "0640", Expected: OSUserReadWrite | OSGroupRead},
+		{Input: "0o640", Expected: OSUserReadWrite | OSGroupRead},
+		{Input: " 0755 ", Expected: OSUserRWXGroupRXOtherRX},
+		{Input: "4755", Expected: OSSetUID | OSUserRWXGroupRXOtherRX},
+		{Input: "1777", Expected: OSSticky | OSAllReadWriteExecute},
+		{Input: "0", Expected: 0},
+		{Input: "u=rw,g=r,o=", Expected: OSUserReadWrite | OSGroupRead},
+		{Input: "a=r,u+w", Expected: OSUserRWGroupROtherR},
+		{Input: "u=rwxs,go=rx", Expected: OSSetUID | OSUserRWXGroupRXOtherRX},
+		{Input: "=rwx,+t", Expected: OSSticky | OSAllReadWriteExecute},
+		{Input: "ug=rw,o=u-w", Expected: OSUserReadWrite | OSGroupReadWrite | OSOtherRead},
+		{Input: "-rw-r-----", Expected: OSUserReadWrite | OSGroupRead},
+		{Input: "rw-r--r--", Expected: OSUserRWGroupROtherR},
+		{Input: "-rwsr-xr-x", Expected: OSSetUID | OSUserRWXGroupRXOtherRX},
+		{Input: "-rwSr--r--", Expected: OSSetUID | OSUserRWGroupROtherR},
+		{Input: "-rwxr-sr-x", Expected: OSSetGID | OSUserRWXGroupRXOtherRX},
+		{Input: "drwxrwxrwt", Expected: OSSticky | OSAllReadWriteExecute},
+		{Input: "drwxrwxrwT", Expected: OSSticky | OSAllReadWrite | OSUserExecute | OSGroupExecute},
+		{Input: "", Error: true},
+		{Input: "0800", Error: true},
+		{Input: "17777", Error: true},
+		{Input: "u=rz", Error: true},
+		{Input: "u", Error: true},
+		{Input: "rwxrwxrwz", Error: true},
+		{Input: "qrwxrwxrwx", Error: true},
+	}
+
+	for i, testStruct := range testStructs {
+		got, err := ParseMode(testStruct.Input)
+
+		if (err != nil) != testStruct.Error {
+			t.Errorf("Expected error %t, got %v for %q on iteration %d", testStruct.Error, err, testStruct.Input, i)
+			continue
+		}
+
+		if got != testStruct.Expected {
+			t.Errorf("Expected %s, got %s for %q on iteration %d", testStruct.Expected, got, testStruct.Input, i)
+		}
+	}
+}
+
+func TestModeApply(t *testing.T) {
+	t.Parallel()
+
+	testStructs := []struct {
+		Mode     Mode
+		Input    string
+		Expected Mode
+	}{
+		{Mode: OSUserRWGroupROtherR, Input: "g+w,o-rwx", Expected: OSUserReadWrite | OSGroupReadWrite},
+		{Mode: OSAllReadWriteExecute, Input: "go-w", Expected: OSUserRWXGroupRXOtherRX},
+		{Mode: OSUserReadWrite, Input: "a+X", Expected: OSUserReadWrite},
+		{Mode: OSUserReadWriteExecute, Input: "a+X", Expected: OSUserReadWriteExecute | OSGroupExecute | OSOtherExecute},
+		{Mode: OSUserReadWrite, Input: "g=u", Expected: OSUserReadWrite | OSGroupReadWrite},
+		{Mode: OSSetUID | OSUserRWXGroupRXOtherRX, Input: "u-s", Expected: OSUserRWXGroupRXOtherRX},
+		{Mode: OSUserRWXGroupRXOtherRX, Input: "o+s", Expected: OSUserRWXGroupRXOtherRX},
+		{Mode: OSUserRWXGroupRXOtherRX, Input: "g+s,+t", Expected: OSSetGID | OSSticky | OSUserRWXGroupRXOtherRX},
+		{Mode: OSAllReadWriteExecute, Input: "o=", Expected: OSUserReadWriteExecute | OSGroupReadWriteExecute},
+	}
+
+	for i, testStruct := range testStructs {
+		got, err := testStruct.Mode.Apply(testStruct.Input)
+		if err != nil {
+			t.Errorf("Expected no error, got %v on iteration %d", err, i)
+			continue
+		}
+
+		if got != testStruct.Expected {
+			t.Errorf("Expected %s, got %s for %q on iteration %d", testStruct.Expected, got, testStruct.Input, i)
+		}
+	}
+
+	if _, err := Mode(0).Apply("u+w,"); err == nil {
+		t.Errorf("expected error for empty clause")
+	}
+}
+
+func TestModeFormat(t *testing.T) {
+	t.Parallel()
+
+	testStructs := []struct {
+		Mode     Mode
+		Octal    string
+		Symbolic string
+		Ls       string
+	}{
+		{Mode: 0, Octal: "0000", Symbolic: "u=,g=,o=", Ls: "---------"},
+		{Mode: OSUserReadWrite | OSGroupRead, Octal: "0640", Symbolic: "u=rw,g=r,o=", Ls: "rw-r-----"},
+		{Mode: OSUserRWXGroupRXOtherRX, Octal: "0755", Symbolic: "u=rwx,g=rx,o=rx", Ls: "rwxr-xr-x"},
+		{Mode: OSSetUID | OSUserRWXGroupRXOtherRX, Octal: "4755", Symbolic: "u=rwxs,g=rx,o=rx", Ls: "rwsr-xr-x"},
+		{Mode: OSSetGID | OSUserRWGroupROtherR, Octal: "2644", Symbolic: "u=rw,g=rs,o=r", Ls: "rw-r-Sr--"},
+		{Mode: OSSticky | OSAllReadWriteExecute, Octal: "1777", Symbolic: "u=rwx,g=rwx,o=rwxt", Ls: "rwxrwxrwt"},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := testStruct.Mode.Octal(); got != testStruct.Octal {
+			t.Errorf("Expected %s, got %s on iteration %d", testStruct.Octal, got, i)
+		}
+
+		if got := testStruct.Mode.Symbolic(); got != testStruct.Symbolic {
+			t.Errorf("Expected %s, got %s on iteration %d", testStruct.Symbolic, got, i)
+		}
+
+		if got := testStruct.Mode.Ls(); got != testStruct.Ls {
+			t.Errorf("Expected %s, got %s on iteration %d", testStruct.Ls, got, i)
+		}
+
+		// every format parses back to the same mode
+		for _, s := range []string{testStruct.Octal, testStruct.Symbolic, testStruct.Ls} {
+			if got, err := ParseMode(s); err != nil || got != testStruct.Mode {
+				t.Errorf("Expected %s, got %s (%v) for %q on iteration %d", testStruct.Mode, got, err, s, i)
+			}
+		}
+	}
+}
+
+func TestModeFileMode(t *testing.T) {
+	t.Parallel()
+
+	m := Mode(OSSetUID | OSSetGID | OSSticky | OSUserRWXGroupRXOtherRX)
+	fm := fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky | 0o755
+
+	if got := m.FileMode(); got != fm {
+		t.Errorf("expected %v, got %v", fm, got)
+	}
+
+	if got := FromFileMode(fm | fs.ModeDir); got != m {
+		t.Errorf("expected %s, got %s", m, got)
+	}
+
+	if got := m.Perm(); got != OSUserRWXGroupRXOtherRX {
+		t.Errorf("expected 0755, got %s", got)
+	}
+}
+
+func TestModeText(t *testing.T) {
+	t.Parallel()
+
+	var config struct {
+		Mode Mode `json:"mode"`
+	}
+
+	if err := json.Unmarshal([]byte(`{"mode":"u=rw,g=r,o="}`), &config); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if config.Mode != OSUserReadWrite|OSGroupRead {
+		t.Errorf("expected 0640, got %s", config.Mode)
+	}
+
+	b, err := json.Marshal(config)
+	if err != nil || string(b) != `{"mode":"0640"}` {
+		t.Errorf("expected {\"mode\":\"0640\"}, got %s (%v)", b, err)
+	}
+
+	var m Mode
+	if err := m.Set("bogus"); err == nil {
+		t.Errorf("expected error, got %s", m)
+	}
+}
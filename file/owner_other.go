@@ -0,0 +1,12 @@
+//go:build !unix
+
+package file
+
+import (
+	"io/fs"
+)
+
+// owner is unavailable on platforms without unix file ownership
+func owner(fi fs.FileInfo) (uid, gid int, ok bool) {
+	return 0, 0, false
+}
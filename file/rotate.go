@@ -0,0 +1,378 @@
+package file
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotateTimeFormat is the timestamp in backup names; it sorts in time order
+const rotateTimeFormat = "20060102T150405.000"
+
+type RotateOption struct {
+	maxSize  int64
+	maxAge   time.Duration
+	backups  int
+	compress bool
+	mode     Mode
+	now      func() time.Time
+}
+
+// WithRotateMaxSize rotates the file before a write would make it larger than n
+// bytes. The default is 100 MiB; 0 disables rotation by size. A single write
+// larger than n is written to a fresh file whole.
+func WithRotateMaxSize(n int64) func(o *RotateOption) {
+	return func(o *RotateOption) {
+		o.maxSize = n
+	}
+}
+
+// WithRotateMaxAge rotates the file on the first write after it has been open
+// for d. Rotation by age is disabled by default.
+func WithRotateMaxAge(d time.Duration) func(o *RotateOption) {
+	return func(o *RotateOption) {
+		o.maxAge = d
+	}
+}
+
+// WithRotateBackups keeps the n most recent rotated files and removes older
+// ones. The default is 7; 0 keeps every backup.
+func WithRotateBackups(n int) func(o *RotateOption) {
+	return func(o *RotateOption) {
+		o.backups = n
+	}
+}
+
+// WithRotateCompression gzips rotated files in the background.
+func WithRotateCompression() func(o *RotateOption) {
+	return func(o *RotateOption) {
+		o.compress = true
+	}
+}
+
+// WithRotateFileMode sets the mode log files are created with. The default is
+// OSUserRWGroupROtherR.
+func WithRotateFileMode(mode Mode) func(o *RotateOption) {
+	return func(o *RotateOption) {
+		o.mode = mode
+	}
+}
+
+// WithRotateClock sets the clock used for file ages and backup names, for
+// testing.
+func WithRotateClock(now func() time.Time) func(o *RotateOption) {
+	return func(o *RotateOption) {
+		o.now = now
+	}
+}
+
+// RotatingWriter is an io.WriteCloser that writes to a file and rotates it by
+// size and age, keeping a limited number of backups:
+//
+//	w, err := file.NewRotatingWriter("/var/log/myapp/app.log",
+//		file.WithRotateMaxSize(10<<20),
+//		file.WithRotateBackups(5),
+//		file.WithRotateCompression(),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	defer w.Close()
+//
+//	logger := slog.New(slog.NewJSONHandler(w, nil))
+//
+// Rotated files are renamed with a UTC timestamp before the extension, such as
+// "app-20240102T150405.000.log", and gain ".gz" when compressed. It is safe
+// for concurrent use.
+type RotatingWriter struct {
+	name   string
+	op     RotateOption
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	opened time.Time
+
+	// mill serializes compressing and pruning backups, which run in the
+	// background so writers are not blocked
+	mill sync.Mutex
+	wg   sync.WaitGroup
+}
+
+// NewRotatingWriter opens the named file for appending, creating it and its
+// directory if needed.
+func NewRotatingWriter(name string, options ...func(*RotateOption)) (*RotatingWriter, error) {
+	op := RotateOption{
+		maxSize: 100 << 20,
+		backups: 7,
+		mode:    OSUserRWGroupROtherR,
+		now:     time.Now,
+	}
+
+	for _, o := range options {
+		o(&op)
+	}
+
+	w := &RotatingWriter{name: name, op: op}
+
+	if err := os.MkdirAll(filepath.Dir(name), OSUserRWXGroupRXOtherRX); err != nil {
+		return nil, err
+	}
+
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Write writes p to the file, rotating it first if needed.
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		return 0, os.ErrClosed
+	}
+
+	tooBig := w.op.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.op.maxSize
+	tooOld := w.op.maxAge > 0 && w.op.now().Sub(w.opened) >= w.op.maxAge
+
+	if tooBig || tooOld {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+
+	return n, err
+}
+
+// Rotate rotates the file immediately, such as on SIGHUP.
+func (w *RotatingWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		return os.ErrClosed
+	}
+
+	return w.rotate()
+}
+
+// Close closes the file and waits for background compression and pruning to
+// finish. It is safe to call Close more than once.
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+
+	var err error
+
+	if w.f != nil {
+		err = w.f.Close()
+		w.f = nil
+	}
+
+	w.mu.Unlock()
+	w.wg.Wait()
+
+	return err
+}
+
+// open opens the current file for appending
+func (w *RotatingWriter) open() error {
+	f, err := os.OpenFile(w.name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, w.op.mode.FileMode())
+	if err != nil {
+		return err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.f = f
+	w.size = fi.Size()
+	w.opened = w.op.now()
+
+	return nil
+}
+
+// rotate renames the current file to a backup and opens a new one; w.mu must
+// be held. If rotating fails, the current file is reopened so that later
+// writes go on appending to it.
+func (w *RotatingWriter) rotate() error {
+	err := w.f.Close()
+	w.f = nil
+
+	if err != nil {
+		return w.reopen(err)
+	}
+
+	// a name is taken while either the backup or its compressed copy
+	// exists, as compressing would overwrite the copy
+	backup := w.backupName(w.op.now())
+	for exists(backup) || exists(backup+".gz") {
+		// several rotations within a millisecond
+		backup = w.backupName(w.parseBackup(filepath.Base(backup)).Add(time.Millisecond))
+	}
+
+	if err := os.Rename(w.name, backup); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return w.reopen(err)
+	}
+
+	if err := w.open(); err != nil {
+		return w.reopen(err)
+	}
+
+	w.wg.Add(1)
+
+	go func() {
+		defer w.wg.Done()
+
+		w.mill.Lock()
+		defer w.mill.Unlock()
+
+		if w.op.compress {
+			_ = w.compress(backup)
+		}
+
+		_ = w.prune()
+	}()
+
+	return nil
+}
+
+// reopen opens the current file again after rotating it failed with err,
+// returning err
+func (w *RotatingWriter) reopen(err error) error {
+	if oerr := w.open(); oerr != nil {
+		return errors.Join(err, oerr)
+	}
+
+	return err
+}
+
+// exists reports whether a file, or a symbolic link, named name exists
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// backupName returns the name of a backup rotated at t
+func (w *RotatingWriter) backupName(t time.Time) string {
+	prefix, ext := w.split()
+
+	return prefix + "-" + t.UTC().Format(rotateTimeFormat) + ext
+}
+
+// split splits the file name into the part before the backup timestamp and
+// its extension
+func (w *RotatingWriter) split() (string, string) {
+	ext := filepath.Ext(w.name)
+
+	return strings.TrimSuffix(w.name, ext), ext
+}
+
+// parseBackup returns the rotation time of a backup file name, or the zero
+// time if base is not a backup of this writer
+func (w *RotatingWriter) parseBackup(base string) time.Time {
+	prefix, ext := w.split()
+	prefix = filepath.Base(prefix) + "-"
+
+	base = strings.TrimSuffix(base, ".gz")
+
+	if !strings.HasPrefix(base, prefix) || !strings.HasSuffix(base, ext) {
+		return time.Time{}
+	}
+
+	t, err := time.Parse(rotateTimeFormat, base[len(prefix):len(base)-len(ext)])
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
+
+// compress gzips a backup, removing the original once the copy is complete
+func (w *RotatingWriter) compress(name string) error {
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	out, err := NewAtomicWriter(name+".gz", w.op.mode.FileMode())
+	if err != nil {
+		return err
+	}
+
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+
+	if _, err := io.Copy(gz, in); err != nil {
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	if err := out.Commit(); err != nil {
+		return err
+	}
+
+	return os.Remove(name)
+}
+
+// prune removes the oldest backups beyond the configured number
+func (w *RotatingWriter) prune() error {
+	if w.op.backups <= 0 {
+		return nil
+	}
+
+	dir := filepath.Dir(w.name)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	type backup struct {
+		name string
+		t    time.Time
+	}
+
+	var backups []backup
+
+	for _, e := range entries {
+		if t := w.parseBackup(e.Name()); !t.IsZero() && e.Type().IsRegular() {
+			backups = append(backups, backup{name: filepath.Join(dir, e.Name()), t: t})
+		}
+	}
+
+	// newest first
+	slices.SortFunc(backups, func(a, b backup) int {
+		return b.t.Compare(a.t)
+	})
+
+	var errs []error
+
+	for _, b := range backups[min(w.op.backups, len(backups)):] {
+		if err := os.Remove(b.name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
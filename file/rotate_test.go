@@ -0,0 +1,307 @@
+package file
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock for WithRotateClock that only moves when told
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.t
+}
+
+func (c *fakeClock) add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.t = c.t.Add(d)
+}
+
+func TestRotatingWriterSize(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	clock := &fakeClock{t: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)}
+
+	w, err := NewRotatingWriter(name, WithRotateMaxSize(10), WithRotateBackups(2), WithRotateClock(clock.now))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	defer w.Close()
+
+	for _, s := range []string{"aaaa\n", "bbbb\n", "cccc\n", "dddd\n", "eeee\n", "ffff\n", "gggg\n"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		clock.add(time.Second)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// the oldest backup holding aaaa and bbbb was pruned
+	assertOnlyFiles(t, dir, "app-20240102T150409.000.log", "app-20240102T150411.000.log", "app.log")
+
+	testStructs := []struct {
+		Name     string
+		Expected string
+	}{
+		{Name: "app-20240102T150409.000.log", Expected: "cccc\ndddd\n"},
+		{Name: "app-20240102T150411.000.log", Expected: "eeee\nffff\n"},
+		{Name: "app.log", Expected: "gggg\n"},
+	}
+
+	for i, testStruct := range testStructs {
+		if b, _ := os.ReadFile(filepath.Join(dir, testStruct.Name)); string(b) != testStruct.Expected {
+			t.Errorf("Expected %q, got %q on iteration %d", testStruct.Expected, b, i)
+		}
+	}
+
+	if _, err := w.Write([]byte("x")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected os.ErrClosed, got %v", err)
+	}
+}
+
+func TestRotatingWriterAge(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "logs", "app.log")
+	clock := &fakeClock{t: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}
+
+	w, err := NewRotatingWriter(name, WithRotateMaxSize(0), WithRotateMaxAge(time.Hour), WithRotateCompression(), WithRotateFileMode(OSUserReadWrite), WithRotateClock(clock.now))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		if fi, _ := os.Stat(name); fi.Mode().Perm()&^OSUserReadWrite != 0 {
+			t.Errorf("expected at most %o, got %o", OSUserReadWrite, fi.Mode().Perm())
+		}
+	}
+
+	w.Write([]byte("first\n"))
+	clock.add(30 * time.Minute)
+	w.Write([]byte("second\n"))
+	clock.add(30 * time.Minute)
+	w.Write([]byte("third\n"))
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	assertOnlyFiles(t, filepath.Dir(name), "app-20240102T010000.000.log.gz", "app.log")
+
+	f, err := os.Open(filepath.Join(dir, "logs", "app-20240102T010000.000.log.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if b, _ := io.ReadAll(gz); string(b) != "first\nsecond\n" {
+		t.Errorf("expected first and second, got %q", b)
+	}
+}
+
+func TestRotatingWriterCollision(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+
+	// the clock never moves, so every rotation wants the same name
+	clock := &fakeClock{t: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)}
+
+	// a backup already compressed under the first name
+	if err := os.WriteFile(filepath.Join(dir, "app-20240102T150405.000.log.gz"), []byte("existing"), OSUserReadWrite); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewRotatingWriter(name, WithRotateMaxSize(0), WithRotateBackups(0), WithRotateCompression(), WithRotateClock(clock.now))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, s := range []string{"first\n", "second\n", "third\n"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if err := w.Rotate(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	assertOnlyFiles(t, dir,
+		"app-20240102T150405.000.log.gz",
+		"app-20240102T150405.001.log.gz",
+		"app-20240102T150405.002.log.gz",
+		"app-20240102T150405.003.log.gz",
+		"app.log",
+	)
+
+	if b, _ := os.ReadFile(filepath.Join(dir, "app-20240102T150405.000.log.gz")); string(b) != "existing" {
+		t.Errorf("expected the existing backup to be kept, got %q", b)
+	}
+
+	testStructs := []struct {
+		Name     string
+		Expected string
+	}{
+		{Name: "app-20240102T150405.001.log.gz", Expected: "first\n"},
+		{Name: "app-20240102T150405.002.log.gz", Expected: "second\n"},
+		{Name: "app-20240102T150405.003.log.gz", Expected: "third\n"},
+	}
+
+	for i, testStruct := range testStructs {
+		f, err := os.Open(filepath.Join(dir, testStruct.Name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if b, _ := io.ReadAll(gz); string(b) != testStruct.Expected {
+			t.Errorf("Expected %q, got %q on iteration %d", testStruct.Expected, b, i)
+		}
+
+		f.Close()
+	}
+}
+
+func TestRotatingWriterRotateFails(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	// the timestamp makes the backup name longer than a file name may be, so
+	// renaming to it fails
+	name := filepath.Join(dir, strings.Repeat("a", 240)+".log")
+
+	w, err := NewRotatingWriter(name, WithRotateMaxSize(0))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	defer w.Close()
+
+	if _, err := w.Write([]byte("before\n")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := w.Rotate(); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	// writing goes on to the same file
+	if _, err := w.Write([]byte("after\n")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if b, _ := os.ReadFile(name); string(b) != "before\nafter\n" {
+		t.Errorf("expected both writes, got %q", b)
+	}
+}
+
+func TestRotatingWriterConcurrent(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+
+	w, err := NewRotatingWriter(name, WithRotateMaxSize(1000), WithRotateBackups(0))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+
+	line := strings.Repeat("x", 99) + "\n"
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 50; j++ {
+				if _, err := w.Write([]byte(line)); err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+			}
+		}()
+
+		if i == 4 {
+			w.Rotate()
+		}
+	}
+
+	wg.Wait()
+	w.Close()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+
+	for _, e := range entries {
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// writes are never split or interleaved
+		if len(b)%len(line) != 0 || strings.Trim(string(b), "x\n") != "" {
+			t.Errorf("expected whole lines in %s", e.Name())
+		}
+
+		if len(b) > 1000 {
+			t.Errorf("expected at most 1000 bytes, got %d in %s", len(b), e.Name())
+		}
+
+		total += len(b)
+	}
+
+	if total != 8*50*len(line) {
+		t.Errorf("expected %d bytes, got %d", 8*50*len(line), total)
+	}
+}
@@ -0,0 +1,241 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ErrInsecureDir is returned when creating a temporary file or directory in a
+// world writable directory without the sticky bit, where any user could
+// rename or replace it.
+var ErrInsecureDir = errors.New("insecure temporary directory")
+
+type TempOption struct {
+	overwrite bool
+}
+
+// WithTempOverwrite overwrites regular files with zeros and syncs them before
+// they are removed. It is best effort: copy-on-write and journaling file
+// systems, SSDs and backups may still retain the original data.
+func WithTempOverwrite() func(o *TempOption) {
+	return func(o *TempOption) {
+		o.overwrite = true
+	}
+}
+
+// TempFile is a temporary file that is removed when closed.
+type TempFile struct {
+	*os.File
+	overwrite bool
+	closed    bool
+}
+
+// SecureTempFile creates a new temporary file in dir that is only readable
+// and writable by its owner (OSUserReadWrite). If dir is empty, os.TempDir
+// is used. Like os.CreateTemp, the file name is pattern with the last "*"
+// replaced by a random string, or pattern followed by a random string.
+//
+// The file is removed when closed, so the usual pattern is:
+//
+//	f, err := file.SecureTempFile("", "export-*.csv")
+//	if err != nil {
+//		return err
+//	}
+//	defer f.Close()
+//
+// ErrInsecureDir is returned if dir is world writable without the sticky bit.
+func SecureTempFile(dir, pattern string, options ...func(*TempOption)) (*TempFile, error) {
+	op := tempOptions(options)
+
+	dir, prefix, suffix, err := prepareTemp(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := createTemp(dir, prefix, suffix, OSUserReadWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TempFile{File: f, overwrite: op.overwrite}, nil
+}
+
+// Close closes and removes the file, overwriting it first if WithTempOverwrite
+// was used. It is safe to call Close more than once.
+func (f *TempFile) Close() error {
+	if f.closed {
+		return nil
+	}
+
+	f.closed = true
+
+	if f.overwrite {
+		_ = overwriteFile(f.File)
+	}
+
+	err := f.File.Close()
+
+	if rerr := os.Remove(f.Name()); rerr != nil && err == nil {
+		err = rerr
+	}
+
+	return err
+}
+
+// TempDir is a temporary directory that is removed along with its contents
+// when closed.
+type TempDir struct {
+	path      string
+	overwrite bool
+	closed    bool
+}
+
+// SecureTempDir creates a new temporary directory in dir that is only
+// accessible by its owner (OSUserReadWriteExecute). dir and pattern are
+// handled as in SecureTempFile.
+//
+// Close removes the directory and everything in it. Files created inside
+// should use restrictive permissions too, such as OSUserReadWrite, although
+// the directory already prevents other users from reaching them.
+func SecureTempDir(dir, pattern string, options ...func(*TempOption)) (*TempDir, error) {
+	op := tempOptions(options)
+
+	dir, prefix, suffix, err := prepareTemp(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < 10000; i++ {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10)+suffix)
+
+		err := os.Mkdir(name, OSUserReadWriteExecute)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &TempDir{path: name, overwrite: op.overwrite}, nil
+	}
+
+	return nil, &fs.PathError{Op: "mkdirtemp", Path: filepath.Join(dir, prefix+"*"+suffix), Err: fs.ErrExist}
+}
+
+// Path returns the path of the directory.
+func (d *TempDir) Path() string {
+	return d.path
+}
+
+// Join returns the path of elem inside the directory.
+func (d *TempDir) Join(elem ...string) string {
+	return filepath.Join(append([]string{d.path}, elem...)...)
+}
+
+// Close removes the directory and its contents, overwriting regular files
+// first if WithTempOverwrite was used. It is safe to call Close more than once.
+func (d *TempDir) Close() error {
+	if d.closed {
+		return nil
+	}
+
+	d.closed = true
+
+	if d.overwrite {
+		_ = filepath.WalkDir(d.path, func(p string, e fs.DirEntry, err error) error {
+			if err != nil || !e.Type().IsRegular() {
+				return nil
+			}
+
+			f, err := os.OpenFile(p, os.O_WRONLY, 0)
+			if err != nil {
+				return nil
+			}
+
+			_ = overwriteFile(f)
+			f.Close()
+
+			return nil
+		})
+	}
+
+	return os.RemoveAll(d.path)
+}
+
+func tempOptions(options []func(*TempOption)) TempOption {
+	op := TempOption{}
+
+	for _, o := range options {
+		o(&op)
+	}
+
+	return op
+}
+
+// prepareTemp resolves the directory of a temporary file, checks that it is
+// safe to create files in and splits pattern around its last "*"
+func prepareTemp(dir, pattern string) (string, string, string, error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	if strings.ContainsRune(pattern, os.PathSeparator) || strings.ContainsRune(pattern, '/') {
+		return "", "", "", &fs.PathError{Op: "createtemp", Path: pattern, Err: errors.New("pattern contains path separator")}
+	}
+
+	prefix, suffix := pattern, ""
+	if i := strings.LastIndexByte(pattern, '*'); i >= 0 {
+		prefix, suffix = pattern[:i], pattern[i+1:]
+	}
+
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if !fi.IsDir() {
+		return "", "", "", &fs.PathError{Op: "createtemp", Path: dir, Err: errors.New("not a directory")}
+	}
+
+	// Windows does not report meaningful permission bits for directories
+	if runtime.GOOS != "windows" && fi.Mode()&OSOtherWrite != 0 && fi.Mode()&fs.ModeSticky == 0 {
+		return "", "", "", fmt.Errorf("%w: %s is world writable without the sticky bit", ErrInsecureDir, dir)
+	}
+
+	return dir, prefix, suffix, nil
+}
+
+// overwriteFile replaces the contents of f with zeros and syncs it
+func overwriteFile(f *os.File) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	zeros := make([]byte, 32*1024)
+	w := io.NewOffsetWriter(f, 0)
+
+	for remaining := fi.Size(); remaining > 0; {
+		n := int64(len(zeros))
+		if remaining < n {
+			n = remaining
+		}
+
+		if _, err := w.Write(zeros[:n]); err != nil {
+			return err
+		}
+
+		remaining -= n
+	}
+
+	return f.Sync()
+}
@@ -0,0 +1,147 @@
+package file
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSecureTempFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	f, err := SecureTempFile(dir, "export-*.csv")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	defer f.Close()
+
+	base := filepath.Base(f.Name())
+	if !strings.HasPrefix(base, "export-") || !strings.HasSuffix(base, ".csv") {
+		t.Errorf("expected export-*.csv, got %s", base)
+	}
+
+	if runtime.GOOS != "windows" {
+		if fi, _ := os.Stat(f.Name()); fi.Mode().Perm() != OSUserReadWrite {
+			t.Errorf("expected %o, got %o", OSUserReadWrite, fi.Mode().Perm())
+		}
+	}
+
+	if _, err := f.WriteString("pii"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Errorf("expected no error on second close, got %v", err)
+	}
+
+	assertOnlyFiles(t, dir)
+}
+
+func TestSecureTempFileOverwrite(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	f, err := SecureTempFile(dir, "pii", WithTempOverwrite())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := f.WriteString("secret data"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// a hard link keeps the data reachable after the file is removed
+	link := filepath.Join(dir, "link")
+	if err := os.Link(f.Name(), link); err != nil {
+		t.Skipf("hard links are unsupported: %v", err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if b, _ := os.ReadFile(link); !bytes.Equal(b, make([]byte, len("secret data"))) {
+		t.Errorf("expected zeros, got %q", b)
+	}
+}
+
+func TestSecureTempDir(t *testing.T) {
+	t.Parallel()
+
+	parent := t.TempDir()
+
+	d, err := SecureTempDir(parent, "bundle-", WithTempOverwrite())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		if fi, _ := os.Stat(d.Path()); fi.Mode().Perm() != OSUserReadWriteExecute {
+			t.Errorf("expected %o, got %o", OSUserReadWriteExecute, fi.Mode().Perm())
+		}
+	}
+
+	if err := os.MkdirAll(d.Join("a", "b"), OSUserReadWriteExecute); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(d.Join("a", "b", "c"), []byte("data"), OSUserReadWrite); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Close(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	assertOnlyFiles(t, parent)
+}
+
+func TestSecureTempInsecureDir(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("directory permissions are not enforced on windows")
+	}
+
+	dir := t.TempDir()
+
+	if err := os.Chmod(dir, OSAllReadWriteExecute); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := SecureTempFile(dir, ""); !errors.Is(err, ErrInsecureDir) {
+		t.Errorf("expected ErrInsecureDir, got %v", err)
+	}
+
+	if _, err := SecureTempDir(dir, ""); !errors.Is(err, ErrInsecureDir) {
+		t.Errorf("expected ErrInsecureDir, got %v", err)
+	}
+
+	// the sticky bit makes a shared directory such as /tmp safe
+	if err := os.Chmod(dir, Mode(OSSticky|OSAllReadWriteExecute).FileMode()); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := SecureTempFile(dir, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	f.Close()
+
+	if _, err := SecureTempFile(dir, "a/b*"); err == nil {
+		t.Errorf("expected error for pattern with separator")
+	}
+}
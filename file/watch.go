@@ -0,0 +1,461 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// EventOp is a set of changes to a watched path.
+type EventOp uint32
+
+const (
+	// EventCreate means the path was created.
+	EventCreate EventOp = 1 << iota
+
+	// EventWrite means the contents of the path changed.
+	EventWrite
+
+	// EventRemove means the path was removed.
+	EventRemove
+
+	// EventReplace means the path was replaced by a different file, such as
+	// by WriteAtomic or a rename over it.
+	EventReplace
+
+	// EventChmod means the permissions of the path changed.
+	EventChmod
+
+	// EventModeMismatch means the permissions of the path differ from the
+	// mode set with WithWatchExpectedMode.
+	EventModeMismatch
+)
+
+// Has reports whether o contains all of op.
+func (o EventOp) Has(op EventOp) bool {
+	return o&op == op
+}
+
+// String returns the names of the changes in o, such as "WRITE|CHMOD".
+func (o EventOp) String() string {
+	names := []string{"CREATE", "WRITE", "REMOVE", "REPLACE", "CHMOD", "MODE_MISMATCH"}
+
+	var parts []string
+
+	for i, name := range names {
+		if o&(1<<i) != 0 {
+			parts = append(parts, name)
+		}
+	}
+
+	if len(parts) == 0 {
+		return "NONE"
+	}
+
+	return strings.Join(parts, "|")
+}
+
+// Event is a debounced change to a watched path.
+type Event struct {
+	// Path is the changed file, either a watched file or an entry of a
+	// watched directory.
+	Path string
+
+	// Op holds every change seen during the debounce period.
+	Op EventOp
+
+	// Mode is the mode of the path after the change, or 0 if it was
+	// removed.
+	Mode Mode
+}
+
+// String returns a description of e suitable for logs.
+func (e Event) String() string {
+	return fmt.Sprintf("%s: %s %s", e.Path, e.Op, e.Mode)
+}
+
+type WatchOption struct {
+	interval time.Duration
+	debounce time.Duration
+	expected *Mode
+	polling  bool
+}
+
+// WithWatchPollInterval sets how often paths are checked when polling. The
+// default is one second.
+func WithWatchPollInterval(d time.Duration) func(o *WatchOption) {
+	return func(o *WatchOption) {
+		o.interval = d
+	}
+}
+
+// WithWatchDebounce sets how long a path must be quiet before its changes are
+// delivered as one event. The default is 100 milliseconds.
+func WithWatchDebounce(d time.Duration) func(o *WatchOption) {
+	return func(o *WatchOption) {
+		o.debounce = d
+	}
+}
+
+// WithWatchExpectedMode reports EventModeMismatch for regular files whose mode
+// is not m, both when watching starts and whenever they change.
+func WithWatchExpectedMode(m Mode) func(o *WatchOption) {
+	return func(o *WatchOption) {
+		o.expected = &m
+	}
+}
+
+// WithWatchPolling forces polling even where a native backend, such as inotify
+// on Linux, is available. Polling also works on network file systems.
+func WithWatchPolling() func(o *WatchOption) {
+	return func(o *WatchOption) {
+		o.polling = true
+	}
+}
+
+// notifier wakes a watcher when a watched path may have changed
+type notifier interface {
+	C() <-chan struct{}
+	Close() error
+}
+
+// Watcher delivers changes to files and directories. Directories are watched
+// non-recursively: changes to their entries are reported, changes deeper in
+// the tree are not.
+//
+// Every change is confirmed by comparing the paths with their previous
+// state, so the polling and native backends report the same events.
+type Watcher struct {
+	paths    []string
+	op       WatchOption
+	events   chan Event
+	errors   chan error
+	notify   notifier
+	state    map[string]fs.FileInfo
+	pending  map[string]EventOp
+	cancel   context.CancelFunc
+	done     chan struct{}
+	closeErr error
+}
+
+// NewWatcher starts watching paths, which may be files or directories that
+// do not exist yet, as long as their parent directory does. Watching stops
+// when ctx is done or Close is called, which closes the Events channel:
+//
+//	w, err := file.NewWatcher(ctx, []string{"/etc/myapp/config.json"})
+//	if err != nil {
+//		return err
+//	}
+//
+//	for e := range w.Events() {
+//		if e.Op.Has(file.EventWrite) || e.Op.Has(file.EventReplace) {
+//			reload()
+//		}
+//	}
+//
+// Editors and deployment tools often replace files by renaming a new file
+// over them, which is reported as EventReplace.
+func NewWatcher(ctx context.Context, paths []string, options ...func(*WatchOption)) (*Watcher, error) {
+	op := WatchOption{
+		interval: time.Second,
+		debounce: 100 * time.Millisecond,
+	}
+
+	for _, o := range options {
+		o(&op)
+	}
+
+	if len(paths) == 0 {
+		return nil, errors.New("watch: no paths")
+	}
+
+	w := &Watcher{
+		op:      op,
+		events:  make(chan Event, 16),
+		errors:  make(chan error, 1),
+		state:   map[string]fs.FileInfo{},
+		pending: map[string]EventOp{},
+		done:    make(chan struct{}),
+	}
+
+	for _, p := range paths {
+		w.paths = append(w.paths, filepath.Clean(p))
+	}
+
+	if !op.polling {
+		n, err := newNotifier(w.paths)
+		if err != nil {
+			return nil, err
+		}
+
+		w.notify = n
+	}
+
+	// the initial state; only mode mismatches are reported for it
+	for p, fi := range w.scan() {
+		w.state[p] = fi
+
+		if w.mismatch(fi) {
+			w.pending[p] |= EventModeMismatch
+		}
+	}
+
+	ctx, w.cancel = context.WithCancel(ctx)
+
+	go w.run(ctx)
+
+	return w, nil
+}
+
+// Events returns the channel changes are delivered on. It is closed when the
+// watcher stops.
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// Errors returns a channel of errors encountered while checking paths, such
+// as permission errors. Errors are dropped if not received promptly. It is
+// closed when the watcher stops.
+func (w *Watcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Close stops the watcher and waits for it to finish. It is safe to call
+// Close more than once.
+func (w *Watcher) Close() error {
+	w.cancel()
+	<-w.done
+
+	return w.closeErr
+}
+
+func (w *Watcher) run(ctx context.Context) {
+	defer close(w.done)
+	defer close(w.events)
+	defer close(w.errors)
+
+	var wake <-chan struct{}
+
+	if w.notify != nil {
+		wake = w.notify.C()
+
+		defer func() {
+			w.closeErr = w.notify.Close()
+		}()
+	} else {
+		t := time.NewTicker(w.op.interval)
+		defer t.Stop()
+
+		tick := make(chan struct{})
+		wake = tick
+
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-t.C:
+					select {
+					case tick <- struct{}{}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	debounce := time.NewTimer(w.op.debounce)
+	defer debounce.Stop()
+
+	if len(w.pending) == 0 {
+		stopTimer(debounce)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-wake:
+			if !ok {
+				return
+			}
+
+			if w.update() {
+				// a timer that already fired must be drained before Reset,
+				// or the stale value would flush before the debounce delay
+				stopTimer(debounce)
+				debounce.Reset(w.op.debounce)
+			}
+		case <-debounce.C:
+			// changes that arrived since the last wake up are included
+			w.update()
+
+			if !w.flush(ctx) {
+				return
+			}
+		}
+	}
+}
+
+// stopTimer stops t and drains its channel if it already fired
+func stopTimer(t *time.Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+}
+
+// update compares the watched paths with their previous state, records the
+// differences as pending and reports whether there were any
+func (w *Watcher) update() bool {
+	current := w.scan()
+	changed := false
+
+	add := func(p string, op EventOp) {
+		if op != 0 {
+			w.pending[p] |= op
+			changed = true
+		}
+	}
+
+	for p, fi := range current {
+		prev, ok := w.state[p]
+
+		var op EventOp
+
+		switch {
+		case !ok:
+			op = EventCreate
+		case !os.SameFile(prev, fi):
+			op = EventReplace
+		default:
+			if !fi.IsDir() && (prev.Size() != fi.Size() || !prev.ModTime().Equal(fi.ModTime())) {
+				op |= EventWrite
+			}
+
+			if prev.Mode() != fi.Mode() {
+				op |= EventChmod
+			}
+		}
+
+		if op != 0 && w.mismatch(fi) {
+			op |= EventModeMismatch
+		}
+
+		add(p, op)
+	}
+
+	for p := range w.state {
+		if _, ok := current[p]; !ok {
+			add(p, EventRemove)
+		}
+	}
+
+	w.state = current
+
+	return changed
+}
+
+// flush delivers the pending events in path order and reports whether the
+// watcher is still running
+func (w *Watcher) flush(ctx context.Context) bool {
+	paths := make([]string, 0, len(w.pending))
+
+	for p := range w.pending {
+		paths = append(paths, p)
+	}
+
+	slices.Sort(paths)
+
+	for _, p := range paths {
+		e := Event{Path: p, Op: w.pending[p]}
+
+		fi, exists := w.state[p]
+		if exists {
+			e.Mode = FromFileMode(fi.Mode())
+		}
+
+		// a path created and removed again within the debounce period never
+		// existed as far as the caller is concerned
+		if e.Op.Has(EventCreate|EventRemove) && !exists {
+			delete(w.pending, p)
+			continue
+		}
+
+		select {
+		case w.events <- e:
+		case <-ctx.Done():
+			return false
+		}
+
+		delete(w.pending, p)
+	}
+
+	return true
+}
+
+// scan returns the current state of the watched paths and the entries of
+// watched directories
+func (w *Watcher) scan() map[string]fs.FileInfo {
+	state := map[string]fs.FileInfo{}
+
+	for _, p := range w.paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			w.report(err)
+			continue
+		}
+
+		if !fi.IsDir() {
+			state[p] = fi
+			continue
+		}
+
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			w.report(err)
+			continue
+		}
+
+		for _, e := range entries {
+			name := filepath.Join(p, e.Name())
+
+			// follow symbolic links so that swapping a link is a replace
+			fi, err := os.Stat(name)
+			if err != nil {
+				w.report(err)
+				continue
+			}
+
+			state[name] = fi
+		}
+	}
+
+	return state
+}
+
+func (w *Watcher) mismatch(fi fs.FileInfo) bool {
+	return w.op.expected != nil && fi.Mode().IsRegular() && FromFileMode(fi.Mode()) != *w.op.expected
+}
+
+// report sends err on the errors channel unless it is a path that does not
+// exist, which is normal for watched paths
+func (w *Watcher) report(err error) {
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+
+	select {
+	case w.errors <- err:
+	default:
+	}
+}
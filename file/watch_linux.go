@@ -0,0 +1,99 @@
+//go:build linux
+
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// inotifyMask is every event that may change the state of a watched path
+const inotifyMask = syscall.IN_ATTRIB | syscall.IN_CLOSE_WRITE | syscall.IN_CREATE |
+	syscall.IN_DELETE | syscall.IN_DELETE_SELF | syscall.IN_MODIFY |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_MOVE_SELF
+
+// inotify is a notifier backed by Linux inotify. Watched files are observed
+// through their parent directory, so that replacing a file by renaming
+// another over it is seen; inotify watches follow inodes, not names. For the
+// same reason watched directories are watched again whenever their parent
+// changes, so that the entries of a directory created or replaced after
+// watching started are seen too.
+type inotify struct {
+	fd    int
+	f     *os.File
+	c     chan struct{}
+	paths []string
+}
+
+func newNotifier(paths []string) (notifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+
+	// a non-blocking descriptor uses the runtime poller, so Close unblocks
+	// a pending Read
+	n := &inotify{fd: fd, f: os.NewFile(uintptr(fd), "inotify"), c: make(chan struct{}, 1), paths: paths}
+
+	dirs := map[string]bool{}
+
+	for _, p := range paths {
+		dirs[filepath.Dir(p)] = true
+	}
+
+	for dir := range dirs {
+		if _, err := syscall.InotifyAddWatch(fd, dir, inotifyMask); err != nil {
+			n.f.Close()
+			return nil, &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+		}
+	}
+
+	n.watchDirs()
+
+	go n.read()
+
+	return n, nil
+}
+
+// read turns inotify events into wake ups; the watcher works out what
+// changed by comparing state, so the events themselves are not decoded
+func (n *inotify) read() {
+	defer close(n.c)
+
+	buf := make([]byte, 64*1024)
+
+	for {
+		if _, err := n.f.Read(buf); err != nil {
+			return
+		}
+
+		// before waking the watcher, so that its scan sees every entry
+		// created since
+		n.watchDirs()
+
+		select {
+		case n.c <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// watchDirs watches the watched paths that are currently directories.
+// Watching a directory that is already watched only refreshes its watch, and
+// paths that are not directories, or no longer exist, are skipped.
+func (n *inotify) watchDirs() {
+	for _, p := range n.paths {
+		if fi, err := os.Stat(p); err == nil && fi.IsDir() {
+			_, _ = syscall.InotifyAddWatch(n.fd, p, inotifyMask)
+		}
+	}
+}
+
+func (n *inotify) C() <-chan struct{} {
+	return n.c
+}
+
+func (n *inotify) Close() error {
+	return n.f.Close()
+}
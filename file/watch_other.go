@@ -0,0 +1,8 @@
+//go:build !linux
+
+package file
+
+// newNotifier returns no native notifier, so watchers poll
+func newNotifier(paths []string) (notifier, error) {
+	return nil, nil
+}
@@ -0,0 +1,331 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// watchBackends runs a test with every backend available on this platform
+var watchBackends = map[string][]func(*WatchOption){
+	"polling": {WithWatchPolling(), WithWatchPollInterval(10 * time.Millisecond), WithWatchDebounce(50 * time.Millisecond)},
+	"native":  {WithWatchPollInterval(10 * time.Millisecond), WithWatchDebounce(50 * time.Millisecond)},
+}
+
+// nextEvent returns the next event or fails the test after a timeout
+func nextEvent(t *testing.T, w *Watcher) Event {
+	t.Helper()
+
+	select {
+	case e, ok := <-w.Events():
+		if !ok {
+			t.Fatal("expected an event, got closed channel")
+		}
+
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected an event, got timeout")
+	}
+
+	return Event{}
+}
+
+// assertNoEvent fails the test if an event arrives within a short period
+func assertNoEvent(t *testing.T, w *Watcher) {
+	t.Helper()
+
+	select {
+	case e := <-w.Events():
+		t.Errorf("expected no event, got %s", e)
+	case <-time.After(150 * time.Millisecond):
+	}
+}
+
+func TestWatcherFile(t *testing.T) {
+	t.Parallel()
+
+	for backend, options := range watchBackends {
+		t.Run(backend, func(t *testing.T) {
+			t.Parallel()
+
+			name := filepath.Join(t.TempDir(), "config.json")
+
+			if err := os.WriteFile(name, []byte("{}"), OSUserReadWrite); err != nil {
+				t.Fatal(err)
+			}
+
+			w, err := NewWatcher(context.Background(), []string{name}, options...)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			defer w.Close()
+
+			// a burst of writes is delivered as one event
+			f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < 5; i++ {
+				f.WriteString(" ")
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			f.Close()
+
+			if e := nextEvent(t, w); e.Path != name || e.Op != EventWrite {
+				t.Errorf("expected %s WRITE, got %s", name, e)
+			}
+
+			assertNoEvent(t, w)
+
+			// atomic replacement
+			if err := WriteAtomic(name, []byte(`{"a":1}`), OSUserReadWrite); err != nil {
+				t.Fatal(err)
+			}
+
+			if e := nextEvent(t, w); !e.Op.Has(EventReplace) {
+				t.Errorf("expected REPLACE, got %s", e)
+			}
+
+			if err := os.Remove(name); err != nil {
+				t.Fatal(err)
+			}
+
+			if e := nextEvent(t, w); e.Op != EventRemove || e.Mode != 0 {
+				t.Errorf("expected REMOVE, got %s", e)
+			}
+
+			if err := os.WriteFile(name, nil, OSUserReadWrite); err != nil {
+				t.Fatal(err)
+			}
+
+			if e := nextEvent(t, w); !e.Op.Has(EventCreate) {
+				t.Errorf("expected CREATE, got %s", e)
+			}
+		})
+	}
+}
+
+func TestWatcherDir(t *testing.T) {
+	t.Parallel()
+
+	for backend, options := range watchBackends {
+		t.Run(backend, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+
+			w, err := NewWatcher(context.Background(), []string{dir}, options...)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			defer w.Close()
+
+			a := filepath.Join(dir, "a.conf")
+			b := filepath.Join(dir, "b.conf")
+
+			if err := os.WriteFile(a, nil, OSUserReadWrite); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(b, nil, OSUserReadWrite); err != nil {
+				t.Fatal(err)
+			}
+
+			// events for different paths are delivered in path order
+			if e := nextEvent(t, w); e.Path != a || !e.Op.Has(EventCreate) {
+				t.Errorf("expected %s CREATE, got %s", a, e)
+			}
+
+			if e := nextEvent(t, w); e.Path != b || !e.Op.Has(EventCreate) {
+				t.Errorf("expected %s CREATE, got %s", b, e)
+			}
+
+			// a file created and removed within the debounce period is not
+			// reported
+			tmp := filepath.Join(dir, "tmp")
+
+			if err := os.WriteFile(tmp, nil, OSUserReadWrite); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.Remove(tmp); err != nil {
+				t.Fatal(err)
+			}
+
+			assertNoEvent(t, w)
+		})
+	}
+}
+
+func TestWatcherLateDir(t *testing.T) {
+	t.Parallel()
+
+	for backend, options := range watchBackends {
+		t.Run(backend, func(t *testing.T) {
+			t.Parallel()
+
+			dir := filepath.Join(t.TempDir(), "conf.d")
+
+			w, err := NewWatcher(context.Background(), []string{dir}, options...)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			defer w.Close()
+
+			// the entries of a directory created after watching started are
+			// watched too
+			if err := os.Mkdir(dir, OSUserReadWriteExecute); err != nil {
+				t.Fatal(err)
+			}
+
+			// the empty directory has nothing to report
+			assertNoEvent(t, w)
+
+			a := filepath.Join(dir, "a.conf")
+
+			if err := os.WriteFile(a, nil, OSUserReadWrite); err != nil {
+				t.Fatal(err)
+			}
+
+			if e := nextEvent(t, w); e.Path != a || !e.Op.Has(EventCreate) {
+				t.Errorf("expected %s CREATE, got %s", a, e)
+			}
+		})
+	}
+}
+
+func TestWatcherExpectedMode(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are limited on windows")
+	}
+
+	for backend, options := range watchBackends {
+		t.Run(backend, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			good := filepath.Join(dir, "good.key")
+			bad := filepath.Join(dir, "bad.key")
+
+			writeTree(t, dir, map[string]os.FileMode{
+				"good.key": OSUserReadWrite,
+				"bad.key":  OSUserRWGroupROtherR,
+			})
+
+			w, err := NewWatcher(context.Background(), []string{dir}, append(options, WithWatchExpectedMode(OSUserReadWrite))...)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			defer w.Close()
+
+			// existing mismatches are reported when watching starts
+			if e := nextEvent(t, w); e.Path != bad || e.Op != EventModeMismatch || e.Mode != OSUserRWGroupROtherR {
+				t.Errorf("expected %s MODE_MISMATCH, got %s", bad, e)
+			}
+
+			if err := os.Chmod(good, OSUserReadWrite|OSGroupRead); err != nil {
+				t.Fatal(err)
+			}
+
+			if e := nextEvent(t, w); e.Path != good || e.Op != EventChmod|EventModeMismatch {
+				t.Errorf("expected %s CHMOD|MODE_MISMATCH, got %s", good, e)
+			}
+
+			if err := os.Chmod(bad, OSUserReadWrite); err != nil {
+				t.Fatal(err)
+			}
+
+			if e := nextEvent(t, w); e.Path != bad || e.Op != EventChmod {
+				t.Errorf("expected %s CHMOD, got %s", bad, e)
+			}
+		})
+	}
+}
+
+func TestWatcherCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w, err := NewWatcher(ctx, []string{t.TempDir()})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-w.Events():
+		if ok {
+			t.Errorf("expected closed channel")
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("expected closed channel, got timeout")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Errorf("expected no error on second close, got %v", err)
+	}
+
+	if _, ok := <-w.Errors(); ok {
+		t.Errorf("expected closed errors channel")
+	}
+
+	if _, err := NewWatcher(context.Background(), nil); err == nil {
+		t.Errorf("expected error for no paths")
+	}
+}
+
+func TestStopTimer(t *testing.T) {
+	t.Parallel()
+
+	timer := time.NewTimer(time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	// the value of the fired timer must not survive a Reset
+	stopTimer(timer)
+	timer.Reset(time.Hour)
+
+	select {
+	case <-timer.C:
+		t.Errorf("expected drained timer")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// stopping a timer that has not fired does not block
+	stopTimer(timer)
+}
+
+func TestEventOpString(t *testing.T) {
+	t.Parallel()
+
+	testStructs := []struct {
+		Op       EventOp
+		Expected string
+	}{
+		{Op: 0, Expected: "NONE"},
+		{Op: EventWrite, Expected: "WRITE"},
+		{Op: EventCreate | EventChmod | EventModeMismatch, Expected: "CREATE|CHMOD|MODE_MISMATCH"},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := testStruct.Op.String(); got != testStruct.Expected {
+			t.Errorf("Expected %s, got %s on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+}
@@ -0,0 +1,432 @@
+package strings
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Answer is the meaning of a reply to a yes or no question.
+type Answer int
+
+const (
+	AnswerUnknown Answer = iota
+	AnswerYes
+	AnswerNo
+)
+
+// String returns "yes", "no" or "unknown".
+func (a Answer) String() string {
+	switch a {
+	case AnswerYes:
+		return "yes"
+	case AnswerNo:
+		return "no"
+	}
+
+	return "unknown"
+}
+
+// AnswerResult is what an AnswerParser made of a reply.
+type AnswerResult struct {
+	Answer Answer
+
+	// Confidence is from 0, for an unknown answer, to 1, for a reply that is
+	// exactly a word such as "yes" or "no". Hedged replies such as "i guess",
+	// replies that only start with a known phrase, and replies that are only
+	// an emoji have a lower confidence.
+	Confidence float64
+
+	// Phrase is the normalized phrase the answer was recognized from, or the
+	// emoji if there was none.
+	Phrase string
+}
+
+// confidences of the ways a reply can match
+const (
+	confidenceExact  = 1.0
+	confidenceHedged = 0.6
+	confidenceEmoji  = 0.9
+	confidencePrefix = 0.7 // multiplies the confidence of the phrase
+)
+
+// answerWords are the phrases of a language, as people type them. Unsure
+// phrases mean neither yes nor no, though they may start like one.
+type answerWords struct {
+	yes, no             []string
+	hedgedYes, hedgedNo []string
+	unsure              []string
+}
+
+// answerLanguages holds the built in phrases by ISO 639-1 language code
+var answerLanguages = map[string]answerWords{
+	"en": {
+		yes: []string{
+			"yes", "y", "yeah", "yea", "ya", "yep", "yup", "yes please", "yessir", "sure", "sure thing",
+			"ok", "okay", "k", "kk", "affirmative", "absolutely", "definitely", "certainly", "of course",
+			"correct", "true", "agreed", "aye", "indeed", "right", "right on", "go ahead", "do it",
+			"confirm", "confirmed", "please do", "by all means", "you bet", "positively", "exactly",
+			"precisely", "undoubtedly", "unquestionably", "indubitably", "gladly", "willingly",
+			"without fail", "beyond a doubt", "most assuredly", "i accept", "i concur", "granted",
+			"naturally", "just so", "righto", "amen",
+			// idioms that start like a no
+			"no problem", "no prob", "no probs", "no problemo", "no worries", "no doubt",
+			"no question", "no objection", "no objections", "not a problem", "why not",
+		},
+		no: []string{
+			"no", "n", "nope", "nah", "no way", "no thanks", "no thank you", "negative", "never",
+			"false", "cancel", "stop", "decline", "declined", "deny", "denied", "incorrect", "wrong",
+			"don't", "do not", "not at all", "absolutely not", "certainly not", "definitely not",
+			"of course not", "nay",
+		},
+		hedgedYes: []string{
+			"i guess", "i guess so", "i think so", "probably", "fine", "alright", "all right",
+			"whatever", "if you must", "very well", "good", "good enough", "even so",
+		},
+		hedgedNo: []string{
+			"i don't think so", "probably not", "not really", "rather not", "i'd rather not",
+		},
+		unsure: []string{
+			"don't know", "i don't know", "dunno", "idk", "no idea", "not sure", "i'm not sure",
+			"no clue",
+		},
+	},
+	"es": {
+		yes:       []string{"sí", "si", "s", "claro", "claro que sí", "por supuesto", "vale", "de acuerdo", "correcto", "afirmativo", "dale", "no hay problema"},
+		no:        []string{"no", "nunca", "para nada", "claro que no", "negativo", "de ninguna manera"},
+		hedgedYes: []string{"supongo", "supongo que sí", "creo que sí", "bueno"},
+		hedgedNo:  []string{"creo que no", "mejor no"},
+		unsure:    []string{"no sé", "no lo sé", "ni idea"},
+	},
+	"fr": {
+		yes:       []string{"oui", "o", "ouais", "bien sûr", "d'accord", "dac", "absolument", "exactement", "volontiers", "certainement"},
+		no:        []string{"non", "nan", "jamais", "pas du tout", "absolument pas", "non merci"},
+		hedgedYes: []string{"je suppose", "je pense", "je crois", "si tu veux", "si vous voulez"},
+		hedgedNo:  []string{"je ne pense pas", "pas vraiment", "plutôt pas"},
+		unsure:    []string{"je ne sais pas", "aucune idée"},
+	},
+	"de": {
+		yes:       []string{"ja", "j", "jawohl", "jep", "klar", "natürlich", "genau", "sicher", "einverstanden", "gerne", "richtig", "stimmt"},
+		no:        []string{"nein", "nee", "nö", "niemals", "auf keinen fall", "keineswegs", "nein danke", "falsch"},
+		hedgedYes: []string{"ich denke schon", "wahrscheinlich", "meinetwegen"},
+		hedgedNo:  []string{"eher nicht", "wohl nicht", "ich denke nicht"},
+		unsure:    []string{"weiß nicht", "ich weiß nicht", "keine ahnung"},
+	},
+	"it": {
+		yes:       []string{"sì", "si", "certo", "certamente", "va bene", "d'accordo", "esatto", "volentieri", "ovviamente"},
+		no:        []string{"no", "mai", "per niente", "assolutamente no", "no grazie"},
+		hedgedYes: []string{"credo di sì", "penso di sì", "suppongo"},
+		hedgedNo:  []string{"credo di no", "penso di no", "meglio di no"},
+		unsure:    []string{"non lo so", "boh"},
+	},
+	"pt": {
+		yes:       []string{"sim", "claro", "com certeza", "certo", "exato", "pode ser", "beleza", "tá bom", "ta bom"},
+		no:        []string{"não", "nao", "nunca", "de jeito nenhum", "não obrigado", "nao obrigado"},
+		hedgedYes: []string{"acho que sim", "talvez sim"},
+		hedgedNo:  []string{"acho que não", "acho que nao"},
+		unsure:    []string{"não sei", "nao sei"},
+	},
+	"nl": {
+		yes:       []string{"ja", "jazeker", "zeker", "natuurlijk", "prima", "akkoord", "klopt", "graag"},
+		no:        []string{"nee", "neen", "nooit", "zeker niet", "nee bedankt"},
+		hedgedYes: []string{"ik denk het", "waarschijnlijk"},
+		hedgedNo:  []string{"ik denk het niet", "liever niet"},
+	},
+	"sv": {
+		yes: []string{"ja", "japp", "absolut", "visst", "självklart", "okej"},
+		no:  []string{"nej", "nä", "aldrig", "absolut inte"},
+	},
+	"ru": {
+		yes:       []string{"да", "ага", "конечно", "хорошо", "ладно", "согласен", "согласна", "верно"},
+		no:        []string{"нет", "не", "никогда", "ни в коем случае", "нет спасибо"},
+		hedgedYes: []string{"наверное", "думаю да"},
+		hedgedNo:  []string{"думаю нет", "вряд ли"},
+		unsure:    []string{"не знаю"},
+	},
+	"ja": {
+		yes:       []string{"はい", "うん", "ええ", "そうです", "もちろん", "了解", "了解です", "大丈夫です", "オーケー"},
+		no:        []string{"いいえ", "いや", "ううん", "いいえ結構です", "だめ", "ダメ", "違います"},
+		hedgedYes: []string{"たぶん", "多分"},
+	},
+	"zh": {
+		yes:       []string{"是", "是的", "对", "对的", "好", "好的", "行", "可以", "当然", "没问题", "嗯"},
+		no:        []string{"不", "不是", "不要", "不行", "不对", "没有", "不用", "不可以"},
+		hedgedYes: []string{"应该是", "大概"},
+		hedgedNo:  []string{"应该不是", "不太"},
+	},
+}
+
+// answerEmoji are the emoji that mean yes or no on their own
+var answerEmoji = map[rune]Answer{
+	'👍': AnswerYes,
+	'👌': AnswerYes,
+	'✅': AnswerYes,
+	'✔': AnswerYes,
+	'☑': AnswerYes,
+	'🆗': AnswerYes,
+	'🙆': AnswerYes,
+	'👎': AnswerNo,
+	'❌': AnswerNo,
+	'❎': AnswerNo,
+	'✖': AnswerNo,
+	'🙅': AnswerNo,
+	'🚫': AnswerNo,
+	'⛔': AnswerNo,
+}
+
+type AnswerOption struct {
+	languages []string
+	extra     []answerPhrase
+}
+
+// WithAnswerLanguages limits the built in phrases to those of the given ISO
+// 639-1 language codes, such as "en" and "es". All of en, es, fr, de, it, pt,
+// nl, sv, ru, ja and zh are used by default.
+func WithAnswerLanguages(languages ...string) func(o *AnswerOption) {
+	return func(o *AnswerOption) {
+		o.languages = languages
+	}
+}
+
+// WithAnswerPhrases registers extra phrases, such as product specific
+// replies like "ship it", that mean the given answer with full confidence.
+// They take precedence over built in phrases. Phrases are normalized like
+// replies are, so case and punctuation do not matter.
+func WithAnswerPhrases(answer Answer, phrases ...string) func(o *AnswerOption) {
+	return func(o *AnswerOption) {
+		for _, p := range phrases {
+			o.extra = append(o.extra, answerPhrase{phrase: p, answer: answer, confidence: confidenceExact})
+		}
+	}
+}
+
+// answerPhrase is the answer a phrase means, and how confidently
+type answerPhrase struct {
+	phrase     string
+	answer     Answer
+	confidence float64
+}
+
+// AnswerParser recognizes yes and no in free text replies, such as to CLI
+// prompts or SMS messages. It is safe for concurrent use.
+type AnswerParser struct {
+	phrases map[string]answerPhrase
+}
+
+// NewAnswerParser returns an AnswerParser for the built in phrases and any
+// registered with WithAnswerPhrases:
+//
+//	p := strings.NewAnswerParser(
+//		strings.WithAnswerLanguages("en", "es"),
+//		strings.WithAnswerPhrases(strings.AnswerYes, "ship it"),
+//	)
+//
+//	if r := p.Parse(reply); r.Answer == strings.AnswerYes && r.Confidence >= 0.8 {
+//		deploy()
+//	}
+//
+// A phrase that means yes in one language and no in another is ignored.
+func NewAnswerParser(options ...func(*AnswerOption)) *AnswerParser {
+	var op AnswerOption
+
+	for _, o := range options {
+		o(&op)
+	}
+
+	languages := op.languages
+	if languages == nil {
+		for l := range answerLanguages {
+			languages = append(languages, l)
+		}
+
+		sort.Strings(languages)
+	}
+
+	p := &AnswerParser{phrases: make(map[string]answerPhrase)}
+	conflicts := make(map[string]bool)
+
+	add := func(phrases []string, answer Answer, confidence float64) {
+		for _, s := range phrases {
+			s = normalizeAnswer(s)
+
+			old, ok := p.phrases[s]
+			switch {
+			case conflicts[s]:
+			case !ok || (old.answer == answer && old.confidence < confidence):
+				p.phrases[s] = answerPhrase{phrase: s, answer: answer, confidence: confidence}
+			case old.answer != answer:
+				conflicts[s] = true
+				delete(p.phrases, s)
+			}
+		}
+	}
+
+	for _, l := range languages {
+		w := answerLanguages[l]
+		add(w.yes, AnswerYes, confidenceExact)
+		add(w.no, AnswerNo, confidenceExact)
+		add(w.hedgedYes, AnswerYes, confidenceHedged)
+		add(w.hedgedNo, AnswerNo, confidenceHedged)
+		add(w.unsure, AnswerUnknown, confidenceExact)
+	}
+
+	for _, e := range op.extra {
+		s := normalizeAnswer(e.phrase)
+		if s != "" {
+			p.phrases[s] = answerPhrase{phrase: s, answer: e.answer, confidence: e.confidence}
+		}
+	}
+
+	return p
+}
+
+// defaultAnswerParser is the parser of ParseAnswer
+var defaultAnswerParser = sync.OnceValue(func() *AnswerParser {
+	return NewAnswerParser()
+})
+
+// ParseAnswer recognizes yes and no in a reply using all built in phrases.
+// See AnswerParser.Parse.
+func ParseAnswer(s string) AnswerResult {
+	return defaultAnswerParser().Parse(s)
+}
+
+// Parse recognizes yes and no in a reply. The reply is trimmed, lower cased
+// and stripped of punctuation, so " Yes!! " and "yes" are the same.
+//
+// A reply that is exactly a known phrase has the confidence of the phrase. A
+// reply that starts with one, such as "yes, go ahead and book it", has a
+// lower confidence, and is unknown if the rest of it has a phrase with
+// another meaning, such as "yes no". Replies such as "no problem" and "don't
+// know" are idioms rather than a no. A reply of only emoji such as 👍 or 👎
+// is recognized too; emoji that contradict the words, or each other, make
+// the answer unknown.
+func (p *AnswerParser) Parse(s string) AnswerResult {
+	emoji, emojiPhrase := emojiAnswer(s)
+	if emoji == answerConflict {
+		return AnswerResult{}
+	}
+
+	text := normalizeAnswer(s)
+	if text == "" {
+		if emoji == AnswerUnknown {
+			return AnswerResult{}
+		}
+
+		return AnswerResult{Answer: emoji, Confidence: confidenceEmoji, Phrase: emojiPhrase}
+	}
+
+	ph, ok := p.phrases[text]
+	if !ok {
+		ph, ok = p.prefix(text)
+	}
+
+	switch {
+	case ok && ph.answer == AnswerUnknown:
+		return AnswerResult{}
+	case !ok && emoji == AnswerUnknown:
+		return AnswerResult{}
+	case !ok:
+		// emoji in a reply whose words mean nothing known
+		return AnswerResult{Answer: emoji, Confidence: confidenceEmoji * confidencePrefix, Phrase: emojiPhrase}
+	case emoji != AnswerUnknown && emoji != ph.answer:
+		return AnswerResult{}
+	}
+
+	return AnswerResult{Answer: ph.answer, Confidence: ph.confidence, Phrase: ph.phrase}
+}
+
+// prefix returns the longest known phrase text starts with, followed by a
+// space, with its confidence lowered. If the rest of text has a phrase with
+// another answer, the phrase found is unknown.
+func (p *AnswerParser) prefix(text string) (answerPhrase, bool) {
+	for i := strings.LastIndexByte(text, ' '); i > 0; i = strings.LastIndexByte(text[:i], ' ') {
+		if ph, ok := p.phrases[text[:i]]; ok {
+			if p.contradicts(ph.answer, strings.Fields(text[i+1:])) {
+				return answerPhrase{phrase: ph.phrase}, true
+			}
+
+			ph.confidence *= confidencePrefix
+			return ph, true
+		}
+	}
+
+	return answerPhrase{}, false
+}
+
+// contradicts reports whether words have a known phrase with an answer other
+// than answer. Phrases of a single letter, such as "n", only count as a whole
+// reply.
+func (p *AnswerParser) contradicts(answer Answer, words []string) bool {
+	for i := 0; i < len(words); i++ {
+		// the longest phrase starting at word i
+		for j := len(words); j > i; j-- {
+			s := strings.Join(words[i:j], " ")
+
+			ph, ok := p.phrases[s]
+			if !ok || utf8.RuneCountInString(s) == 1 {
+				continue
+			}
+
+			if ph.answer != answer {
+				return true
+			}
+
+			i = j - 1
+
+			break
+		}
+	}
+
+	return false
+}
+
+// answerConflict is returned by emojiAnswer for emoji that mean both yes and no
+const answerConflict Answer = -1
+
+// emojiAnswer returns the answer the emoji in s mean, and the first of them
+func emojiAnswer(s string) (Answer, string) {
+	answer := AnswerUnknown
+	first := ""
+
+	for _, r := range s {
+		a, ok := answerEmoji[r]
+		if !ok {
+			continue
+		}
+
+		if answer != AnswerUnknown && answer != a {
+			return answerConflict, ""
+		}
+
+		if first == "" {
+			first = string(r)
+		}
+
+		answer = a
+	}
+
+	return answer, first
+}
+
+// normalizeAnswer lower cases s, removes apostrophes, replaces punctuation,
+// symbols and emoji with spaces and collapses white space
+func normalizeAnswer(s string) string {
+	s = strings.ToLower(s)
+
+	var b strings.Builder
+
+	for _, r := range s {
+		switch {
+		case r == '\'' || r == '’':
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			b.WriteRune(r)
+		default:
+			b.WriteByte(' ')
+		}
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,91 @@
+package strings
+
+import "testing"
+
+func TestParseAnswer(t *testing.T) {
+	testStructs := []struct {
+		Input      string
+		Expected   Answer
+		Confidence float64
+	}{
+		{Input: "yes", Expected: AnswerYes, Confidence: 1},
+		{Input: "  YES!! ", Expected: AnswerYes, Confidence: 1},
+		{Input: "Yep.", Expected: AnswerYes, Confidence: 1},
+		{Input: "Of course!", Expected: AnswerYes, Confidence: 1},
+		{Input: "no", Expected: AnswerNo, Confidence: 1},
+		{Input: "Nope", Expected: AnswerNo, Confidence: 1},
+		{Input: "Don't!", Expected: AnswerNo, Confidence: 1},
+		{Input: "don’t", Expected: AnswerNo, Confidence: 1},
+		{Input: "STOP", Expected: AnswerNo, Confidence: 1},
+		{Input: "i guess", Expected: AnswerYes, Confidence: 0.6},
+		{Input: "Not really...", Expected: AnswerNo, Confidence: 0.6},
+		{Input: "yes, book it for tuesday", Expected: AnswerYes, Confidence: 0.7},
+		{Input: "no thanks, maybe later", Expected: AnswerNo, Confidence: 0.7},
+		{Input: "No problem!", Expected: AnswerYes, Confidence: 1},
+		{Input: "no worries", Expected: AnswerYes, Confidence: 1},
+		{Input: "no problem at all", Expected: AnswerYes, Confidence: 0.7},
+		{Input: "no, it's a problem", Expected: AnswerNo, Confidence: 0.7},
+		{Input: "don't know", Expected: AnswerUnknown},
+		{Input: "I don't know, ask Sam", Expected: AnswerUnknown},
+		{Input: "no idea", Expected: AnswerUnknown},
+		{Input: "yes no", Expected: AnswerUnknown},
+		{Input: "no, yes", Expected: AnswerUnknown},
+		{Input: "yes, I don't know", Expected: AnswerUnknown},
+		{Input: "yes, plan n", Expected: AnswerYes, Confidence: 0.7},
+		{Input: "👍", Expected: AnswerYes, Confidence: 0.9},
+		{Input: "👍🏽", Expected: AnswerYes, Confidence: 0.9},
+		{Input: "👎", Expected: AnswerNo, Confidence: 0.9},
+		{Input: "yes 👍", Expected: AnswerYes, Confidence: 1},
+		{Input: "yes 👎", Expected: AnswerUnknown},
+		{Input: "👍👎", Expected: AnswerUnknown},
+		{Input: "Sí", Expected: AnswerYes, Confidence: 1},
+		{Input: "oui", Expected: AnswerYes, Confidence: 1},
+		{Input: "Nein", Expected: AnswerNo, Confidence: 1},
+		{Input: "não", Expected: AnswerNo, Confidence: 1},
+		{Input: "Да", Expected: AnswerYes, Confidence: 1},
+		{Input: "はい", Expected: AnswerYes, Confidence: 1},
+		{Input: "不是", Expected: AnswerNo, Confidence: 1},
+		{Input: "maybe", Expected: AnswerUnknown},
+		{Input: "what?", Expected: AnswerUnknown},
+		{Input: "yesterday", Expected: AnswerUnknown},
+		{Input: "", Expected: AnswerUnknown},
+		{Input: "?!", Expected: AnswerUnknown},
+	}
+
+	for i, testStruct := range testStructs {
+		got := ParseAnswer(testStruct.Input)
+
+		if got.Answer != testStruct.Expected || got.Confidence != testStruct.Confidence {
+			t.Errorf("Expected %s (%v), got %s (%v) on iteration %d", testStruct.Expected, testStruct.Confidence, got.Answer, got.Confidence, i)
+		}
+	}
+}
+
+func TestAnswerParser(t *testing.T) {
+	p := NewAnswerParser(
+		WithAnswerLanguages("en"),
+		WithAnswerPhrases(AnswerYes, "Ship it!", "fine"),
+		WithAnswerPhrases(AnswerNo, "hold off"),
+	)
+
+	testStructs := []struct {
+		Input    string
+		Expected AnswerResult
+	}{
+		{Input: "ship it", Expected: AnswerResult{Answer: AnswerYes, Confidence: 1, Phrase: "ship it"}},
+		{Input: "Hold off.", Expected: AnswerResult{Answer: AnswerNo, Confidence: 1, Phrase: "hold off"}},
+		{Input: "fine", Expected: AnswerResult{Answer: AnswerYes, Confidence: 1, Phrase: "fine"}},
+		{Input: "oui", Expected: AnswerResult{}},
+		{Input: "👎", Expected: AnswerResult{Answer: AnswerNo, Confidence: 0.9, Phrase: "👎"}},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := p.Parse(testStruct.Input); got != testStruct.Expected {
+			t.Errorf("Expected %+v, got %+v on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+
+	if got := AnswerNo.String(); got != "no" {
+		t.Errorf("Expected %s, got %s", "no", got)
+	}
+}
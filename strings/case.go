@@ -0,0 +1,396 @@
+package strings
+
+import (
+	"strings"
+	"unicode"
+)
+
+// defaultInitialisms are the initialisms golint keeps in upper case
+var defaultInitialisms = []string{
+	"ACL", "API", "ASCII", "CPU", "CSS", "DNS", "EOF", "GUID", "HTML", "HTTP",
+	"HTTPS", "ID", "IP", "JSON", "LHS", "QPS", "RAM", "RHS", "RPC", "SLA",
+	"SMTP", "SQL", "SSH", "TCP", "TLS", "TTL", "UDP", "UI", "UID", "UUID",
+	"URI", "URL", "UTF8", "VM", "XML", "XMPP", "XSRF", "XSS",
+}
+
+// defaultCaseOption is the CaseOption without options, built once
+var defaultCaseOption = newCaseOption(nil)
+
+type CaseOption struct {
+	noDefaults bool
+	extra      []string
+
+	// initialisms maps the upper case form of each initialism to how it is
+	// written; mixed are those with lower case letters, which split words
+	initialisms map[string]string
+	mixed       []string
+}
+
+// WithCaseInitialisms adds initialisms to the default list, which is the one
+// golint uses: ACL, API, HTTP, ID, JSON, URL and so on. Initialisms are
+// written as given by ToCamel, ToPascal and ToTitle, so "user_id" becomes
+// "UserID" rather than "UserId". Initialisms with lower case letters, such as
+// "IPv4" or "OAuth", are also kept together as one word when splitting, so
+// "OAuthToken" becomes "oauth_token" rather than "o_auth_token".
+func WithCaseInitialisms(initialisms ...string) func(o *CaseOption) {
+	return func(o *CaseOption) {
+		o.extra = append(o.extra, initialisms...)
+	}
+}
+
+// WithCaseNoDefaultInitialisms leaves out the default initialisms, so
+// only those given with WithCaseInitialisms are used.
+func WithCaseNoDefaultInitialisms() func(o *CaseOption) {
+	return func(o *CaseOption) {
+		o.noDefaults = true
+	}
+}
+
+// newCaseOption applies options and indexes the initialisms
+func newCaseOption(options []func(*CaseOption)) *CaseOption {
+	op := &CaseOption{}
+
+	for _, o := range options {
+		o(op)
+	}
+
+	var initialisms []string
+	if !op.noDefaults {
+		initialisms = defaultInitialisms
+	}
+
+	op.initialisms = make(map[string]string)
+
+	for _, s := range append(initialisms[:len(initialisms):len(initialisms)], op.extra...) {
+		if s == "" {
+			continue
+		}
+
+		op.initialisms[strings.ToUpper(s)] = s
+
+		if strings.ToUpper(s) != s {
+			op.mixed = append(op.mixed, s)
+		}
+	}
+
+	return op
+}
+
+// caseOption returns the CaseOption for options
+func caseOption(options []func(*CaseOption)) *CaseOption {
+	if len(options) == 0 {
+		return defaultCaseOption
+	}
+
+	return newCaseOption(options)
+}
+
+// ToSnake converts s to snake case, such as "http_server_v2" from
+// "HTTPServerV2", for database columns and JSON keys.
+//
+// Words are separated by anything that is not a letter or digit, by a lower
+// case letter or digit followed by an upper case letter ("userName"), and
+// before the last of a run of upper case letters followed by a lower case
+// one ("HTTPServer"). Digits stay with the word before them ("utf8", "v2").
+//
+// A run of upper case letters made of initialisms is split into them, as
+// golint does ("JSONAPI", "HTTPSURL"), keeping digits after the last one
+// ("HTML5Parser", "UUIDV4") and a plural "s" that ends the word ("userIDs").
+func ToSnake(s string, options ...func(*CaseOption)) string {
+	return joinWords(splitWords(s, caseOption(options)), "_", strings.ToLower)
+}
+
+// ToScreamingSnake converts s to upper case snake case, such as
+// "HTTP_SERVER_V2", for environment variables and constants. Words are split
+// as by ToSnake.
+func ToScreamingSnake(s string, options ...func(*CaseOption)) string {
+	return joinWords(splitWords(s, caseOption(options)), "_", strings.ToUpper)
+}
+
+// ToKebab converts s to kebab case, such as "http-server-v2", for URLs and
+// command line flags. Words are split as by ToSnake.
+func ToKebab(s string, options ...func(*CaseOption)) string {
+	return joinWords(splitWords(s, caseOption(options)), "-", strings.ToLower)
+}
+
+// ToCamel converts s to camel case, such as "httpServerV2" or "userID", for
+// unexported Go identifiers and JavaScript. The first word is lower case and
+// the others are capitalized, except initialisms, which are written as in
+// the initialism list. Words are split as by ToSnake.
+func ToCamel(s string, options ...func(*CaseOption)) string {
+	op := caseOption(options)
+	words := splitWords(s, op)
+
+	for i, w := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(w)
+		} else {
+			words[i] = op.capitalize(w)
+		}
+	}
+
+	return strings.Join(words, "")
+}
+
+// ToPascal converts s to Pascal case, such as "HTTPServerV2" or "UserID", for
+// exported Go identifiers and type names. Each word is capitalized, except
+// initialisms, which are written as in the initialism list. Words are split
+// as by ToSnake.
+func ToPascal(s string, options ...func(*CaseOption)) string {
+	op := caseOption(options)
+
+	return joinWords(splitWords(s, op), "", op.capitalize)
+}
+
+// ToTitle converts s to words separated by spaces and capitalized like
+// ToPascal, such as "HTTP Server V2" or "User ID", for labels and headings.
+// Unlike the standard library's strings.ToTitle, it does not upper case
+// every letter.
+func ToTitle(s string, options ...func(*CaseOption)) string {
+	op := caseOption(options)
+
+	return joinWords(splitWords(s, op), " ", op.capitalize)
+}
+
+// joinWords joins words converted by f with sep
+func joinWords(words []string, sep string, f func(string) string) string {
+	for i, w := range words {
+		words[i] = f(w)
+	}
+
+	return strings.Join(words, sep)
+}
+
+// capitalize writes w as its initialism, or the plural of one, or upper
+// cases its first letter and lower cases the rest
+func (o *CaseOption) capitalize(w string) string {
+	u := strings.ToUpper(w)
+
+	if s, ok := o.initialisms[u]; ok {
+		return s
+	}
+
+	if p, ok := strings.CutSuffix(u, "S"); ok {
+		if s, ok := o.initialisms[p]; ok {
+			return s + "s"
+		}
+	}
+
+	// HTML5
+	if p := strings.TrimRightFunc(u, unicode.IsDigit); p != u {
+		if s, ok := o.initialisms[p]; ok {
+			return s + u[len(p):]
+		}
+	}
+
+	for i, r := range w {
+		return string(unicode.ToTitle(r)) + strings.ToLower(w[i+len(string(r)):])
+	}
+
+	return w
+}
+
+// runeCase is how splitWords treats a rune
+type runeCase int
+
+const (
+	caseSeparator runeCase = iota
+	caseUpper
+	caseLower // including letters without case
+	caseDigit
+	caseMark
+)
+
+// classifyRune returns the runeCase of r
+func classifyRune(r rune) runeCase {
+	switch {
+	case unicode.IsUpper(r) || unicode.IsTitle(r):
+		return caseUpper
+	case unicode.IsLetter(r):
+		return caseLower
+	case unicode.IsDigit(r) || unicode.IsNumber(r):
+		return caseDigit
+	case unicode.In(r, unicode.Mn, unicode.Mc):
+		return caseMark
+	}
+
+	return caseSeparator
+}
+
+// splitWords splits s into words, as ToSnake describes
+func splitWords(s string, op *CaseOption) []string {
+	rs := []rune(s)
+	cs := make([]runeCase, len(rs))
+
+	for i, r := range rs {
+		cs[i] = classifyRune(r)
+	}
+
+	var words []string
+
+	for i := 0; i < len(rs); {
+		// marks without a letter to combine with are dropped
+		if cs[i] == caseSeparator || cs[i] == caseMark {
+			i++
+			continue
+		}
+
+		if n := op.mixedInitialism(rs, cs, i); n > 0 {
+			words = append(words, string(rs[i:i+n]))
+			i += n
+
+			continue
+		}
+
+		if ns := op.initialismRun(rs, cs, i); ns != nil {
+			for _, n := range ns {
+				words = append(words, string(rs[i:i+n]))
+				i += n
+			}
+
+			continue
+		}
+
+		j := i + 1
+		for j < len(rs) && !wordBoundary(cs, j) {
+			j++
+		}
+
+		words = append(words, string(rs[i:j]))
+		i = j
+	}
+
+	return words
+}
+
+// wordBoundary reports whether a word starts at rune i, after rune i-1
+func wordBoundary(cs []runeCase, i int) bool {
+	// marks combine with the letter before them
+	p := i - 1
+	for p > 0 && cs[p] == caseMark {
+		p--
+	}
+
+	prev, cur := cs[p], cs[i]
+
+	switch {
+	case cur == caseSeparator:
+		return true
+	case cur != caseUpper:
+		return false
+	case prev == caseLower || prev == caseDigit:
+		// userName, utf8String
+		return true
+	case prev == caseUpper:
+		// HTTPServer
+		return i+1 < len(cs) && cs[i+1] == caseLower
+	}
+
+	return false
+}
+
+// initialismRun returns the lengths of the words the run of upper case
+// letters at rune i splits into, if the run is made of initialisms, or nil.
+// Digits after the run belong to its last word, which may then also be a
+// single letter ("V4"), and so does a plural "s" ending the word. If the run
+// is followed by another lower case letter, its last letter starts the next
+// word instead.
+func (o *CaseOption) initialismRun(rs []rune, cs []runeCase, i int) []int {
+	upper := i
+	for upper < len(rs) && cs[upper] == caseUpper {
+		upper++
+	}
+
+	if upper-i < 2 {
+		return nil
+	}
+
+	end := upper
+	for end < len(rs) && cs[end] == caseDigit {
+		end++
+	}
+
+	plural := false
+
+	if end < len(rs) && cs[end] == caseLower {
+		switch {
+		case end > upper:
+			// digits followed by a lower case letter
+			return nil
+		case rs[end] == 's' && (end+1 == len(rs) || cs[end+1] != caseLower):
+			plural = true
+		default:
+			upper--
+			end--
+		}
+	}
+
+	ns := o.tileInitialisms(rs, i, upper, end)
+	if ns != nil && plural {
+		ns[len(ns)-1]++
+	}
+
+	return ns
+}
+
+// tileInitialisms splits rs[i:end], upper case letters up to upper and
+// digits after, into known initialisms, preferring the longest first, and
+// returns their lengths, or nil if it cannot be split
+func (o *CaseOption) tileInitialisms(rs []rune, i, upper, end int) []int {
+	if i == end {
+		return []int{}
+	}
+
+	for n := end - i; n > 0; n-- {
+		j := i + n
+
+		// digits are not split
+		if j > upper && j < end {
+			continue
+		}
+
+		_, ok := o.initialisms[string(rs[i:j])]
+
+		if !ok && j == end && end > upper {
+			// HTML5, V4
+			_, ok = o.initialisms[string(rs[i:upper])]
+			ok = ok || upper-i == 1
+		}
+
+		if !ok {
+			continue
+		}
+
+		if rest := o.tileInitialisms(rs, j, upper, end); rest != nil {
+			return append([]int{n}, rest...)
+		}
+	}
+
+	return nil
+}
+
+// mixedInitialism returns the length of the longest initialism with lower
+// case letters at rune i, if it is not followed by a lower case letter
+func (o *CaseOption) mixedInitialism(rs []rune, cs []runeCase, i int) int {
+	longest := 0
+
+	for _, s := range o.mixed {
+		n := 0
+		ok := true
+
+		for _, r := range s {
+			if i+n >= len(rs) || rs[i+n] != r {
+				ok = false
+				break
+			}
+
+			n++
+		}
+
+		if ok && n > longest && (i+n == len(rs) || cs[i+n] != caseLower) {
+			longest = n
+		}
+	}
+
+	return longest
+}
@@ -0,0 +1,115 @@
+package strings
+
+import "testing"
+
+func TestCaseConversion(t *testing.T) {
+	testStructs := []struct {
+		Input          string
+		Snake          string
+		ScreamingSnake string
+		Kebab          string
+		Camel          string
+		Pascal         string
+		Title          string
+	}{
+		{Input: "", Snake: "", ScreamingSnake: "", Kebab: "", Camel: "", Pascal: "", Title: ""},
+		{Input: "HTTPServer", Snake: "http_server", ScreamingSnake: "HTTP_SERVER", Kebab: "http-server", Camel: "httpServer", Pascal: "HTTPServer", Title: "HTTP Server"},
+		{Input: "userID", Snake: "user_id", ScreamingSnake: "USER_ID", Kebab: "user-id", Camel: "userID", Pascal: "UserID", Title: "User ID"},
+		{Input: "user_id", Snake: "user_id", ScreamingSnake: "USER_ID", Kebab: "user-id", Camel: "userID", Pascal: "UserID", Title: "User ID"},
+		{Input: "USER_ID", Snake: "user_id", ScreamingSnake: "USER_ID", Kebab: "user-id", Camel: "userID", Pascal: "UserID", Title: "User ID"},
+		{Input: "  first-name  ", Snake: "first_name", ScreamingSnake: "FIRST_NAME", Kebab: "first-name", Camel: "firstName", Pascal: "FirstName", Title: "First Name"},
+		{Input: "HTTPServerV2", Snake: "http_server_v2", ScreamingSnake: "HTTP_SERVER_V2", Kebab: "http-server-v2", Camel: "httpServerV2", Pascal: "HTTPServerV2", Title: "HTTP Server V2"},
+		{Input: "Base64Encode", Snake: "base64_encode", ScreamingSnake: "BASE64_ENCODE", Kebab: "base64-encode", Camel: "base64Encode", Pascal: "Base64Encode", Title: "Base64 Encode"},
+		{Input: "utf8String", Snake: "utf8_string", ScreamingSnake: "UTF8_STRING", Kebab: "utf8-string", Camel: "utf8String", Pascal: "UTF8String", Title: "UTF8 String"},
+		{Input: "parse JSON api response", Snake: "parse_json_api_response", ScreamingSnake: "PARSE_JSON_API_RESPONSE", Kebab: "parse-json-api-response", Camel: "parseJSONAPIResponse", Pascal: "ParseJSONAPIResponse", Title: "Parse JSON API Response"},
+		{Input: "userIDs", Snake: "user_ids", ScreamingSnake: "USER_IDS", Kebab: "user-ids", Camel: "userIDs", Pascal: "UserIDs", Title: "User IDs"},
+		{Input: "ServeURLs", Snake: "serve_urls", ScreamingSnake: "SERVE_URLS", Kebab: "serve-urls", Camel: "serveURLs", Pascal: "ServeURLs", Title: "Serve URLs"},
+		{Input: "URLsToFetch", Snake: "urls_to_fetch", ScreamingSnake: "URLS_TO_FETCH", Kebab: "urls-to-fetch", Camel: "urlsToFetch", Pascal: "URLsToFetch", Title: "URLs To Fetch"},
+		{Input: "user_ids", Snake: "user_ids", ScreamingSnake: "USER_IDS", Kebab: "user-ids", Camel: "userIDs", Pascal: "UserIDs", Title: "User IDs"},
+		{Input: "UIState", Snake: "ui_state", ScreamingSnake: "UI_STATE", Kebab: "ui-state", Camel: "uiState", Pascal: "UIState", Title: "UI State"},
+		{Input: "ServeHTTPAPI", Snake: "serve_http_api", ScreamingSnake: "SERVE_HTTP_API", Kebab: "serve-http-api", Camel: "serveHTTPAPI", Pascal: "ServeHTTPAPI", Title: "Serve HTTP API"},
+		{Input: "userIDURL", Snake: "user_id_url", ScreamingSnake: "USER_ID_URL", Kebab: "user-id-url", Camel: "userIDURL", Pascal: "UserIDURL", Title: "User ID URL"},
+		{Input: "HTTPSURL", Snake: "https_url", ScreamingSnake: "HTTPS_URL", Kebab: "https-url", Camel: "httpsURL", Pascal: "HTTPSURL", Title: "HTTPS URL"},
+		{Input: "newUUIDV4", Snake: "new_uuid_v4", ScreamingSnake: "NEW_UUID_V4", Kebab: "new-uuid-v4", Camel: "newUUIDV4", Pascal: "NewUUIDV4", Title: "New UUID V4"},
+		{Input: "HTML5Parser", Snake: "html5_parser", ScreamingSnake: "HTML5_PARSER", Kebab: "html5-parser", Camel: "html5Parser", Pascal: "HTML5Parser", Title: "HTML5 Parser"},
+		{Input: "IDEA_BOARD", Snake: "idea_board", ScreamingSnake: "IDEA_BOARD", Kebab: "idea-board", Camel: "ideaBoard", Pascal: "IdeaBoard", Title: "Idea Board"},
+		{Input: "straßeÜbersicht", Snake: "straße_übersicht", ScreamingSnake: "STRAßE_ÜBERSICHT", Kebab: "straße-übersicht", Camel: "straßeÜbersicht", Pascal: "StraßeÜbersicht", Title: "Straße Übersicht"},
+		{Input: "étatCivil", Snake: "état_civil", ScreamingSnake: "ÉTAT_CIVIL", Kebab: "état-civil", Camel: "étatCivil", Pascal: "ÉtatCivil", Title: "État Civil"},
+		{Input: "имяПользователя", Snake: "имя_пользователя", ScreamingSnake: "ИМЯ_ПОЛЬЗОВАТЕЛЯ", Kebab: "имя-пользователя", Camel: "имяПользователя", Pascal: "ИмяПользователя", Title: "Имя Пользователя"},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := ToSnake(testStruct.Input); got != testStruct.Snake {
+			t.Errorf("Expected %s, got %s on iteration %d", testStruct.Snake, got, i)
+		}
+
+		if got := ToScreamingSnake(testStruct.Input); got != testStruct.ScreamingSnake {
+			t.Errorf("Expected %s, got %s on iteration %d", testStruct.ScreamingSnake, got, i)
+		}
+
+		if got := ToKebab(testStruct.Input); got != testStruct.Kebab {
+			t.Errorf("Expected %s, got %s on iteration %d", testStruct.Kebab, got, i)
+		}
+
+		if got := ToCamel(testStruct.Input); got != testStruct.Camel {
+			t.Errorf("Expected %s, got %s on iteration %d", testStruct.Camel, got, i)
+		}
+
+		if got := ToPascal(testStruct.Input); got != testStruct.Pascal {
+			t.Errorf("Expected %s, got %s on iteration %d", testStruct.Pascal, got, i)
+		}
+
+		if got := ToTitle(testStruct.Input); got != testStruct.Title {
+			t.Errorf("Expected %s, got %s on iteration %d", testStruct.Title, got, i)
+		}
+	}
+}
+
+func TestCaseRoundTrip(t *testing.T) {
+	inputs := []string{
+		"parse JSON api response", "serve_http_api", "user_id_url", "https_url", "new_uuid_v4",
+		"html5_parser", "user_ids", "serve_urls", "http_server_v2", "utf8_string", "base64_encode",
+		"xml_http_request", "cpu_id", "api_v2_url",
+	}
+
+	for i, input := range inputs {
+		snake := ToSnake(input)
+
+		if got := ToSnake(ToCamel(input)); got != snake {
+			t.Errorf("Expected %s, got %s from %s on iteration %d", snake, got, ToCamel(input), i)
+		}
+
+		if got := ToSnake(ToPascal(input)); got != snake {
+			t.Errorf("Expected %s, got %s from %s on iteration %d", snake, got, ToPascal(input), i)
+		}
+
+		if got := ToSnake(ToScreamingSnake(input)); got != snake {
+			t.Errorf("Expected %s, got %s from %s on iteration %d", snake, got, ToScreamingSnake(input), i)
+		}
+	}
+}
+
+func TestCaseInitialisms(t *testing.T) {
+	testStructs := []struct {
+		Function func(string, ...func(*CaseOption)) string
+		Options  []func(*CaseOption)
+		Input    string
+		Expected string
+	}{
+		{Function: ToSnake, Input: "OAuthToken", Expected: "o_auth_token"},
+		{Function: ToSnake, Options: []func(*CaseOption){WithCaseInitialisms("OAuth")}, Input: "OAuthToken", Expected: "oauth_token"},
+		{Function: ToPascal, Options: []func(*CaseOption){WithCaseInitialisms("OAuth")}, Input: "oauth_token", Expected: "OAuthToken"},
+		{Function: ToSnake, Options: []func(*CaseOption){WithCaseInitialisms("IPv4")}, Input: "parseIPv4Address", Expected: "parse_ipv4_address"},
+		{Function: ToCamel, Options: []func(*CaseOption){WithCaseInitialisms("IPv4")}, Input: "ipv4_address", Expected: "ipv4Address"},
+		{Function: ToSnake, Options: []func(*CaseOption){WithCaseInitialisms("OAuth")}, Input: "OAuthenticate", Expected: "o_authenticate"},
+		{Function: ToPascal, Options: []func(*CaseOption){WithCaseInitialisms("SKU")}, Input: "product_sku", Expected: "ProductSKU"},
+		{Function: ToPascal, Options: []func(*CaseOption){WithCaseNoDefaultInitialisms()}, Input: "user_id", Expected: "UserId"},
+		{Function: ToPascal, Options: []func(*CaseOption){WithCaseNoDefaultInitialisms(), WithCaseInitialisms("SKU")}, Input: "sku_http_id", Expected: "SKUHttpId"},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := testStruct.Function(testStruct.Input, testStruct.Options...); got != testStruct.Expected {
+			t.Errorf("Expected %s, got %s on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+}
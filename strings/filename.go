@@ -0,0 +1,111 @@
+package strings
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxFilenameBytes is the longest file name most file systems accept
+const maxFilenameBytes = 255
+
+// SanitizeFilename returns a version of name, such as one supplied with an
+// upload, that is safe to use as a single file name on unix and Windows.
+//
+// Any directory part is dropped, so "../../etc/passwd" and
+// "C:\Users\bob\report.pdf" become "passwd" and "report.pdf". Invalid UTF-8,
+// control characters and bidirectional formatting characters, which can
+// disguise an extension, are removed. Characters Windows reserves
+// (< > : " | ? *) are replaced with an underscore, leading and trailing spaces
+// and dots are trimmed, and names reserved by Windows such as CON or LPT1 are
+// prefixed with an underscore. The result is at most 255 bytes long, keeping
+// the extension when shortening.
+//
+// An empty string is returned when nothing usable is left, so callers should
+// substitute a name of their own.
+//
+// This function operates on runes to remain Unicode safe.
+func SanitizeFilename(name string) string {
+	name = strings.ToValidUTF8(name, "")
+
+	// keep only the last path element, for either separator
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsControl(r) || isBidiControl(r):
+			return -1
+		case strings.ContainsRune(`<>:"|?*`, r):
+			return '_'
+		}
+
+		return r
+	}, name)
+
+	// Windows ignores trailing spaces and dots, and leading dots hide files
+	// on unix and make "." and ".." special
+	name = strings.TrimFunc(name, func(r rune) bool {
+		return r == '.' || unicode.IsSpace(r)
+	})
+
+	if name == "" {
+		return ""
+	}
+
+	if isReservedFilename(name) {
+		name = "_" + name
+	}
+
+	return truncateFilename(name, maxFilenameBytes)
+}
+
+// isBidiControl reports whether r is a bidirectional formatting character,
+// which can make "exe.pdf" display as "fdp.exe"
+func isBidiControl(r rune) bool {
+	return r == '\u061c' || r == '\u200e' || r == '\u200f' ||
+		(r >= '\u202a' && r <= '\u202e') || (r >= '\u2066' && r <= '\u2069')
+}
+
+// isReservedFilename reports whether name is a device name reserved by
+// Windows, with or without an extension
+func isReservedFilename(name string) bool {
+	base, _, _ := strings.Cut(name, ".")
+	base = strings.ToUpper(strings.TrimRight(base, " "))
+
+	switch base {
+	case "CON", "PRN", "AUX", "NUL", "CONIN$", "CONOUT$":
+		return true
+	}
+
+	if len(base) >= 4 && (strings.HasPrefix(base, "COM") || strings.HasPrefix(base, "LPT")) {
+		switch base[3:] {
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9", "¹", "²", "³":
+			return true
+		}
+	}
+
+	return false
+}
+
+// truncateFilename shortens name to at most limit bytes without splitting a
+// rune, keeping a short extension
+func truncateFilename(name string, limit int) string {
+	if len(name) <= limit {
+		return name
+	}
+
+	ext := ""
+	if i := strings.LastIndexByte(name, '.'); i > 0 && len(name)-i <= 16 {
+		ext = name[i:]
+		name = name[:i]
+	}
+
+	n := limit - len(ext)
+	for n > 0 && !utf8.RuneStart(name[n]) {
+		n--
+	}
+
+	return strings.TrimRightFunc(name[:n], unicode.IsSpace) + ext
+}
@@ -0,0 +1,59 @@
+package strings
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	testStructs := []struct {
+		Input    string
+		Expected string
+	}{
+		{Input: "report.pdf", Expected: "report.pdf"},
+		{Input: "../../etc/passwd", Expected: "passwd"},
+		{Input: `C:\Users\bob\report.pdf`, Expected: "report.pdf"},
+		{Input: "..", Expected: ""},
+		{Input: "/", Expected: ""},
+		{Input: "", Expected: ""},
+		{Input: ".htaccess", Expected: "htaccess"},
+		{Input: " notes.txt. ", Expected: "notes.txt"},
+		{Input: "a\x00b\nc\x7f.txt", Expected: "abc.txt"},
+		{Input: "invoice\u202efdp.exe", Expected: "invoicefdp.exe"},
+		{Input: `what?<is>this:"|*.txt`, Expected: "what__is_this____.txt"},
+		{Input: "CON", Expected: "_CON"},
+		{Input: "con.txt", Expected: "_con.txt"},
+		{Input: "LPT1.log", Expected: "_LPT1.log"},
+		{Input: "COM10.log", Expected: "COM10.log"},
+		{Input: "console.log", Expected: "console.log"},
+		{Input: "résumé 履歴書.docx", Expected: "résumé 履歴書.docx"},
+		{Input: "photo 📷.jpg", Expected: "photo 📷.jpg"},
+		{Input: "bad\xff\xfename.txt", Expected: "badname.txt"},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := SanitizeFilename(testStruct.Input); got != testStruct.Expected {
+			t.Errorf("Expected %q, got %q for %q on iteration %d", testStruct.Expected, got, testStruct.Input, i)
+		}
+	}
+}
+
+func TestSanitizeFilenameLength(t *testing.T) {
+	// 3 byte runes do not divide the limit evenly
+	long := strings.Repeat("字", 100) + ".pdf"
+
+	got := SanitizeFilename(long)
+
+	if len(got) > 255 || !utf8.ValidString(got) {
+		t.Errorf("expected at most 255 bytes of valid UTF-8, got %d bytes", len(got))
+	}
+
+	if !strings.HasSuffix(got, ".pdf") {
+		t.Errorf("expected .pdf extension to be kept, got %s", got)
+	}
+
+	if expected := strings.Repeat("字", 83) + ".pdf"; got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
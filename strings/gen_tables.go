@@ -0,0 +1,269 @@
+//go:build ignore
+
+// gen_tables generates tables.go from the Unicode Character Database, and
+// copies the grapheme cluster break conformance tests of the same version
+// to testdata/GraphemeBreakTest.txt.
+//
+// Run it with go generate from the strings package. The data files are
+// downloaded from unicode.org unless -ucd names a local copy of the database
+// laid out as it is published:
+//
+//	go run gen_tables.go -ucd /path/to/ucd
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const unicodeVersion = "16.0.0"
+
+var (
+	ucd    = flag.String("ucd", "https://www.unicode.org/Public/"+unicodeVersion+"/ucd", "URL or directory of the Unicode Character Database")
+	output = flag.String("output", "tables.go", "file to write")
+	tests  = flag.String("tests", "testdata/GraphemeBreakTest.txt", "file to copy the grapheme break tests to")
+)
+
+// property names, as used in tables.go, of Grapheme_Cluster_Break values
+var gcbNames = map[string]string{
+	"CR":                 "gcbCR",
+	"LF":                 "gcbLF",
+	"Control":            "gcbControl",
+	"Extend":             "gcbExtend",
+	"ZWJ":                "gcbZWJ",
+	"Regional_Indicator": "gcbRegionalIndicator",
+	"Prepend":            "gcbPrepend",
+	"SpacingMark":        "gcbSpacingMark",
+	"L":                  "gcbL",
+	"V":                  "gcbV",
+	"T":                  "gcbT",
+	"LV":                 "gcbLV",
+	"LVT":                "gcbLVT",
+}
+
+// property names, as used in tables.go, of Indic_Conjunct_Break values
+var incbNames = map[string]string{
+	"Linker":    "incbLinker",
+	"Consonant": "incbConsonant",
+	"Extend":    "incbExtend",
+}
+
+// property names, as used in tables.go, of the East_Asian_Width values that
+// are not narrow
+var eawNames = map[string]string{
+	"W": "eaWide",
+	"F": "eaWide",
+	"A": "eaAmbiguous",
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("gen_tables: ")
+	flag.Parse()
+
+	// the names of the properties of each code point, joined with | in the
+	// table; the Grapheme_Cluster_Break value comes first
+	props := make([][]string, 0x110000)
+
+	parse("auxiliary/GraphemeBreakProperty.txt", func(lo, hi rune, fields []string) {
+		name, ok := gcbNames[fields[0]]
+		if !ok {
+			log.Fatalf("unknown Grapheme_Cluster_Break value %q", fields[0])
+		}
+
+		for r := lo; r <= hi; r++ {
+			props[r] = append([]string{name}, props[r]...)
+		}
+	})
+
+	parse("emoji/emoji-data.txt", func(lo, hi rune, fields []string) {
+		if fields[0] != "Extended_Pictographic" {
+			return
+		}
+
+		for r := lo; r <= hi; r++ {
+			props[r] = append(props[r], "extPict")
+		}
+	})
+
+	parse("DerivedCoreProperties.txt", func(lo, hi rune, fields []string) {
+		if fields[0] != "InCB" {
+			return
+		}
+
+		name, ok := incbNames[fields[1]]
+		if !ok {
+			log.Fatalf("unknown Indic_Conjunct_Break value %q", fields[1])
+		}
+
+		for r := lo; r <= hi; r++ {
+			props[r] = append(props[r], name)
+		}
+	})
+
+	widths := make([]string, 0x110000)
+
+	parse("EastAsianWidth.txt", func(lo, hi rune, fields []string) {
+		for r := lo; r <= hi; r++ {
+			widths[r] = eawNames[fields[0]]
+		}
+	})
+
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "// Code generated by gen_tables.go; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package strings\n\n")
+	fmt.Fprintf(&b, "// unicodeVersion is the version of the Unicode Character Database the\n")
+	fmt.Fprintf(&b, "// tables are generated from\n")
+	fmt.Fprintf(&b, "const unicodeVersion = %q\n\n", unicodeVersion)
+	fmt.Fprintf(&b, "// graphemeTable holds the grapheme break properties of all code points\n")
+	fmt.Fprintf(&b, "// that have any, in order\n")
+	fmt.Fprintf(&b, "var graphemeTable = []graphemeRange{\n")
+
+	writeRanges(&b, func(r int) string {
+		return strings.Join(props[r], " | ")
+	})
+
+	fmt.Fprintf(&b, "}\n\n")
+	fmt.Fprintf(&b, "// widthTable holds the East_Asian_Width of all code points that are wide,\n")
+	fmt.Fprintf(&b, "// full width or ambiguous, in order\n")
+	fmt.Fprintf(&b, "var widthTable = []widthRange{\n")
+
+	writeRanges(&b, func(r int) string {
+		return widths[r]
+	})
+
+	fmt.Fprintf(&b, "}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := copyFile("auxiliary/GraphemeBreakTest.txt", *tests); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// copyFile copies a UCD data file to dst
+func copyFile(name, dst string) error {
+	rc, err := open(name)
+	if err != nil {
+		return err
+	}
+
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(dst, b, 0o644)
+}
+
+// writeRanges writes a table entry for each range of code points with the
+// same non-empty value
+func writeRanges(w io.Writer, value func(r int) string) {
+	for lo := 0; lo < 0x110000; {
+		v := value(lo)
+
+		hi := lo
+		for hi+1 < 0x110000 && value(hi+1) == v {
+			hi++
+		}
+
+		if v != "" {
+			fmt.Fprintf(w, "\t{0x%04X, 0x%04X, %s},\n", lo, hi, v)
+		}
+
+		lo = hi + 1
+	}
+}
+
+// parse calls f with the code point range and remaining fields of each line
+// of a UCD data file
+func parse(name string, f func(lo, hi rune, fields []string)) {
+	rc, err := open(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer rc.Close()
+
+	s := bufio.NewScanner(rc)
+
+	for s.Scan() {
+		line, _, _ := strings.Cut(s.Text(), "#")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.Split(line, ";")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+
+		if len(fields) < 2 {
+			log.Fatalf("%s: malformed line %q", name, s.Text())
+		}
+
+		first, last, _ := strings.Cut(fields[0], "..")
+		if last == "" {
+			last = first
+		}
+
+		lo, err := strconv.ParseUint(first, 16, 32)
+		if err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+
+		hi, err := strconv.ParseUint(last, 16, 32)
+		if err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+
+		f(rune(lo), rune(hi), fields[1:])
+	}
+
+	if err := s.Err(); err != nil {
+		log.Fatalf("%s: %v", name, err)
+	}
+}
+
+// open opens a UCD data file from the -ucd URL or directory
+func open(name string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(*ucd, "http://") && !strings.HasPrefix(*ucd, "https://") {
+		return os.Open(filepath.Join(*ucd, filepath.FromSlash(name)))
+	}
+
+	resp, err := http.Get(*ucd + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", name, resp.Status)
+	}
+
+	return resp.Body, nil
+}
@@ -0,0 +1,304 @@
+package strings
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:generate go run gen_tables.go
+
+// graphemeProp holds the properties of a code point that grapheme cluster
+// segmentation depends on: its Grapheme_Cluster_Break value in the low bits,
+// whether it is Extended_Pictographic and its Indic_Conjunct_Break value
+type graphemeProp uint8
+
+const (
+	gcbOther graphemeProp = iota
+	gcbCR
+	gcbLF
+	gcbControl
+	gcbExtend
+	gcbZWJ
+	gcbRegionalIndicator
+	gcbPrepend
+	gcbSpacingMark
+	gcbL
+	gcbV
+	gcbT
+	gcbLV
+	gcbLVT
+
+	gcbMask graphemeProp = 0x0f
+)
+
+const (
+	extPict graphemeProp = 1 << 4
+
+	incbLinker    graphemeProp = 1 << 5
+	incbConsonant graphemeProp = 2 << 5
+	incbExtend    graphemeProp = 3 << 5
+
+	incbMask graphemeProp = 3 << 5
+)
+
+// graphemeRange gives the properties of the code points lo through hi
+type graphemeRange struct {
+	lo, hi rune
+	prop   graphemeProp
+}
+
+// graphemeProperty returns the properties of r
+func graphemeProperty(r rune) graphemeProp {
+	if r < utf8.RuneSelf {
+		switch {
+		case r == '\r':
+			return gcbCR
+		case r == '\n':
+			return gcbLF
+		case r < 0x20 || r == 0x7f:
+			return gcbControl
+		}
+
+		return gcbOther
+	}
+
+	i := sort.Search(len(graphemeTable), func(i int) bool {
+		return graphemeTable[i].hi >= r
+	})
+
+	if i < len(graphemeTable) && graphemeTable[i].lo <= r {
+		return graphemeTable[i].prop
+	}
+
+	return gcbOther
+}
+
+// firstGrapheme returns the length in bytes of the grapheme cluster at the
+// start of s, following the extended grapheme cluster rules of Unicode
+// Standard Annex #29. Invalid UTF-8 bytes are clusters of their own.
+func firstGrapheme(s string) int {
+	if s == "" {
+		return 0
+	}
+
+	r, n := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError && n == 1 {
+		return n
+	}
+
+	prev := graphemeProperty(r)
+
+	// state for the rules that look further back than the previous code point
+	emoji := prev&extPict != 0                 // GB11: ExtPict Extend*
+	emojiZWJ := false                          // GB11: ExtPict Extend* ZWJ
+	conjunct := prev&incbMask == incbConsonant // GB9c: Consonant [Extend Linker]*
+	linked := false                            // GB9c: and at least one Linker
+	ri := prev&gcbMask == gcbRegionalIndicator // GB12, GB13: an odd number of RI
+
+	for n < len(s) {
+		r, w := utf8.DecodeRuneInString(s[n:])
+		if r == utf8.RuneError && w == 1 {
+			break
+		}
+
+		next := graphemeProperty(r)
+
+		if graphemeBreak(prev, next, emojiZWJ, conjunct && linked, ri) {
+			break
+		}
+
+		switch {
+		case next&extPict != 0:
+			emoji, emojiZWJ = true, false
+		case emoji && !emojiZWJ && next&gcbMask == gcbExtend:
+		case emoji && !emojiZWJ && next&gcbMask == gcbZWJ:
+			emojiZWJ = true
+		default:
+			emoji, emojiZWJ = false, false
+		}
+
+		switch {
+		case next&incbMask == incbConsonant:
+			conjunct, linked = true, false
+		case conjunct && next&incbMask == incbLinker:
+			linked = true
+		case conjunct && next&incbMask == incbExtend:
+		default:
+			conjunct, linked = false, false
+		}
+
+		ri = !ri && next&gcbMask == gcbRegionalIndicator
+
+		prev = next
+		n += w
+	}
+
+	return n
+}
+
+// graphemeBreak reports whether there is a grapheme cluster boundary between
+// code points with the properties prev and next
+func graphemeBreak(prev, next graphemeProp, emojiZWJ, linked, ri bool) bool {
+	p, q := prev&gcbMask, next&gcbMask
+
+	switch {
+	case p == gcbCR && q == gcbLF: // GB3
+		return false
+	case p == gcbCR || p == gcbLF || p == gcbControl: // GB4
+		return true
+	case q == gcbCR || q == gcbLF || q == gcbControl: // GB5
+		return true
+	case p == gcbL && (q == gcbL || q == gcbV || q == gcbLV || q == gcbLVT): // GB6
+		return false
+	case (p == gcbLV || p == gcbV) && (q == gcbV || q == gcbT): // GB7
+		return false
+	case (p == gcbLVT || p == gcbT) && q == gcbT: // GB8
+		return false
+	case q == gcbExtend || q == gcbZWJ || q == gcbSpacingMark: // GB9, GB9a
+		return false
+	case p == gcbPrepend: // GB9b
+		return false
+	case linked && next&incbMask == incbConsonant: // GB9c
+		return false
+	case emojiZWJ && next&extPict != 0: // GB11
+		return false
+	case ri && q == gcbRegionalIndicator: // GB12, GB13
+		return false
+	}
+
+	return true // GB999
+}
+
+// Graphemes splits s into its user-perceived characters, the extended
+// grapheme clusters of Unicode Standard Annex #29. A flag, an emoji ZWJ
+// sequence such as a family, or a letter with combining accents is a single
+// element. Invalid UTF-8 bytes are elements of their own.
+func Graphemes(s string) []string {
+	var gs []string
+
+	for s != "" {
+		n := firstGrapheme(s)
+		gs = append(gs, s[:n])
+		s = s[n:]
+	}
+
+	return gs
+}
+
+// GraphemeCount returns the number of user-perceived characters in s, as
+// split by Graphemes.
+func GraphemeCount(s string) int {
+	c := 0
+
+	for s != "" {
+		s = s[firstGrapheme(s):]
+		c++
+	}
+
+	return c
+}
+
+// unitGraphemes splits s like Graphemes, but keeps the two runes of a
+// "\r\n" cluster apart so that ASCII text splits the same as into runes
+func unitGraphemes(s string) []string {
+	var gs []string
+
+	for s != "" {
+		n := firstGrapheme(s)
+		if s[:n] == "\r\n" {
+			n = 1
+		}
+
+		gs = append(gs, s[:n])
+		s = s[n:]
+	}
+
+	return gs
+}
+
+// MaskLeftGraphemes is like MaskLeft, but keeps the last (keep) grapheme
+// clusters and replaces each of the others with a single mask rune, so that
+// flags, emoji sequences and accented letters are never split.
+//
+// For ASCII text it returns the same as MaskLeft, so a "\r\n" line ending
+// counts as two characters here even though it is one grapheme cluster.
+func MaskLeftGraphemes(mask rune, keep int, text string) string {
+	gs := unitGraphemes(text)
+	k := max(0, min(keep, len(gs)))
+
+	// if we are keeping the whole text, go ahead and return it
+	if len(gs) <= k {
+		return text
+	}
+
+	return strings.Repeat(string(mask), len(gs)-k) + strings.Join(gs[len(gs)-k:], "")
+}
+
+// MaskRightGraphemes is like MaskRight, but keeps the first (keep) grapheme
+// clusters and replaces each of the others with a single mask rune, so that
+// flags, emoji sequences and accented letters are never split.
+//
+// For ASCII text it returns the same as MaskRight, so a "\r\n" line ending
+// counts as two characters here even though it is one grapheme cluster.
+func MaskRightGraphemes(mask rune, keep int, text string) string {
+	gs := unitGraphemes(text)
+	k := max(0, min(keep, len(gs)))
+
+	// if we are keeping the whole text, go ahead and return it
+	if len(gs) <= k {
+		return text
+	}
+
+	return strings.Join(gs[:k], "") + strings.Repeat(string(mask), len(gs)-k)
+}
+
+// TruncateRightGraphemes is like TruncateRight, but counts "length" and the
+// length of "ellipsis" in grapheme clusters rather than runes, so that flags,
+// emoji sequences and accented letters are never split.
+//
+// For ASCII text it returns the same as TruncateRight, so a "\r\n" line ending
+// counts as two characters here even though it is one grapheme cluster.
+func TruncateRightGraphemes(text string, length int, ellipsis string) string {
+	gs := unitGraphemes(text)
+	l := max(0, min(length, len(gs)))
+
+	// if we are keeping the whole text, go ahead and return it
+	if len(gs) <= l {
+		return text
+	}
+
+	// truncate the end of the string, and overwrite its final clusters with
+	// as much of ellipsis as will fit
+	r := gs[:l]
+	es := unitGraphemes(ellipsis)
+	m := min(len(r), len(es))
+	copy(r[len(r)-m:], es[:m])
+
+	return strings.Join(r, "")
+}
+
+// TruncateLeftGraphemes is like TruncateLeft, but counts "length" and the
+// length of "ellipsis" in grapheme clusters rather than runes, so that flags,
+// emoji sequences and accented letters are never split.
+//
+// For ASCII text it returns the same as TruncateLeft, so a "\r\n" line ending
+// counts as two characters here even though it is one grapheme cluster.
+func TruncateLeftGraphemes(text string, length int, ellipsis string) string {
+	gs := unitGraphemes(text)
+	l := max(0, min(length, len(gs)))
+
+	// if we are keeping the whole text, go ahead and return it
+	if len(gs) <= l {
+		return text
+	}
+
+	// truncate the beginning of the string, and overwrite its first clusters
+	// with as much of ellipsis as will fit
+	r := gs[len(gs)-l:]
+	es := unitGraphemes(ellipsis)
+	m := min(len(r), len(es))
+	copy(r, es[:m])
+
+	return strings.Join(r, "")
+}
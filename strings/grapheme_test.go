@@ -0,0 +1,243 @@
+package strings
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGraphemes(t *testing.T) {
+	testStructs := []struct {
+		Input    string
+		Expected []string
+	}{
+		{Input: "", Expected: nil},
+		{Input: "abc", Expected: []string{"a", "b", "c"}},
+		{Input: "a\r\nb", Expected: []string{"a", "\r\n", "b"}},
+		{Input: "e\u0301te\u0301", Expected: []string{"e\u0301", "t", "e\u0301"}},
+		{Input: "🇺🇸🇬🇧🇫", Expected: []string{"🇺🇸", "🇬🇧", "🇫"}},
+		{Input: "👩‍👩‍👧‍👦!", Expected: []string{"👩‍👩‍👧‍👦", "!"}},
+		{Input: "👍🏽👍", Expected: []string{"👍🏽", "👍"}},
+		{Input: "❤️", Expected: []string{"❤️"}},
+		{Input: "a‍👍", Expected: []string{"a‍", "👍"}},
+		{Input: "각가", Expected: []string{"각", "가"}},
+		{Input: "각", Expected: []string{"각"}},
+		{Input: "नमस्ते", Expected: []string{"न", "म", "स्ते"}},
+		{Input: "क्‍ष", Expected: []string{"क्‍ष"}},
+		{Input: "؀١", Expected: []string{"؀١"}},
+		{Input: "a\xffb", Expected: []string{"a", "\xff", "b"}},
+		{Input: "�́", Expected: []string{"�́"}},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := Graphemes(testStruct.Input); !slices.Equal(got, testStruct.Expected) {
+			t.Errorf("Expected %+q, got %+q on iteration %d", testStruct.Expected, got, i)
+		}
+
+		if got := GraphemeCount(testStruct.Input); got != len(testStruct.Expected) {
+			t.Errorf("Expected count %d, got %d on iteration %d", len(testStruct.Expected), got, i)
+		}
+	}
+}
+
+// TestGraphemeBreakConformance runs the Unicode grapheme cluster break tests
+// for the version of the tables, which go generate keeps in testdata
+func TestGraphemeBreakConformance(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "GraphemeBreakTest.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+
+	if !s.Scan() || !strings.Contains(s.Text(), "GraphemeBreakTest-"+unicodeVersion+".txt") {
+		t.Fatalf("Expected GraphemeBreakTest.txt for Unicode %s, got %q", unicodeVersion, s.Text())
+	}
+
+	tests := 0
+
+	for line := 2; s.Scan(); line++ {
+		text, _, _ := strings.Cut(s.Text(), "#")
+
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var (
+			input    strings.Builder
+			cluster  strings.Builder
+			expected []string
+		)
+
+		for _, field := range fields {
+			switch field {
+			case "÷":
+				if cluster.Len() > 0 {
+					expected = append(expected, cluster.String())
+					cluster.Reset()
+				}
+			case "×":
+			default:
+				r, err := strconv.ParseUint(field, 16, 32)
+				if err != nil {
+					t.Fatalf("Expected a code point, got %q on line %d", field, line)
+				}
+
+				input.WriteRune(rune(r))
+				cluster.WriteRune(rune(r))
+			}
+		}
+
+		tests++
+
+		if got := Graphemes(input.String()); !slices.Equal(got, expected) {
+			t.Errorf("Expected %+q, got %+q on line %d", expected, got, line)
+		}
+
+		if got := GraphemeCount(input.String()); got != len(expected) {
+			t.Errorf("Expected count %d, got %d on line %d", len(expected), got, line)
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if tests == 0 {
+		t.Errorf("Expected conformance tests, got none")
+	}
+}
+
+func TestGraphemesASCII(t *testing.T) {
+	var ascii []byte
+	for c := 0; c < 0x80; c++ {
+		ascii = append(ascii, byte(c))
+	}
+
+	s := string(ascii) + "Hi\r\nthere!\r\n"
+
+	for l := -1; l <= len(s)+1; l++ {
+		if got, want := TruncateRightGraphemes(s, l, "…"), TruncateRight(s, l, "…"); got != want {
+			t.Errorf("TruncateRightGraphemes(%d): expected %q, got %q", l, want, got)
+		}
+
+		if got, want := TruncateLeftGraphemes(s, l, "..."), TruncateLeft(s, l, "..."); got != want {
+			t.Errorf("TruncateLeftGraphemes(%d): expected %q, got %q", l, want, got)
+		}
+
+		if got, want := MaskLeftGraphemes('*', l, s), MaskLeft('*', l, s); got != want {
+			t.Errorf("MaskLeftGraphemes(%d): expected %q, got %q", l, want, got)
+		}
+
+		if got, want := MaskRightGraphemes('⌘', l, s), MaskRight('⌘', l, s); got != want {
+			t.Errorf("MaskRightGraphemes(%d): expected %q, got %q", l, want, got)
+		}
+	}
+}
+
+func TestGraphemesCRLF(t *testing.T) {
+	s := "line1\r\nline2"
+
+	if got, want := GraphemeCount(s), 11; got != want {
+		t.Errorf("GraphemeCount: expected %d, got %d", want, got)
+	}
+
+	testStructs := []struct {
+		Got      string
+		Expected string
+	}{
+		{Got: MaskLeftGraphemes('*', 3, s), Expected: "*********ne2"},
+		{Got: MaskRightGraphemes('*', 6, s), Expected: "line1\r******"},
+		{Got: TruncateRightGraphemes(s, 8, "…"), Expected: "line1\r\n…"},
+		{Got: TruncateLeftGraphemes(s, 7, "…"), Expected: "…\nline2"},
+		{Got: TruncateRightGraphemes(s, 8, "\r\n"), Expected: "line1\r\r\n"},
+	}
+
+	for i, testStruct := range testStructs {
+		if testStruct.Got != testStruct.Expected {
+			t.Errorf("Expected %q, got %q on iteration %d", testStruct.Expected, testStruct.Got, i)
+		}
+	}
+}
+
+func TestTruncateRightGraphemes(t *testing.T) {
+	testTruncate(t, TruncateRightGraphemes, 0, "Hi there!", "…", "")
+	testTruncate(t, TruncateRightGraphemes, 1, "Hi there!", "…", "…")
+	testTruncate(t, TruncateRightGraphemes, 5, "Hi there!", "...", "Hi...")
+	testTruncate(t, TruncateRightGraphemes, 9, "Hi there!", "…", "Hi there!")
+
+	testStructs := []struct {
+		Input    string
+		Length   int
+		Ellipsis string
+		Expected string
+	}{
+		{Input: "Zoe\u0308 Saldan\u0303a", Length: 4, Ellipsis: "…", Expected: "Zoe\u0308…"},
+		{Input: "Zoe\u0308 Saldan\u0303a", Length: 3, Ellipsis: "", Expected: "Zoe\u0308"},
+		{Input: "🇺🇸🇬🇧🇫🇷🇩🇪", Length: 3, Ellipsis: "…", Expected: "🇺🇸🇬🇧…"},
+		{Input: "👩‍👩‍👧‍👦 family", Length: 2, Ellipsis: "…", Expected: "👩‍👩‍👧‍👦…"},
+		{Input: "abcdef", Length: 3, Ellipsis: "🇺🇸🇬🇧", Expected: "a🇺🇸🇬🇧"},
+		{Input: "abcdef", Length: 1, Ellipsis: "🇺🇸🇬🇧", Expected: "🇺🇸"},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := TruncateRightGraphemes(testStruct.Input, testStruct.Length, testStruct.Ellipsis); got != testStruct.Expected {
+			t.Errorf("Expected %+q, got %+q on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+}
+
+func TestTruncateLeftGraphemes(t *testing.T) {
+	testTruncate(t, TruncateLeftGraphemes, 0, "Hi there!", "…", "")
+	testTruncate(t, TruncateLeftGraphemes, 1, "Hi there!", "…", "…")
+	testTruncate(t, TruncateLeftGraphemes, 5, "Hi there!", "...", "...e!")
+	testTruncate(t, TruncateLeftGraphemes, 9, "Hi there!", "…", "Hi there!")
+
+	testStructs := []struct {
+		Input    string
+		Length   int
+		Ellipsis string
+		Expected string
+	}{
+		{Input: "Saldan\u0303a", Length: 3, Ellipsis: "…", Expected: "…n\u0303a"},
+		{Input: "🇺🇸🇬🇧🇫🇷🇩🇪", Length: 3, Ellipsis: "…", Expected: "…🇫🇷🇩🇪"},
+		{Input: "hi 👍🏽👍🏿", Length: 3, Ellipsis: "…", Expected: "…👍🏽👍🏿"},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := TruncateLeftGraphemes(testStruct.Input, testStruct.Length, testStruct.Ellipsis); got != testStruct.Expected {
+			t.Errorf("Expected %+q, got %+q on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+}
+
+func TestMaskGraphemes(t *testing.T) {
+	testStructs := []struct {
+		Input string
+		Keep  int
+		Left  string
+		Right string
+	}{
+		{Input: "Zoe\u0308 Saldan\u0303a", Keep: 3, Left: "********an\u0303a", Right: "Zoe\u0308********"},
+		{Input: "🇺🇸🇬🇧🇫🇷", Keep: 1, Left: "**🇫🇷", Right: "🇺🇸**"},
+		{Input: "👩‍👩‍👧‍👦", Keep: 0, Left: "*", Right: "*"},
+		{Input: "👩‍👩‍👧‍👦", Keep: 1, Left: "👩‍👩‍👧‍👦", Right: "👩‍👩‍👧‍👦"},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := MaskLeftGraphemes('*', testStruct.Keep, testStruct.Input); got != testStruct.Left {
+			t.Errorf("Expected %+q, got %+q on iteration %d", testStruct.Left, got, i)
+		}
+
+		if got := MaskRightGraphemes('*', testStruct.Keep, testStruct.Input); got != testStruct.Right {
+			t.Errorf("Expected %+q, got %+q on iteration %d", testStruct.Right, got, i)
+		}
+	}
+}
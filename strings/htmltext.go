@@ -0,0 +1,450 @@
+package strings
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+type HTMLTextOption struct {
+	width  int
+	bullet string
+}
+
+// WithHTMLTextWrapWidth wraps lines of text longer than width columns, as
+// measured by DisplayWidth, at spaces. Words longer than a line, such as URLs,
+// are not broken. Text is not wrapped by default.
+func WithHTMLTextWrapWidth(width int) func(o *HTMLTextOption) {
+	return func(o *HTMLTextOption) {
+		o.width = width
+	}
+}
+
+// WithHTMLTextBullet sets the marker of the items of unordered lists. The
+// default is "* ".
+func WithHTMLTextBullet(bullet string) func(o *HTMLTextOption) {
+	return func(o *HTMLTextOption) {
+		o.bullet = bullet
+	}
+}
+
+// HTMLToText converts HTML, such as the body of an email or notification, to
+// readable plain text:
+//
+//	text := strings.HTMLToText(body, strings.WithHTMLTextWrapWidth(72))
+//
+// Entities are unescaped and white space is collapsed as a browser would.
+// Paragraphs, headings and other blocks are separated by line breaks, list
+// items get a bullet or their number, nested lists and block quotes are
+// indented, links become "text (url)" and images become their alternative
+// text. The content of script, style and head elements is dropped, and
+// preformatted text is kept as is.
+//
+// Unlike StripHTMLTags, the result is not safe to include in HTML without
+// escaping it.
+func HTMLToText(s string, options ...func(*HTMLTextOption)) string {
+	op := HTMLTextOption{
+		bullet: "* ",
+	}
+
+	for _, o := range options {
+		o(&op)
+	}
+
+	doc, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		// the parser only fails when reading fails, which a strings.Reader
+		// does not
+		return ""
+	}
+
+	r := &textRenderer{op: op}
+	r.walk(doc)
+	r.flush()
+
+	return r.out.String()
+}
+
+// htmlSeparators is how many line breaks separate each block element from
+// what surrounds it
+var htmlSeparators = map[atom.Atom]int{
+	atom.P:          2,
+	atom.H1:         2,
+	atom.H2:         2,
+	atom.H3:         2,
+	atom.H4:         2,
+	atom.H5:         2,
+	atom.H6:         2,
+	atom.Ul:         2,
+	atom.Ol:         2,
+	atom.Dl:         2,
+	atom.Pre:        2,
+	atom.Blockquote: 2,
+	atom.Table:      2,
+	atom.Hr:         2,
+	atom.Figure:     2,
+	atom.Div:        1,
+	atom.Li:         1,
+	atom.Dt:         1,
+	atom.Dd:         1,
+	atom.Tr:         1,
+	atom.Section:    1,
+	atom.Article:    1,
+	atom.Header:     1,
+	atom.Footer:     1,
+	atom.Nav:        1,
+	atom.Aside:      1,
+	atom.Main:       1,
+	atom.Address:    1,
+	atom.Form:       1,
+	atom.Fieldset:   1,
+	atom.Figcaption: 1,
+	atom.Caption:    1,
+}
+
+// textRenderer accumulates the inline text of the current block and writes
+// it out, wrapped and indented, when the block ends
+type textRenderer struct {
+	op  HTMLTextOption
+	out strings.Builder
+
+	// text is the inline text of the current block; space is whether a
+	// collapsed space is pending before the next word
+	text  strings.Builder
+	space bool
+
+	// sep is how many line breaks to write before the next block
+	sep int
+
+	// prefixes indent the lines of nested blocks; bullet replaces the last
+	// one on the first line of a list item
+	prefixes []string
+	bullet   string
+
+	// last is the prefix of the last block written
+	last string
+
+	// lists is how many lists the current block is nested in
+	lists int
+
+	pre bool
+}
+
+// walk renders n and its children
+func (r *textRenderer) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.write(n.Data)
+		return
+	case html.ElementNode:
+	case html.DocumentNode:
+		r.children(n)
+		return
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head, atom.Template, atom.Noscript:
+		return
+	case atom.Br:
+		r.text.WriteByte('\n')
+		r.space = false
+
+		return
+	case atom.Img:
+		r.write(htmlAttr(n, "alt"))
+		return
+	case atom.Hr:
+		r.block(2)
+		r.write("---")
+		r.block(2)
+
+		return
+	case atom.A:
+		r.link(n)
+		return
+	case atom.Ul, atom.Ol:
+		r.list(n)
+		return
+	case atom.Blockquote:
+		r.block(2)
+		r.indent("> ", func() {
+			r.children(n)
+			r.flush()
+		})
+		r.block(2)
+
+		return
+	case atom.Pre:
+		r.block(2)
+		r.pre = true
+		r.children(n)
+		r.block(2)
+		r.pre = false
+
+		return
+	case atom.Td, atom.Th:
+		if n.PrevSibling != nil {
+			r.space = true
+		}
+	}
+
+	sep := htmlSeparators[n.DataAtom]
+	r.block(sep)
+	r.children(n)
+	r.block(sep)
+}
+
+// children renders the children of n
+func (r *textRenderer) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.walk(c)
+	}
+}
+
+// write adds text to the current block, collapsing white space unless in
+// preformatted text
+func (r *textRenderer) write(s string) {
+	if r.pre {
+		r.text.WriteString(s)
+		return
+	}
+
+	if s != "" && isHTMLSpace(rune(s[0])) {
+		r.space = true
+	}
+
+	for i, word := range strings.FieldsFunc(s, isHTMLSpace) {
+		if (i > 0 || r.space) && !r.lineStart() {
+			r.text.WriteByte(' ')
+		}
+
+		r.text.WriteString(word)
+		r.space = false
+	}
+
+	if s != "" && isHTMLSpace(rune(s[len(s)-1])) {
+		r.space = true
+	}
+}
+
+// lineStart reports whether nothing has been written on the current line
+func (r *textRenderer) lineStart() bool {
+	n := r.text.Len()
+
+	return n == 0 || r.text.String()[n-1] == '\n'
+}
+
+// link renders a link as its text followed by its URL
+func (r *textRenderer) link(n *html.Node) {
+	start := r.text.Len()
+	r.children(n)
+
+	href := strings.TrimSpace(htmlAttr(n, "href"))
+	text := strings.TrimSpace(r.text.String()[min(start, r.text.Len()):])
+
+	// leave out links within the page and to scripts, and URLs that are the
+	// text already
+	lower := strings.ToLower(href)
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(lower, "javascript:") {
+		return
+	}
+
+	if text == href || text == strings.TrimPrefix(href, "mailto:") ||
+		text == strings.TrimPrefix(strings.TrimPrefix(href, "https://"), "http://") {
+		return
+	}
+
+	if text == "" {
+		r.write(href)
+		return
+	}
+
+	r.space = true
+	r.write("(" + href + ")")
+}
+
+// list renders a list, numbering the items of ordered lists
+func (r *textRenderer) list(n *html.Node) {
+	// nested lists are not separated from their item by a blank line
+	sep := 2
+	if r.lists > 0 {
+		sep = 1
+	}
+
+	r.block(sep)
+	r.lists++
+
+	num, err := strconv.Atoi(htmlAttr(n, "start"))
+	if err != nil {
+		num = 1
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			r.walk(c)
+			continue
+		}
+
+		bullet := r.op.bullet
+		if n.DataAtom == atom.Ol {
+			bullet = strconv.Itoa(num) + ". "
+			num++
+		}
+
+		r.block(1)
+		r.indent(strings.Repeat(" ", DisplayWidth(bullet)), func() {
+			r.bullet = bullet
+			r.children(c)
+			r.block(1)
+			r.bullet = ""
+		})
+	}
+
+	r.lists--
+	r.block(sep)
+}
+
+// indent renders the blocks f writes with an extra prefix
+func (r *textRenderer) indent(prefix string, f func()) {
+	r.prefixes = append(r.prefixes, prefix)
+	f()
+	r.prefixes = r.prefixes[:len(r.prefixes)-1]
+}
+
+// block ends the current block, if any, and asks for at least sep line
+// breaks before the next one
+func (r *textRenderer) block(sep int) {
+	if sep == 0 {
+		return
+	}
+
+	r.flush()
+	r.sep = max(r.sep, sep)
+}
+
+// flush writes out the text of the current block
+func (r *textRenderer) flush() {
+	text := r.text.String()
+	r.text.Reset()
+	r.space = false
+
+	if !r.pre {
+		text = strings.TrimSpace(text)
+	} else {
+		text = strings.TrimPrefix(strings.TrimRight(text, " \t\r\n"), "\n")
+	}
+
+	if text == "" {
+		return
+	}
+
+	prefix := strings.Join(r.prefixes, "")
+	first := prefix
+
+	if r.bullet != "" && len(r.prefixes) > 0 {
+		first = strings.Join(r.prefixes[:len(r.prefixes)-1], "") + r.bullet
+		r.bullet = ""
+	}
+
+	// blank lines between blocks keep the prefixes the blocks share, so
+	// block quotes are not interrupted
+	if r.out.Len() > 0 {
+		shared := 0
+		for shared < min(len(prefix), len(r.last)) && prefix[shared] == r.last[shared] {
+			shared++
+		}
+
+		r.out.WriteByte('\n')
+
+		for i := 1; i < r.sep; i++ {
+			r.out.WriteString(strings.TrimRight(prefix[:shared], " "))
+			r.out.WriteByte('\n')
+		}
+	}
+
+	r.sep = 0
+	r.last = prefix
+
+	for i, line := range r.lines(text, DisplayWidth(prefix)) {
+		if i > 0 {
+			r.out.WriteByte('\n')
+		}
+
+		p := prefix
+		if i == 0 {
+			p = first
+		}
+
+		if line == "" {
+			p = strings.TrimRight(p, " ")
+		}
+
+		r.out.WriteString(p)
+		r.out.WriteString(strings.ReplaceAll(line, "\u00a0", " "))
+	}
+}
+
+// lines splits text into lines, wrapping them unless in preformatted text
+func (r *textRenderer) lines(text string, indent int) []string {
+	var lines []string
+
+	for _, line := range strings.Split(text, "\n") {
+		if r.pre || r.op.width <= 0 {
+			lines = append(lines, line)
+			continue
+		}
+
+		lines = append(lines, wrap(strings.TrimSpace(line), max(1, r.op.width-indent))...)
+	}
+
+	return lines
+}
+
+// wrap breaks s at spaces into lines of at most width columns, except for
+// words that are longer on their own
+func wrap(s string, width int) []string {
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+
+	for _, word := range strings.Split(s, " ") {
+		w := DisplayWidth(word)
+
+		if lineWidth > 0 && lineWidth+1+w > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineWidth = 0
+		}
+
+		if lineWidth > 0 {
+			line.WriteByte(' ')
+			lineWidth++
+		}
+
+		line.WriteString(word)
+		lineWidth += w
+	}
+
+	return append(lines, line.String())
+}
+
+// htmlAttr returns the value of the named attribute of n
+func htmlAttr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == name {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+// isHTMLSpace reports whether r is white space as HTML defines it, which
+// does not include non-breaking spaces
+func isHTMLSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\f' || r == '\r'
+}
@@ -0,0 +1,47 @@
+package strings
+
+import "testing"
+
+func TestHTMLToText(t *testing.T) {
+	testStructs := []struct {
+		Input    string
+		Width    int
+		Expected string
+	}{
+		{Input: ``, Expected: ``},
+		{Input: `plain text`, Expected: `plain text`},
+		{Input: `<p>5 &gt; 3 &amp;&amp; 2&nbsp;&lt; 4</p>`, Expected: `5 > 3 && 2 < 4`},
+		{Input: "<p>Hello,\n   <b>world</b>!</p><p>Bye</p>", Expected: "Hello, world!\n\nBye"},
+		{Input: `<div>one</div><div>two</div>`, Expected: "one\ntwo"},
+		{Input: `line one<br>line two`, Expected: "line one\nline two"},
+		{Input: `<head><title>Title</title><style>p { color: red }</style></head><p>Body</p><script>alert(1)</script>`, Expected: "Body"},
+		{Input: `See <a href="https://example.com/a">the docs</a> now`, Expected: "See the docs (https://example.com/a) now"},
+		{Input: `<a href="https://example.com">https://example.com</a>`, Expected: "https://example.com"},
+		{Input: `<a href="mailto:bob@example.com">bob@example.com</a>`, Expected: "bob@example.com"},
+		{Input: `<a href="#top">Top</a> <a href="javascript:x()">Run</a>`, Expected: "Top Run"},
+		{Input: `<a href="https://example.com"><img src="x.png"></a>`, Expected: "https://example.com"},
+		{Input: `<img src="logo.png" alt="Logo"> Inc.`, Expected: "Logo Inc."},
+		{Input: `<ul><li>one</li><li>two<ul><li>nested</li></ul></li></ul>`, Expected: "* one\n* two\n  * nested"},
+		{Input: `<ol start="9"><li>nine</li><li>ten</li></ol>`, Expected: "9. nine\n10. ten"},
+		{Input: `<p>Intro</p><ul><li>item</li></ul><p>End</p>`, Expected: "Intro\n\n* item\n\nEnd"},
+		{Input: `<p>Intro</p><blockquote><p>one</p><p>two</p></blockquote>`, Expected: "Intro\n\n> one\n>\n> two"},
+		{Input: "<pre>  a\n    b</pre>", Expected: "  a\n    b"},
+		{Input: `<table><tr><th>a</th><th>b</th></tr><tr><td>1</td><td>2</td></tr></table>`, Expected: "a b\n1 2"},
+		{Input: `<p>above</p><hr><p>below</p>`, Expected: "above\n\n---\n\nbelow"},
+		{Input: `<p>the quick brown fox jumps over the lazy dog</p>`, Width: 15, Expected: "the quick brown\nfox jumps over\nthe lazy dog"},
+		{Input: `<p>see https://example.com/a/very/long/path here</p>`, Width: 10, Expected: "see\nhttps://example.com/a/very/long/path\nhere"},
+		{Input: `<ul><li>the quick brown fox jumps</li></ul>`, Width: 12, Expected: "* the quick\n  brown fox\n  jumps"},
+		{Input: `<blockquote>the quick brown fox</blockquote>`, Width: 12, Expected: "> the quick\n> brown fox"},
+		{Input: `<p>日本語の文章です 日本語の文章です</p>`, Width: 20, Expected: "日本語の文章です\n日本語の文章です"},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := HTMLToText(testStruct.Input, WithHTMLTextWrapWidth(testStruct.Width)); got != testStruct.Expected {
+			t.Errorf("Expected %q, got %q on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+
+	if got, expected := HTMLToText(`<ul><li>one</li></ul>`, WithHTMLTextBullet("• ")), "• one"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,442 @@
+package strings
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Detector finds one kind of secret or personal data in free text.
+type Detector struct {
+	// Name identifies the detector in findings, such as "aws_access_key".
+	Name string
+
+	// Pattern finds candidates. If it has a capturing group, only the
+	// first group is treated as the secret, so that context such as
+	// "Bearer " can be part of the pattern without being masked.
+	Pattern *regexp.Regexp
+
+	// Validate, if not nil, rejects candidates that only look like a
+	// secret, such as card numbers failing the Luhn checksum.
+	Validate func(s string) bool
+
+	// Mask masks a detected value with the mask rune.
+	Mask func(s string, mask rune) string
+}
+
+// Finding is a secret found by a Redactor.
+type Finding struct {
+	// Detector is the name of the detector that found the secret.
+	Detector string
+
+	// Start and End are the byte offsets of the secret in the scanned text.
+	Start, End int
+
+	// Value is the secret.
+	Value string
+
+	// mask is the Mask of the detector that found the secret
+	mask func(s string, mask rune) string
+}
+
+// maskAll masks every rune of s
+func maskAll(s string, mask rune) string {
+	return MaskLeft(mask, 0, s)
+}
+
+// maskAllButLast4 masks all but the last four runes of s
+func maskAllButLast4(s string, mask rune) string {
+	return MaskLeft(mask, 4, s)
+}
+
+// AWSAccessKeyDetector finds AWS access key ids, keeping their last four
+// characters.
+var AWSAccessKeyDetector = Detector{
+	Name:    "aws_access_key",
+	Pattern: regexp.MustCompile(`\b((?:AKIA|ASIA|AGPA|AIDA|AIPA|ANPA|ANVA|AROA)[A-Z0-9]{16})\b`),
+	Mask:    maskAllButLast4,
+}
+
+// BearerTokenDetector finds the token of a bearer authorization, such as a
+// logged Authorization header, and masks it completely.
+var BearerTokenDetector = Detector{
+	Name:    "bearer_token",
+	Pattern: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`),
+	Mask:    maskAll,
+}
+
+// JWTDetector finds JSON Web Tokens and masks their signature.
+var JWTDetector = Detector{
+	Name:    "jwt",
+	Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+	Mask:    JWTRecognizer.Mask,
+}
+
+// EmailDetector finds email addresses and masks them like EmailRecognizer.
+var EmailDetector = Detector{
+	Name:    "email",
+	Pattern: regexp.MustCompile(`[\p{L}\p{N}._%+\-]+@[\p{L}\p{N}.\-]+\.\p{L}{2,}`),
+	Mask:    EmailRecognizer.Mask,
+}
+
+// SSNDetector finds US social security numbers written as 123-45-6789,
+// keeping the last four digits.
+var SSNDetector = Detector{
+	Name:    "ssn",
+	Pattern: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+	Validate: func(s string) bool {
+		area, group, serial := s[:3], s[4:6], s[7:]
+		return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+	},
+	Mask: func(s string, mask rune) string {
+		return maskDigits(s, mask, 4)
+	},
+}
+
+// CardDetector finds Luhn valid payment card numbers, optionally grouped with
+// spaces or dashes, keeping the last four digits.
+var CardDetector = Detector{
+	Name:    "card",
+	Pattern: regexp.MustCompile(`\b(?:\d[ -]?){11,18}\d\b`),
+	Validate: func(s string) bool {
+		digits, _ := digitsOnly(s, " -")
+		return len(digits) >= 12 && luhn(digits)
+	},
+	Mask: func(s string, mask rune) string {
+		return maskDigits(s, mask, 4)
+	},
+}
+
+// BuiltinDetectors are the detectors a Redactor uses by default. High
+// entropy strings are detected separately, see WithRedactorEntropy.
+var BuiltinDetectors = []Detector{
+	AWSAccessKeyDetector,
+	BearerTokenDetector,
+	JWTDetector,
+	EmailDetector,
+	SSNDetector,
+	CardDetector,
+}
+
+// entropyCandidate is a run of characters that may be a generated secret
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/_\-]+=*`)
+
+type RedactorOption struct {
+	mask      rune
+	custom    []Detector
+	detectors []Detector
+	entropy   float64
+	minLength int
+	sensitive []string
+}
+
+// WithRedactorRune sets the rune used for masking. The default is '*'.
+func WithRedactorRune(mask rune) func(o *RedactorOption) {
+	return func(o *RedactorOption) {
+		o.mask = mask
+	}
+}
+
+// WithRedactorDetectors replaces the built-in detectors, for example to only
+// use some of BuiltinDetectors.
+func WithRedactorDetectors(ds ...Detector) func(o *RedactorOption) {
+	return func(o *RedactorOption) {
+		o.detectors = ds
+	}
+}
+
+// WithRedactorDetector adds a custom detector, which wins over the others
+// when they find the same text.
+func WithRedactorDetector(d Detector) func(o *RedactorOption) {
+	return func(o *RedactorOption) {
+		o.custom = append(o.custom, d)
+	}
+}
+
+// WithRedactorEntropy sets the Shannon entropy, in bits per character, above
+// which a run of at least minLength letters, digits and base64 punctuation is
+// treated as a generated secret, such as an API key. The default is 4.0 bits
+// and 20 characters; an entropy of 0 disables the check.
+func WithRedactorEntropy(bits float64, minLength int) func(o *RedactorOption) {
+	return func(o *RedactorOption) {
+		o.entropy = bits
+		o.minLength = minLength
+	}
+}
+
+// WithRedactorSensitiveKeys adds slog attribute keys whose values
+// RedactingHandler masks completely, in addition to common ones such as
+// password, token and authorization. Keys are compared case insensitively.
+func WithRedactorSensitiveKeys(keys ...string) func(o *RedactorOption) {
+	return func(o *RedactorOption) {
+		o.sensitive = append(o.sensitive, keys...)
+	}
+}
+
+// Redactor finds secrets and personal data, such as access keys, tokens,
+// email addresses and card numbers, in free text and masks them in place.
+//
+// This type operates on runes to remain Unicode safe.
+type Redactor struct {
+	op        RedactorOption
+	detectors []Detector
+}
+
+// NewRedactor returns a Redactor that uses BuiltinDetectors and high entropy
+// detection unless configured otherwise.
+func NewRedactor(options ...func(*RedactorOption)) *Redactor {
+	op := RedactorOption{
+		mask:      '*',
+		detectors: BuiltinDetectors,
+		entropy:   4.0,
+		minLength: 20,
+	}
+
+	for _, o := range options {
+		o(&op)
+	}
+
+	return &Redactor{op: op, detectors: append(slices.Clone(op.custom), op.detectors...)}
+}
+
+// Find returns the secrets in s in order. Overlapping findings are resolved
+// in favor of the one starting first, then the longest.
+func (r *Redactor) Find(s string) []Finding {
+	var findings []Finding
+
+	for _, d := range r.detectors {
+		for _, loc := range d.Pattern.FindAllStringSubmatchIndex(s, -1) {
+			start, end := loc[0], loc[1]
+
+			// only the first group is the secret
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+
+			v := s[start:end]
+
+			if d.Validate != nil && !d.Validate(v) {
+				continue
+			}
+
+			findings = append(findings, Finding{Detector: d.Name, Start: start, End: end, Value: v, mask: d.Mask})
+		}
+	}
+
+	if r.op.entropy > 0 {
+		for _, loc := range entropyCandidate.FindAllStringIndex(s, -1) {
+			v := s[loc[0]:loc[1]]
+
+			if len(v) >= r.op.minLength && looksGenerated(v) && shannonEntropy(v) >= r.op.entropy {
+				findings = append(findings, Finding{Detector: "high_entropy", Start: loc[0], End: loc[1], Value: v})
+			}
+		}
+	}
+
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		if a.Start != b.Start {
+			return a.Start - b.Start
+		}
+
+		return b.End - a.End
+	})
+
+	// drop findings overlapping an earlier one
+	result := findings[:0]
+	end := 0
+
+	for _, f := range findings {
+		if f.Start >= end {
+			result = append(result, f)
+			end = f.End
+		}
+	}
+
+	return result
+}
+
+// Redact returns s with every finding masked in place.
+func (r *Redactor) Redact(s string) string {
+	findings := r.Find(s)
+	if len(findings) == 0 {
+		return s
+	}
+
+	var sb strings.Builder
+
+	last := 0
+
+	for _, f := range findings {
+		sb.WriteString(s[last:f.Start])
+		sb.WriteString(r.mask(f))
+		last = f.End
+	}
+
+	sb.WriteString(s[last:])
+
+	return sb.String()
+}
+
+func (r *Redactor) mask(f Finding) string {
+	if f.mask != nil {
+		return f.mask(f.Value, r.op.mask)
+	}
+
+	return maskAll(f.Value, r.op.mask)
+}
+
+// looksGenerated reports whether s mixes letters and digits, which words,
+// identifiers and paths rarely do as thoroughly as generated secrets
+func looksGenerated(s string) bool {
+	var lower, upper, digit bool
+
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+	}
+
+	return digit && (lower || upper)
+}
+
+// shannonEntropy returns the entropy of s in bits per rune
+func shannonEntropy(s string) float64 {
+	counts := map[rune]int{}
+	n := 0
+
+	for _, c := range s {
+		counts[c]++
+		n++
+	}
+
+	entropy := 0.0
+
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
+
+// sensitiveMaskLength is how many mask runes replace the value of a
+// sensitive key, whatever its length
+const sensitiveMaskLength = 8
+
+// sensitiveKeys are slog attribute keys whose values are always masked
+var sensitiveKeys = []string{
+	"access_token", "api_key", "apikey", "authorization", "client_secret",
+	"cookie", "credentials", "passwd", "password", "private_key",
+	"refresh_token", "secret", "set-cookie", "token",
+}
+
+// RedactingHandler is a slog.Handler that redacts log messages and attribute
+// values with a Redactor before passing records on to another handler:
+//
+//	logger := slog.New(strings.NewRedactingHandler(
+//		slog.NewJSONHandler(os.Stdout, nil),
+//		strings.NewRedactor(),
+//	))
+//
+// String values are redacted in place. Other values are formatted and only
+// replaced by the redacted text if it contains a secret, so they keep their
+// type otherwise. Values of sensitive keys, such as password or token, are
+// replaced by a fixed number of mask runes so that their length is hidden.
+type RedactingHandler struct {
+	next slog.Handler
+	r    *Redactor
+}
+
+// NewRedactingHandler wraps next. If r is nil, NewRedactor() is used.
+func NewRedactingHandler(next slog.Handler, r *Redactor) *RedactingHandler {
+	if r == nil {
+		r = NewRedactor()
+	}
+
+	return &RedactingHandler{next: next, r: r}
+}
+
+// Enabled implements slog.Handler.
+func (h *RedactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *RedactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.r.Redact(record.Message), record.PC)
+
+	record.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(a))
+		return true
+	})
+
+	return h.next.Handle(ctx, redacted)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *RedactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+
+	for i, a := range attrs {
+		redacted[i] = h.redactAttr(a)
+	}
+
+	return &RedactingHandler{next: h.next.WithAttrs(redacted), r: h.r}
+}
+
+// WithGroup implements slog.Handler.
+func (h *RedactingHandler) WithGroup(name string) slog.Handler {
+	return &RedactingHandler{next: h.next.WithGroup(name), r: h.r}
+}
+
+func (h *RedactingHandler) redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+
+	if v.Kind() == slog.KindGroup {
+		group := v.Group()
+		redacted := make([]any, len(group))
+
+		for i, ga := range group {
+			redacted[i] = h.redactAttr(ga)
+		}
+
+		return slog.Group(a.Key, redacted...)
+	}
+
+	if h.isSensitive(a.Key) {
+		return slog.String(a.Key, strings.Repeat(string(h.r.op.mask), sensitiveMaskLength))
+	}
+
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.r.Redact(v.String()))
+	case slog.KindAny:
+		s := fmt.Sprintf("%+v", v.Any())
+		if redacted := h.r.Redact(s); redacted != s {
+			return slog.String(a.Key, redacted)
+		}
+	}
+
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+func (h *RedactingHandler) isSensitive(key string) bool {
+	for _, list := range [][]string{sensitiveKeys, h.r.op.sensitive} {
+		for _, k := range list {
+			if strings.EqualFold(key, k) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,303 @@
+package strings
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"golang.org/x/net/html"
+)
+
+// SanitizePreset is a named set of the HTML elements and attributes a
+// Sanitizer keeps.
+type SanitizePreset int
+
+const (
+	// SanitizePlainText removes all markup, like StripHTMLTags.
+	SanitizePlainText SanitizePreset = iota
+
+	// SanitizeBasicFormatting keeps paragraphs, line breaks and inline
+	// formatting such as bold, italics and code, but no links or images.
+	SanitizeBasicFormatting
+
+	// SanitizeUGC keeps what user generated content such as comments and
+	// descriptions needs: basic formatting, headings, lists, quotes, links and
+	// images.
+	SanitizeUGC
+
+	// SanitizeMarkdown keeps what Markdown renderers produce: everything
+	// SanitizeUGC keeps, plus tables, task list checkboxes and the language
+	// classes of fenced code blocks.
+	SanitizeMarkdown
+)
+
+// basicElements are the elements SanitizeBasicFormatting keeps
+var basicElements = []string{
+	"p", "br", "b", "strong", "i", "em", "u", "s", "del", "ins", "mark",
+	"small", "sub", "sup", "code", "pre", "blockquote", "span",
+}
+
+// ugcElements are the elements SanitizeUGC keeps in addition to basicElements
+var ugcElements = []string{
+	"h1", "h2", "h3", "h4", "h5", "h6", "hr", "q", "cite", "abbr", "kbd",
+	"samp", "var", "figure", "figcaption",
+}
+
+var (
+	codeLanguage = regexp.MustCompile(`^language-[\w+#-]+$`)
+	checkbox     = regexp.MustCompile(`(?i)^checkbox$`)
+	checkboxAttr = regexp.MustCompile(`^(|checked|disabled)$`)
+)
+
+type SanitizeOption struct {
+	schemes     []string
+	follow      bool
+	targetBlank bool
+	imageProxy  func(*url.URL)
+}
+
+// WithSanitizeURLSchemes sets the URL schemes allowed in links and image
+// sources, replacing the default of "http", "https" and "mailto". URLs with
+// any other scheme are removed; relative URLs are always allowed.
+func WithSanitizeURLSchemes(schemes ...string) func(o *SanitizeOption) {
+	return func(o *SanitizeOption) {
+		o.schemes = schemes
+	}
+}
+
+// WithSanitizeFollowLinks stops adding rel="nofollow" to links, for content
+// from trusted authors.
+func WithSanitizeFollowLinks() func(o *SanitizeOption) {
+	return func(o *SanitizeOption) {
+		o.follow = true
+	}
+}
+
+// WithSanitizeTargetBlank adds target="_blank" and rel="noopener" to links to
+// other sites, so they open in a new tab that cannot script the page.
+func WithSanitizeTargetBlank() func(o *SanitizeOption) {
+	return func(o *SanitizeOption) {
+		o.targetBlank = true
+	}
+}
+
+// WithSanitizeImageProxy calls rewrite with the source URL of each image kept,
+// so it can be changed to go through an image proxy that hides visitors'
+// addresses from the image host:
+//
+//	strings.WithSanitizeImageProxy(func(u *url.URL) {
+//		*u = url.URL{
+//			Scheme:   "https",
+//			Host:     "img.example.com",
+//			RawQuery: url.Values{"url": {u.String()}}.Encode(),
+//		}
+//	})
+func WithSanitizeImageProxy(rewrite func(u *url.URL)) func(o *SanitizeOption) {
+	return func(o *SanitizeOption) {
+		o.imageProxy = rewrite
+	}
+}
+
+// Removal is an element or attribute a Sanitizer removed, and how many times.
+type Removal struct {
+	// Element is the name of the element, such as "script".
+	Element string
+
+	// Attribute is the name of the attribute, such as "onclick", or empty if
+	// the element itself was removed.
+	Attribute string
+
+	Count int
+}
+
+// String returns the removal as "script" or "a[onclick]", followed by the
+// count if it is more than one.
+func (r Removal) String() string {
+	s := r.Element
+	if r.Attribute != "" {
+		s += "[" + r.Attribute + "]"
+	}
+
+	if r.Count > 1 {
+		s += fmt.Sprintf(" ×%d", r.Count)
+	}
+
+	return s
+}
+
+// Sanitizer removes everything from untrusted HTML that a SanitizePreset
+// does not allow, so that it can be safely included in a page. It is safe for
+// concurrent use.
+type Sanitizer struct {
+	policy *bluemonday.Policy
+
+	// checkboxes removes input elements other than checkboxes, which the
+	// policy cannot express
+	checkboxes bool
+}
+
+// NewSanitizer returns a Sanitizer for a preset:
+//
+//	s := strings.NewSanitizer(strings.SanitizeUGC,
+//		strings.WithSanitizeTargetBlank(),
+//		strings.WithSanitizeURLSchemes("https", "mailto"),
+//	)
+//
+//	safe := s.Sanitize(comment)
+//
+// Links always get rel="nofollow" unless WithSanitizeFollowLinks is given.
+// Options about links and images do not apply to presets without them.
+func NewSanitizer(preset SanitizePreset, options ...func(*SanitizeOption)) *Sanitizer {
+	op := SanitizeOption{
+		schemes: []string{"http", "https", "mailto"},
+	}
+
+	for _, o := range options {
+		o(&op)
+	}
+
+	if preset == SanitizePlainText {
+		return &Sanitizer{policy: bluemonday.StrictPolicy()}
+	}
+
+	p := bluemonday.NewPolicy()
+	p.AllowElements(basicElements...)
+	p.AllowAttrs("cite").OnElements("blockquote")
+
+	if preset == SanitizeBasicFormatting {
+		return &Sanitizer{policy: p}
+	}
+
+	p.AllowElements(ugcElements...)
+	p.AllowLists()
+	p.AllowAttrs("cite").OnElements("q")
+	p.AllowAttrs("title").OnElements("a", "abbr", "img")
+
+	// links and images
+	p.RequireParseableURLs(true)
+	p.AllowRelativeURLs(true)
+	p.AllowURLSchemes(op.schemes...)
+	p.AllowAttrs("href").OnElements("a")
+	p.RequireNoFollowOnLinks(!op.follow)
+	p.AddTargetBlankToFullyQualifiedLinks(op.targetBlank)
+	p.AllowAttrs("src", "alt").OnElements("img")
+	p.AllowAttrs("width", "height").Matching(bluemonday.NumberOrPercent).OnElements("img")
+
+	if op.imageProxy != nil {
+		p.RewriteSrc(op.imageProxy)
+	}
+
+	if preset == SanitizeMarkdown {
+		p.AllowTables()
+		p.AllowAttrs("class").Matching(codeLanguage).OnElements("code")
+		p.AllowAttrs("type").Matching(checkbox).OnElements("input")
+		p.AllowAttrs("checked", "disabled").Matching(checkboxAttr).OnElements("input")
+
+		return &Sanitizer{policy: p, checkboxes: true}
+	}
+
+	return &Sanitizer{policy: p}
+}
+
+// Sanitize returns text with everything the preset does not allow removed.
+func (s *Sanitizer) Sanitize(text string) string {
+	out := s.policy.Sanitize(text)
+
+	if s.checkboxes {
+		out = checkboxesOnly(out)
+	}
+
+	return out
+}
+
+// SanitizeReport is like Sanitize, but also reports which elements and
+// attributes were removed, ordered by element and attribute, such as for
+// logging attempts to inject script or for telling a user why their markup
+// changed. An element whose content is removed with it, like script and
+// style, is reported once, without what it contained.
+func (s *Sanitizer) SanitizeReport(text string) (string, []Removal) {
+	out := s.Sanitize(text)
+
+	before := countMarkup(text)
+	after := countMarkup(out)
+
+	var removed []Removal
+
+	for k, n := range before {
+		if d := n - after[k]; d > 0 {
+			removed = append(removed, Removal{Element: k.element, Attribute: k.attribute, Count: d})
+		}
+	}
+
+	slices.SortFunc(removed, func(a, b Removal) int {
+		if c := strings.Compare(a.Element, b.Element); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Attribute, b.Attribute)
+	})
+
+	return out, removed
+}
+
+// checkboxesOnly removes the input elements of sanitized HTML that are not
+// checkboxes, as an input without a type is a text field
+func checkboxesOnly(s string) string {
+	if !strings.Contains(s, "<input") {
+		return s
+	}
+
+	var b strings.Builder
+
+	z := html.NewTokenizer(strings.NewReader(s))
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return b.String()
+		}
+
+		raw := z.Raw()
+
+		if tt == html.StartTagToken || tt == html.SelfClosingTagToken {
+			t := z.Token()
+
+			if t.Data == "input" && !slices.ContainsFunc(t.Attr, func(a html.Attribute) bool {
+				return a.Key == "type" && checkbox.MatchString(a.Val)
+			}) {
+				continue
+			}
+		}
+
+		b.Write(raw)
+	}
+}
+
+// markupKey is an element, or an attribute of an element
+type markupKey struct {
+	element   string
+	attribute string
+}
+
+// countMarkup counts the start tags and their attributes in s
+func countMarkup(s string) map[markupKey]int {
+	counts := make(map[markupKey]int)
+	z := html.NewTokenizer(strings.NewReader(s))
+
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return counts
+		case html.StartTagToken, html.SelfClosingTagToken:
+			t := z.Token()
+			counts[markupKey{element: t.Data}]++
+
+			for _, a := range t.Attr {
+				counts[markupKey{element: t.Data, attribute: a.Key}]++
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package strings
+
+import (
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestSanitizer(t *testing.T) {
+	proxy := WithSanitizeImageProxy(func(u *url.URL) {
+		*u = url.URL{Scheme: "https", Host: "img.example.com", RawQuery: url.Values{"url": {u.String()}}.Encode()}
+	})
+
+	testStructs := []struct {
+		Preset   SanitizePreset
+		Options  []func(*SanitizeOption)
+		Input    string
+		Expected string
+	}{
+		{Preset: SanitizePlainText, Input: `<p>Hello <b>world</b></p>`, Expected: `Hello world`},
+		{Preset: SanitizeBasicFormatting, Input: `<p onclick="x()">Hello <b>world</b><script>alert(1)</script></p>`, Expected: `<p>Hello <b>world</b></p>`},
+		{Preset: SanitizeBasicFormatting, Input: `<a href="https://example.com">link</a><h1>Title</h1>`, Expected: `linkTitle`},
+		{Preset: SanitizeUGC, Input: `<h1>Title</h1><ul><li>one</li></ul>`, Expected: `<h1>Title</h1><ul><li>one</li></ul>`},
+		{Preset: SanitizeUGC, Input: `<a href="https://example.com">link</a>`, Expected: `<a href="https://example.com" rel="nofollow">link</a>`},
+		{Preset: SanitizeUGC, Input: `<a href="/about">link</a>`, Expected: `<a href="/about" rel="nofollow">link</a>`},
+		{Preset: SanitizeUGC, Input: `<a href="javascript:alert(1)">link</a>`, Expected: `link`},
+		{Preset: SanitizeUGC, Options: []func(*SanitizeOption){WithSanitizeTargetBlank()}, Input: `<a href="https://example.com">link</a>`, Expected: `<a href="https://example.com" rel="nofollow noopener" target="_blank">link</a>`},
+		{Preset: SanitizeUGC, Options: []func(*SanitizeOption){WithSanitizeFollowLinks()}, Input: `<a href="https://example.com">link</a>`, Expected: `<a href="https://example.com">link</a>`},
+		{Preset: SanitizeUGC, Options: []func(*SanitizeOption){WithSanitizeURLSchemes("https")}, Input: `<a href="http://example.com">a</a><a href="mailto:bob@example.com">b</a>`, Expected: `ab`},
+		{Preset: SanitizeUGC, Options: []func(*SanitizeOption){proxy}, Input: `<img src="http://tracker.example/pixel.gif" alt="x">`, Expected: `<img src="https://img.example.com?url=http%3A%2F%2Ftracker.example%2Fpixel.gif" alt="x">`},
+		{Preset: SanitizeUGC, Input: `<table><tr><td>cell</td></tr></table>`, Expected: `cell`},
+		{Preset: SanitizeMarkdown, Input: `<table><tr><td>cell</td></tr></table>`, Expected: `<table><tr><td>cell</td></tr></table>`},
+		{Preset: SanitizeMarkdown, Input: `<pre><code class="language-go">x := 1</code></pre>`, Expected: `<pre><code class="language-go">x := 1</code></pre>`},
+		{Preset: SanitizeMarkdown, Input: `<code class="evil">x</code>`, Expected: `<code>x</code>`},
+		{Preset: SanitizeMarkdown, Input: `<li><input checked="" disabled="" type="checkbox"> done</li>`, Expected: `<li><input checked="" disabled="" type="checkbox"> done</li>`},
+		{Preset: SanitizeMarkdown, Input: `<input name="q" value="x" form="f" formaction="/x" type="checkbox">`, Expected: `<input type="checkbox">`},
+		{Preset: SanitizeMarkdown, Input: `<input disabled type="text"><input disabled>x`, Expected: `x`},
+		{Preset: SanitizeMarkdown, Input: `<input type="hidden" name="a">x`, Expected: `x`},
+	}
+
+	for i, testStruct := range testStructs {
+		s := NewSanitizer(testStruct.Preset, testStruct.Options...)
+
+		if got := s.Sanitize(testStruct.Input); got != testStruct.Expected {
+			t.Errorf("Expected %s, got %s on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+}
+
+func TestSanitizeReport(t *testing.T) {
+	s := NewSanitizer(SanitizeUGC)
+
+	out, removed := s.SanitizeReport(`<p onclick="x()">Hi <a href="javascript:alert(1)">there</a></p><script>alert(1)</script><script>alert(2)</script><img src="/a.png" onerror="x()">`)
+
+	if expected := `<p>Hi there</p><img src="/a.png">`; out != expected {
+		t.Errorf("Expected %s, got %s", expected, out)
+	}
+
+	expected := []Removal{
+		{Element: "a", Count: 1},
+		{Element: "a", Attribute: "href", Count: 1},
+		{Element: "img", Attribute: "onerror", Count: 1},
+		{Element: "p", Attribute: "onclick", Count: 1},
+		{Element: "script", Count: 2},
+	}
+
+	if !slices.Equal(removed, expected) {
+		t.Errorf("Expected %v, got %v", expected, removed)
+	}
+
+	if _, removed := s.SanitizeReport(`<p>clean</p>`); len(removed) != 0 {
+		t.Errorf("Expected nothing removed, got %v", removed)
+	}
+
+	if got := (Removal{Element: "script", Count: 2}).String(); got != "script ×2" {
+		t.Errorf("Expected %s, got %s", "script ×2", got)
+	}
+
+	if got := (Removal{Element: "a", Attribute: "onclick", Count: 1}).String(); got != "a[onclick]" {
+		t.Errorf("Expected %s, got %s", "a[onclick]", got)
+	}
+}
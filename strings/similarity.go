@@ -0,0 +1,326 @@
+package strings
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Levenshtein returns the number of single rune insertions, deletions and
+// substitutions needed to turn a into b. It is case sensitive.
+//
+// This function operates on runes to remain Unicode safe.
+func Levenshtein(a, b string) int {
+	return levenshtein([]rune(a), []rune(b))
+}
+
+// levenshtein is Levenshtein on runes, keeping two rows of the distance
+// matrix
+func levenshtein(a, b []rune) int {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := range a {
+		cur[0] = i + 1
+
+		for j := range b {
+			cost := 1
+			if a[i] == b[j] {
+				cost = 0
+			}
+
+			cur[j+1] = min(prev[j+1]+1, cur[j]+1, prev[j]+cost)
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+// DamerauLevenshtein is like Levenshtein, but also counts swapping two
+// adjacent runes as a single edit, so "teh" is one edit from "the". Unlike
+// the restricted optimal string alignment distance, runes may be edited
+// again after being swapped, so "ca" is two edits from "abc".
+//
+// This function operates on runes to remain Unicode safe.
+func DamerauLevenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	inf := len(ra) + len(rb)
+
+	// d is offset by one row and column from the usual distance matrix, to
+	// hold the sentinel inf
+	d := make([][]int, len(ra)+2)
+	for i := range d {
+		d[i] = make([]int, len(rb)+2)
+	}
+
+	d[0][0] = inf
+	for i := 0; i <= len(ra); i++ {
+		d[i+1][0] = inf
+		d[i+1][1] = i
+	}
+
+	for j := 0; j <= len(rb); j++ {
+		d[0][j+1] = inf
+		d[1][j+1] = j
+	}
+
+	// last is the last row each rune was seen in a
+	last := make(map[rune]int)
+
+	for i := 1; i <= len(ra); i++ {
+		// lastMatch is the last column in this row where a and b matched
+		lastMatch := 0
+
+		for j := 1; j <= len(rb); j++ {
+			k := last[rb[j-1]]
+			l := lastMatch
+
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+				lastMatch = j
+			}
+
+			d[i+1][j+1] = min(
+				d[i][j]+cost,
+				d[i+1][j]+1,
+				d[i][j+1]+1,
+				d[k][l]+(i-k-1)+1+(j-l-1),
+			)
+		}
+
+		last[ra[i-1]] = i
+	}
+
+	return d[len(ra)+1][len(rb)+1]
+}
+
+// JaroWinkler returns the Jaro-Winkler similarity of a and b, from 0 for
+// nothing in common to 1 for equal strings. It suits short strings such as
+// names, favoring those with a common prefix of up to four runes when they
+// are already at least 0.7 similar. It is case sensitive.
+//
+// This function operates on runes to remain Unicode safe.
+func JaroWinkler(a, b string) float64 {
+	return jaroWinkler([]rune(a), []rune(b))
+}
+
+// jaroWinkler is JaroWinkler on runes
+func jaroWinkler(a, b []rune) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	window := max(0, max(len(a), len(b))/2-1)
+	matchedA := make([]bool, len(a))
+	matchedB := make([]bool, len(b))
+	matches := 0
+
+	for i := range a {
+		for j := max(0, i-window); j < min(len(b), i+window+1); j++ {
+			if !matchedB[j] && a[i] == b[j] {
+				matchedA[i] = true
+				matchedB[j] = true
+				matches++
+
+				break
+			}
+		}
+	}
+
+	if matches == 0 {
+		return 0
+	}
+
+	// count the matched runes that are in a different order
+	transpositions := 0
+	j := 0
+
+	for i := range a {
+		if !matchedA[i] {
+			continue
+		}
+
+		for !matchedB[j] {
+			j++
+		}
+
+		if a[i] != b[j] {
+			transpositions++
+		}
+
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(a)) + m/float64(len(b)) + (m-float64(transpositions/2))/m) / 3
+
+	if jaro < 0.7 {
+		return jaro
+	}
+
+	prefix := 0
+	for prefix < min(4, len(a), len(b)) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// trigram is three consecutive runes
+type trigram [3]rune
+
+// TrigramSimilarity returns how many of the trigrams, or three rune
+// sequences, of a and b they have in common, from 0 for none to 1 for all, as
+// PostgreSQL's pg_trgm does. Strings are lower cased and split into words at
+// anything that is not a letter or digit, and words are padded with spaces,
+// so word order matters little and "two words" is similar to "word".
+//
+// This function operates on runes to remain Unicode safe.
+func TrigramSimilarity(a, b string) float64 {
+	return trigramSimilarity(trigrams(a), trigrams(b))
+}
+
+// trigrams returns the sorted, distinct trigrams of the words of s
+func trigrams(s string) []trigram {
+	var ts []trigram
+
+	for _, word := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		rs := append([]rune("  "+word), ' ')
+
+		for i := 0; i+3 <= len(rs); i++ {
+			ts = append(ts, trigram(rs[i:i+3]))
+		}
+	}
+
+	slices.SortFunc(ts, compareTrigrams)
+
+	return slices.Compact(ts)
+}
+
+// compareTrigrams orders trigrams by their runes
+func compareTrigrams(a, b trigram) int {
+	for i := range a {
+		if a[i] != b[i] {
+			return int(a[i] - b[i])
+		}
+	}
+
+	return 0
+}
+
+// trigramSimilarity returns the Jaccard index of two sorted sets of trigrams
+func trigramSimilarity(a, b []trigram) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	common := 0
+
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch c := compareTrigrams(a[i], b[j]); {
+		case c == 0:
+			common++
+			i++
+			j++
+		case c < 0:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// Match is a candidate BestMatches found similar to the query.
+type Match struct {
+	Candidate string
+
+	// Index is the position of Candidate in the candidates.
+	Index int
+
+	// Score is from 0 to 1, as described by BestMatches.
+	Score float64
+}
+
+// BestMatches returns up to n of the candidates most similar to query, with a
+// score of at least threshold, best first, such as for "did you mean"
+// suggestions:
+//
+//	matches := strings.BestMatches("Untied States", countries, 3, 0.85)
+//
+// The score is the higher of the JaroWinkler and TrigramSimilarity of the
+// lower cased strings, so both typos and words in a different order match.
+// Candidates with the same score keep their order. Thresholds of 0.8 to 0.9
+// suit most uses. A threshold above 0 skips the Jaro-Winkler comparison of
+// candidates too different in length to reach it, so thousands of candidates
+// are compared in milliseconds.
+func BestMatches(query string, candidates []string, n int, threshold float64) []Match {
+	if n <= 0 {
+		return nil
+	}
+
+	lower := strings.ToLower(query)
+	q := []rune(lower)
+	qt := trigrams(lower)
+
+	var matches []Match
+
+	for i, c := range candidates {
+		lc := strings.ToLower(c)
+		score := trigramSimilarity(qt, trigrams(lc))
+
+		rc := []rune(lc)
+		if bound := jaroBound(len(q), len(rc)); bound >= threshold && bound > score {
+			score = max(score, jaroWinkler(q, rc))
+		}
+
+		if score >= threshold && score > 0 {
+			matches = append(matches, Match{Candidate: c, Index: i, Score: score})
+		}
+	}
+
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+
+		return 0
+	})
+
+	return matches[:min(n, len(matches))]
+}
+
+// jaroBound returns the highest Jaro-Winkler similarity strings of a and b
+// runes can have
+func jaroBound(a, b int) float64 {
+	if a == 0 || b == 0 {
+		// equal only when both are empty
+		return 1
+	}
+
+	short, long := float64(min(a, b)), float64(max(a, b))
+	jaro := (1 + short/long + 1) / 3
+
+	return jaro + 0.4*(1-jaro)
+}
@@ -0,0 +1,134 @@
+package strings
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	testStructs := []struct {
+		A, B     string
+		Expected int
+	}{
+		{A: "", B: "", Expected: 0},
+		{A: "", B: "abc", Expected: 3},
+		{A: "abc", B: "", Expected: 3},
+		{A: "kitten", B: "sitting", Expected: 3},
+		{A: "flaw", B: "lawn", Expected: 2},
+		{A: "the", B: "teh", Expected: 2},
+		{A: "ca", B: "abc", Expected: 3},
+		{A: "Köln", B: "Koln", Expected: 1},
+		{A: "日本語", B: "日本", Expected: 1},
+		{A: "Case", B: "case", Expected: 1},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := Levenshtein(testStruct.A, testStruct.B); got != testStruct.Expected {
+			t.Errorf("Expected %d, got %d on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+}
+
+func TestDamerauLevenshtein(t *testing.T) {
+	testStructs := []struct {
+		A, B     string
+		Expected int
+	}{
+		{A: "", B: "", Expected: 0},
+		{A: "", B: "abc", Expected: 3},
+		{A: "kitten", B: "sitting", Expected: 3},
+		{A: "the", B: "teh", Expected: 1},
+		{A: "ca", B: "abc", Expected: 2},
+		{A: "a cat", B: "an act", Expected: 2},
+		{A: "日本語", B: "本日語", Expected: 1},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := DamerauLevenshtein(testStruct.A, testStruct.B); got != testStruct.Expected {
+			t.Errorf("Expected %d, got %d on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	testStructs := []struct {
+		A, B     string
+		Expected float64
+	}{
+		{A: "", B: "", Expected: 1},
+		{A: "abc", B: "", Expected: 0},
+		{A: "abc", B: "abc", Expected: 1},
+		{A: "abc", B: "xyz", Expected: 0},
+		{A: "MARTHA", B: "MARHTA", Expected: 0.961},
+		{A: "DWAYNE", B: "DUANE", Expected: 0.84},
+		{A: "DIXON", B: "DICKSONX", Expected: 0.813},
+		{A: "Zürich", B: "Zurich", Expected: 0.9},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := JaroWinkler(testStruct.A, testStruct.B); math.Abs(got-testStruct.Expected) > 0.001 {
+			t.Errorf("Expected %.3f, got %.3f on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+}
+
+func TestTrigramSimilarity(t *testing.T) {
+	testStructs := []struct {
+		A, B     string
+		Expected float64
+	}{
+		{A: "", B: "", Expected: 0},
+		{A: "word", B: "word", Expected: 1},
+		{A: "Word", B: "word!", Expected: 1},
+		{A: "word", B: "two words", Expected: 4.0 / 11},
+		{A: "new york", B: "york new", Expected: 1},
+		{A: "abc", B: "xyz", Expected: 0},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := TrigramSimilarity(testStruct.A, testStruct.B); math.Abs(got-testStruct.Expected) > 0.001 {
+			t.Errorf("Expected %.3f, got %.3f on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+}
+
+func TestBestMatches(t *testing.T) {
+	countries := []string{"United States", "United Kingdom", "United Arab Emirates", "Germany", "France", "Österreich"}
+
+	testStructs := []struct {
+		Query     string
+		N         int
+		Threshold float64
+		Expected  []string
+	}{
+		{Query: "Untied States", N: 3, Threshold: 0.85, Expected: []string{"United States"}},
+		{Query: "united", N: 2, Threshold: 0.5, Expected: []string{"United States", "United Kingdom"}},
+		{Query: "germny", N: 3, Threshold: 0.8, Expected: []string{"Germany"}},
+		{Query: "GERMANY", N: 3, Threshold: 0.8, Expected: []string{"Germany"}},
+		{Query: "osterreich", N: 3, Threshold: 0.8, Expected: []string{"Österreich"}},
+		{Query: "Kingdom United", N: 1, Threshold: 0.8, Expected: []string{"United Kingdom"}},
+		{Query: "xyz", N: 3, Threshold: 0.7, Expected: nil},
+		{Query: "France", N: 0, Threshold: 0.7, Expected: nil},
+	}
+
+	for i, testStruct := range testStructs {
+		var got []string
+		for _, m := range BestMatches(testStruct.Query, countries, testStruct.N, testStruct.Threshold) {
+			got = append(got, m.Candidate)
+
+			if countries[m.Index] != m.Candidate {
+				t.Errorf("Expected index of %s, got %d on iteration %d", m.Candidate, m.Index, i)
+			}
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(testStruct.Expected) {
+			t.Errorf("Expected %v, got %v on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+
+	matches := BestMatches("France", countries, 1, 0)
+	if len(matches) != 1 || matches[0].Score != 1 {
+		t.Errorf("Expected France with score 1, got %v", matches)
+	}
+}
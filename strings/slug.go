@@ -0,0 +1,262 @@
+package strings
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrSlugTaken is returned by UniqueSlug when every suffix it tried exists.
+var ErrSlugTaken = errors.New("slug is taken")
+
+// ErrSlugTooLong is returned by UniqueSlug when WithSlugMaxLength leaves no
+// room for part of the slug and a suffix.
+var ErrSlugTooLong = errors.New("slug does not fit with a suffix")
+
+// maxSlugAttempts is how many slugs UniqueSlug tries, including the one
+// without a suffix
+const maxSlugAttempts = 100
+
+type SlugOption struct {
+	separator rune
+	maxLength int
+	keepCase  bool
+}
+
+// WithSlugSeparator sets the rune that separates words to '-', '_', '.' or
+// '~', the punctuation that needs no escaping in a URL. Any other rune is
+// ignored, so the default '-' is used.
+func WithSlugSeparator(separator rune) func(o *SlugOption) {
+	return func(o *SlugOption) {
+		if strings.ContainsRune("-_.~", separator) {
+			o.separator = separator
+		}
+	}
+}
+
+// WithSlugMaxLength limits slugs to length bytes, cutting them after the
+// last whole word that fits like TruncateWords does. Slugs are not limited by
+// default.
+func WithSlugMaxLength(length int) func(o *SlugOption) {
+	return func(o *SlugOption) {
+		o.maxLength = length
+	}
+}
+
+// WithSlugKeepCase keeps upper case letters instead of lower casing them.
+func WithSlugKeepCase() func(o *SlugOption) {
+	return func(o *SlugOption) {
+		o.keepCase = true
+	}
+}
+
+// slugOptions applies options to the defaults
+func slugOptions(options []func(*SlugOption)) SlugOption {
+	op := SlugOption{
+		separator: '-',
+	}
+
+	for _, o := range options {
+		o(&op)
+	}
+
+	return op
+}
+
+// Slugify returns a version of s, such as the title of an article or the
+// name of a product, that is safe to use in a URL path:
+//
+//	strings.Slugify("Crème Brûlée: A Beginner's Guide") // "creme-brulee-a-beginners-guide"
+//
+// Accented Latin letters lose their accents, letters such as "ß" and "æ"
+// become "ss" and "ae", and Greek and Cyrillic are transliterated, so
+// "Москва" becomes "moskva". Apostrophes are removed, and any other run of
+// characters that are not ASCII letters or digits, including scripts that
+// cannot be transliterated such as Chinese, becomes a single separator.
+// Separators are never leading or trailing.
+//
+// An empty string is returned when nothing usable is left, so callers should
+// substitute a slug of their own.
+func Slugify(s string, options ...func(*SlugOption)) string {
+	op := slugOptions(options)
+
+	var b strings.Builder
+	space := false
+
+	for _, r := range s {
+		if r == '\'' || r == '’' || unicode.In(r, unicode.Mn, unicode.Me) {
+			continue
+		}
+
+		t, ok := slugTransliterations[r]
+		if !ok {
+			t = string(r)
+		}
+
+		for _, c := range t {
+			if !isSlugRune(c) {
+				space = true
+				continue
+			}
+
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+
+			space = false
+
+			if !op.keepCase {
+				c = unicode.ToLower(c)
+			}
+
+			b.WriteRune(c)
+		}
+	}
+
+	return op.finish(b.String())
+}
+
+// finish limits the length of words separated by spaces and joins them with
+// the separator
+func (o SlugOption) finish(s string) string {
+	if o.maxLength > 0 {
+		s = TruncateWords(s, o.maxLength, "")
+	}
+
+	return strings.ReplaceAll(s, " ", string(o.separator))
+}
+
+// UniqueSlug returns slug, as made by Slugify with the same options, if
+// exists reports that it is not in use yet. Otherwise it appends a number,
+// such as "my-post-2", trying numbers up to 99 and shortening slug to keep
+// within WithSlugMaxLength. It returns ErrSlugTaken if all of them exist,
+// ErrSlugTooLong if the maximum length cannot fit a suffix after at least
+// one character of slug, or the first error from exists:
+//
+//	slug, err := strings.UniqueSlug(strings.Slugify(title), func(s string) (bool, error) {
+//		return db.PostSlugExists(ctx, s)
+//	})
+func UniqueSlug(slug string, exists func(slug string) (bool, error), options ...func(*SlugOption)) (string, error) {
+	op := slugOptions(options)
+	words := strings.ReplaceAll(slug, string(op.separator), " ")
+
+	for i := 1; i <= maxSlugAttempts; i++ {
+		candidate := slug
+
+		if i > 1 {
+			suffix := strconv.Itoa(i)
+
+			base := words
+			if op.maxLength > 0 {
+				room := op.maxLength - len(suffix) - 1
+				if room < 1 {
+					return "", ErrSlugTooLong
+				}
+
+				base = TruncateWords(words, room, "")
+			}
+
+			candidate = op.finish(strings.TrimSpace(base + " " + suffix))
+		}
+
+		taken, err := exists(candidate)
+		if err != nil {
+			return "", err
+		}
+
+		if !taken {
+			return candidate, nil
+		}
+	}
+
+	return "", ErrSlugTaken
+}
+
+// isSlugRune reports whether r may appear in a slug word
+func isSlugRune(r rune) bool {
+	return r < 0x80 && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// slugTransliterations are the ASCII spellings of Latin letters with
+// diacritics, Greek and Cyrillic letters
+var slugTransliterations = map[rune]string{
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Æ': "AE", 'Ç': "C", 'È': "E",
+	'É': "E", 'Ê': "E", 'Ë': "E", 'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I", 'Ð': "D", 'Ñ': "N",
+	'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "O", 'Ø': "O", 'Ù': "U", 'Ú': "U", 'Û': "U",
+	'Ü': "U", 'Ý': "Y", 'Þ': "TH", 'ß': "ss", 'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a",
+	'å': "a", 'æ': "ae", 'ç': "c", 'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ì': "i", 'í': "i",
+	'î': "i", 'ï': "i", 'ð': "d", 'ñ': "n", 'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o",
+	'ø': "o", 'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'þ': "th", 'ÿ': "y", 'Ā': "A",
+	'ā': "a", 'Ă': "A", 'ă': "a", 'Ą': "A", 'ą': "a", 'Ć': "C", 'ć': "c", 'Ĉ': "C", 'ĉ': "c",
+	'Ċ': "C", 'ċ': "c", 'Č': "C", 'č': "c", 'Ď': "D", 'ď': "d", 'Đ': "D", 'đ': "d", 'Ē': "E",
+	'ē': "e", 'Ĕ': "E", 'ĕ': "e", 'Ė': "E", 'ė': "e", 'Ę': "E", 'ę': "e", 'Ě': "E", 'ě': "e",
+	'Ĝ': "G", 'ĝ': "g", 'Ğ': "G", 'ğ': "g", 'Ġ': "G", 'ġ': "g", 'Ģ': "G", 'ģ': "g", 'Ĥ': "H",
+	'ĥ': "h", 'Ħ': "H", 'ħ': "h", 'Ĩ': "I", 'ĩ': "i", 'Ī': "I", 'ī': "i", 'Ĭ': "I", 'ĭ': "i",
+	'Į': "I", 'į': "i", 'İ': "I", 'ı': "i", 'Ĳ': "IJ", 'ĳ': "ij", 'Ĵ': "J", 'ĵ': "j", 'Ķ': "K",
+	'ķ': "k", 'ĸ': "q", 'Ĺ': "L", 'ĺ': "l", 'Ļ': "L", 'ļ': "l", 'Ľ': "L", 'ľ': "l", 'Ł': "L",
+	'ł': "l", 'Ń': "N", 'ń': "n", 'Ņ': "N", 'ņ': "n", 'Ň': "N", 'ň': "n", 'Ŋ': "NG", 'ŋ': "ng",
+	'Ō': "O", 'ō': "o", 'Ŏ': "O", 'ŏ': "o", 'Ő': "O", 'ő': "o", 'Œ': "OE", 'œ': "oe", 'Ŕ': "R",
+	'ŕ': "r", 'Ŗ': "R", 'ŗ': "r", 'Ř': "R", 'ř': "r", 'Ś': "S", 'ś': "s", 'Ŝ': "S", 'ŝ': "s",
+	'Ş': "S", 'ş': "s", 'Š': "S", 'š': "s", 'Ţ': "T", 'ţ': "t", 'Ť': "T", 'ť': "t", 'Ŧ': "T",
+	'ŧ': "t", 'Ũ': "U", 'ũ': "u", 'Ū': "U", 'ū': "u", 'Ŭ': "U", 'ŭ': "u", 'Ů': "U", 'ů': "u",
+	'Ű': "U", 'ű': "u", 'Ų': "U", 'ų': "u", 'Ŵ': "W", 'ŵ': "w", 'Ŷ': "Y", 'ŷ': "y", 'Ÿ': "Y",
+	'Ź': "Z", 'ź': "z", 'Ż': "Z", 'ż': "z", 'Ž': "Z", 'ž': "z", 'ſ': "s", 'Ɓ': "B", 'Ɗ': "D",
+	'Ǝ': "E", 'Ə': "E", 'ƒ': "f", 'Ƙ': "K", 'ƙ': "k", 'Ơ': "O", 'ơ': "o", 'Ư': "U", 'ư': "u",
+	'Ƴ': "Y", 'ƴ': "y", 'Ǆ': "DZ", 'ǅ': "Dz", 'ǆ': "dz", 'Ǉ': "LJ", 'ǈ': "Lj", 'ǉ': "lj", 'Ǌ': "NJ",
+	'ǋ': "Nj", 'ǌ': "nj", 'Ǎ': "A", 'ǎ': "a", 'Ǐ': "I", 'ǐ': "i", 'Ǒ': "O", 'ǒ': "o", 'Ǔ': "U",
+	'ǔ': "u", 'Ǖ': "U", 'ǖ': "u", 'Ǘ': "U", 'ǘ': "u", 'Ǚ': "U", 'ǚ': "u", 'Ǜ': "U", 'ǜ': "u",
+	'ǝ': "e", 'Ǟ': "A", 'ǟ': "a", 'Ǡ': "A", 'ǡ': "a", 'Ǧ': "G", 'ǧ': "g", 'Ǩ': "K", 'ǩ': "k",
+	'Ǫ': "O", 'ǫ': "o", 'Ǭ': "O", 'ǭ': "o", 'ǰ': "j", 'Ǳ': "DZ", 'ǲ': "Dz", 'ǳ': "dz", 'Ǵ': "G",
+	'ǵ': "g", 'Ǹ': "N", 'ǹ': "n", 'Ǻ': "A", 'ǻ': "a", 'Ȁ': "A", 'ȁ': "a", 'Ȃ': "A", 'ȃ': "a",
+	'Ȅ': "E", 'ȅ': "e", 'Ȇ': "E", 'ȇ': "e", 'Ȉ': "I", 'ȉ': "i", 'Ȋ': "I", 'ȋ': "i", 'Ȍ': "O",
+	'ȍ': "o", 'Ȏ': "O", 'ȏ': "o", 'Ȑ': "R", 'ȑ': "r", 'Ȓ': "R", 'ȓ': "r", 'Ȕ': "U", 'ȕ': "u",
+	'Ȗ': "U", 'ȗ': "u", 'Ș': "S", 'ș': "s", 'Ț': "T", 'ț': "t", 'Ȟ': "H", 'ȟ': "h", 'Ȧ': "A",
+	'ȧ': "a", 'Ȩ': "E", 'ȩ': "e", 'Ȫ': "O", 'ȫ': "o", 'Ȭ': "O", 'ȭ': "o", 'Ȯ': "O", 'ȯ': "o",
+	'Ȱ': "O", 'ȱ': "o", 'Ȳ': "Y", 'ȳ': "y", 'Ά': "A", 'Έ': "E", 'Ή': "I", 'Ί': "I", 'Ό': "O",
+	'Ύ': "Y", 'Ώ': "O", 'ΐ': "i", 'Α': "A", 'Β': "V", 'Γ': "G", 'Δ': "D", 'Ε': "E", 'Ζ': "Z",
+	'Η': "I", 'Θ': "Th", 'Ι': "I", 'Κ': "K", 'Λ': "L", 'Μ': "M", 'Ν': "N", 'Ξ': "X", 'Ο': "O",
+	'Π': "P", 'Ρ': "R", 'Σ': "S", 'Τ': "T", 'Υ': "Y", 'Φ': "F", 'Χ': "Ch", 'Ψ': "Ps", 'Ω': "O",
+	'Ϊ': "I", 'Ϋ': "Y", 'ά': "a", 'έ': "e", 'ή': "i", 'ί': "i", 'ΰ': "y", 'α': "a", 'β': "v",
+	'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l",
+	'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'ς': "s", 'σ': "s", 'τ': "t",
+	'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o", 'ϊ': "i", 'ϋ': "y", 'ό': "o", 'ύ': "y",
+	'ώ': "o", 'ϴ': "Th", 'Ё': "Yo", 'Ђ': "Dj", 'Ѓ': "G", 'Є': "Ye", 'Ѕ': "Dz", 'І': "I", 'Ї': "Yi",
+	'Ј': "J", 'Љ': "Lj", 'Њ': "Nj", 'Ћ': "C", 'Ќ': "K", 'Ў': "U", 'Џ': "Dz", 'А': "A", 'Б': "B",
+	'В': "V", 'Г': "G", 'Д': "D", 'Е': "E", 'Ж': "Zh", 'З': "Z", 'И': "I", 'Й': "Y", 'К': "K",
+	'Л': "L", 'М': "M", 'Н': "N", 'О': "O", 'П': "P", 'Р': "R", 'С': "S", 'Т': "T", 'У': "U",
+	'Ф': "F", 'Х': "Kh", 'Ц': "Ts", 'Ч': "Ch", 'Ш': "Sh", 'Щ': "Shch", 'Ъ': "", 'Ы': "Y", 'Ь': "",
+	'Э': "E", 'Ю': "Yu", 'Я': "Ya", 'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch",
+	'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya", 'ё': "yo",
+	'ђ': "dj", 'ѓ': "g", 'є': "ye", 'ѕ': "dz", 'і': "i", 'ї': "yi", 'ј': "j", 'љ': "lj", 'њ': "nj",
+	'ћ': "c", 'ќ': "k", 'ў': "u", 'џ': "dz", 'Ґ': "G", 'ґ': "g", 'Ḁ': "A", 'ḁ': "a", 'Ḃ': "B",
+	'ḃ': "b", 'Ḅ': "B", 'ḅ': "b", 'Ḇ': "B", 'ḇ': "b", 'Ḉ': "C", 'ḉ': "c", 'Ḋ': "D", 'ḋ': "d",
+	'Ḍ': "D", 'ḍ': "d", 'Ḏ': "D", 'ḏ': "d", 'Ḑ': "D", 'ḑ': "d", 'Ḓ': "D", 'ḓ': "d", 'Ḕ': "E",
+	'ḕ': "e", 'Ḗ': "E", 'ḗ': "e", 'Ḙ': "E", 'ḙ': "e", 'Ḛ': "E", 'ḛ': "e", 'Ḝ': "E", 'ḝ': "e",
+	'Ḟ': "F", 'ḟ': "f", 'Ḡ': "G", 'ḡ': "g", 'Ḣ': "H", 'ḣ': "h", 'Ḥ': "H", 'ḥ': "h", 'Ḧ': "H",
+	'ḧ': "h", 'Ḩ': "H", 'ḩ': "h", 'Ḫ': "H", 'ḫ': "h", 'Ḭ': "I", 'ḭ': "i", 'Ḯ': "I", 'ḯ': "i",
+	'Ḱ': "K", 'ḱ': "k", 'Ḳ': "K", 'ḳ': "k", 'Ḵ': "K", 'ḵ': "k", 'Ḷ': "L", 'ḷ': "l", 'Ḹ': "L",
+	'ḹ': "l", 'Ḻ': "L", 'ḻ': "l", 'Ḽ': "L", 'ḽ': "l", 'Ḿ': "M", 'ḿ': "m", 'Ṁ': "M", 'ṁ': "m",
+	'Ṃ': "M", 'ṃ': "m", 'Ṅ': "N", 'ṅ': "n", 'Ṇ': "N", 'ṇ': "n", 'Ṉ': "N", 'ṉ': "n", 'Ṋ': "N",
+	'ṋ': "n", 'Ṍ': "O", 'ṍ': "o", 'Ṏ': "O", 'ṏ': "o", 'Ṑ': "O", 'ṑ': "o", 'Ṓ': "O", 'ṓ': "o",
+	'Ṕ': "P", 'ṕ': "p", 'Ṗ': "P", 'ṗ': "p", 'Ṙ': "R", 'ṙ': "r", 'Ṛ': "R", 'ṛ': "r", 'Ṝ': "R",
+	'ṝ': "r", 'Ṟ': "R", 'ṟ': "r", 'Ṡ': "S", 'ṡ': "s", 'Ṣ': "S", 'ṣ': "s", 'Ṥ': "S", 'ṥ': "s",
+	'Ṧ': "S", 'ṧ': "s", 'Ṩ': "S", 'ṩ': "s", 'Ṫ': "T", 'ṫ': "t", 'Ṭ': "T", 'ṭ': "t", 'Ṯ': "T",
+	'ṯ': "t", 'Ṱ': "T", 'ṱ': "t", 'Ṳ': "U", 'ṳ': "u", 'Ṵ': "U", 'ṵ': "u", 'Ṷ': "U", 'ṷ': "u",
+	'Ṹ': "U", 'ṹ': "u", 'Ṻ': "U", 'ṻ': "u", 'Ṽ': "V", 'ṽ': "v", 'Ṿ': "V", 'ṿ': "v", 'Ẁ': "W",
+	'ẁ': "w", 'Ẃ': "W", 'ẃ': "w", 'Ẅ': "W", 'ẅ': "w", 'Ẇ': "W", 'ẇ': "w", 'Ẉ': "W", 'ẉ': "w",
+	'Ẋ': "X", 'ẋ': "x", 'Ẍ': "X", 'ẍ': "x", 'Ẏ': "Y", 'ẏ': "y", 'Ẑ': "Z", 'ẑ': "z", 'Ẓ': "Z",
+	'ẓ': "z", 'Ẕ': "Z", 'ẕ': "z", 'ẖ': "h", 'ẗ': "t", 'ẘ': "w", 'ẙ': "y", 'ẛ': "s", 'ẞ': "SS",
+	'Ạ': "A", 'ạ': "a", 'Ả': "A", 'ả': "a", 'Ấ': "A", 'ấ': "a", 'Ầ': "A", 'ầ': "a", 'Ẩ': "A",
+	'ẩ': "a", 'Ẫ': "A", 'ẫ': "a", 'Ậ': "A", 'ậ': "a", 'Ắ': "A", 'ắ': "a", 'Ằ': "A", 'ằ': "a",
+	'Ẳ': "A", 'ẳ': "a", 'Ẵ': "A", 'ẵ': "a", 'Ặ': "A", 'ặ': "a", 'Ẹ': "E", 'ẹ': "e", 'Ẻ': "E",
+	'ẻ': "e", 'Ẽ': "E", 'ẽ': "e", 'Ế': "E", 'ế': "e", 'Ề': "E", 'ề': "e", 'Ể': "E", 'ể': "e",
+	'Ễ': "E", 'ễ': "e", 'Ệ': "E", 'ệ': "e", 'Ỉ': "I", 'ỉ': "i", 'Ị': "I", 'ị': "i", 'Ọ': "O",
+	'ọ': "o", 'Ỏ': "O", 'ỏ': "o", 'Ố': "O", 'ố': "o", 'Ồ': "O", 'ồ': "o", 'Ổ': "O", 'ổ': "o",
+	'Ỗ': "O", 'ỗ': "o", 'Ộ': "O", 'ộ': "o", 'Ớ': "O", 'ớ': "o", 'Ờ': "O", 'ờ': "o", 'Ở': "O",
+	'ở': "o", 'Ỡ': "O", 'ỡ': "o", 'Ợ': "O", 'ợ': "o", 'Ụ': "U", 'ụ': "u", 'Ủ': "U", 'ủ': "u",
+	'Ứ': "U", 'ứ': "u", 'Ừ': "U", 'ừ': "u", 'Ử': "U", 'ử': "u", 'Ữ': "U", 'ữ': "u", 'Ự': "U",
+	'ự': "u", 'Ỳ': "Y", 'ỳ': "y", 'Ỵ': "Y", 'ỵ': "y", 'Ỷ': "Y", 'ỷ': "y", 'Ỹ': "Y", 'ỹ': "y",
+}
@@ -0,0 +1,87 @@
+package strings
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	testStructs := []struct {
+		Options  []func(*SlugOption)
+		Input    string
+		Expected string
+	}{
+		{Input: "", Expected: ""},
+		{Input: "Hello, World!", Expected: "hello-world"},
+		{Input: "  --Hello   World--  ", Expected: "hello-world"},
+		{Input: "Crème Brûlée: A Beginner's Guide", Expected: "creme-brulee-a-beginners-guide"},
+		{Input: "Straße in Łódź", Expected: "strasse-in-lodz"},
+		{Input: "Ærøskøbing Œuvre", Expected: "aeroskobing-oeuvre"},
+		{Input: "Tiếng Việt", Expected: "tieng-viet"},
+		{Input: "café", Expected: "cafe"},
+		{Input: "Москва, Україна", Expected: "moskva-ukrayina"},
+		{Input: "Αθήνα", Expected: "athina"},
+		{Input: "東京 Tower 2024", Expected: "tower-2024"},
+		{Input: "東京", Expected: ""},
+		{Input: "C++ & Go", Expected: "c-go"},
+		{Options: []func(*SlugOption){WithSlugSeparator('_')}, Input: "Hello World", Expected: "hello_world"},
+		{Options: []func(*SlugOption){WithSlugSeparator('~')}, Input: "Hello World", Expected: "hello~world"},
+		{Options: []func(*SlugOption){WithSlugSeparator('/')}, Input: "Hello World", Expected: "hello-world"},
+		{Options: []func(*SlugOption){WithSlugSeparator('—'), WithSlugMaxLength(9)}, Input: "Hello big world", Expected: "hello-big"},
+		{Options: []func(*SlugOption){WithSlugSeparator('_'), WithSlugMaxLength(9)}, Input: "Hello big world", Expected: "hello_big"},
+		{Options: []func(*SlugOption){WithSlugKeepCase()}, Input: "Hello Wörld", Expected: "Hello-World"},
+		{Options: []func(*SlugOption){WithSlugMaxLength(14)}, Input: "The quick brown fox", Expected: "the-quick"},
+		{Options: []func(*SlugOption){WithSlugMaxLength(15)}, Input: "The quick brown fox", Expected: "the-quick-brown"},
+		{Options: []func(*SlugOption){WithSlugMaxLength(5)}, Input: "Supercalifragilistic", Expected: "super"},
+		{Options: []func(*SlugOption){WithSlugMaxLength(64)}, Input: "Short", Expected: "short"},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := Slugify(testStruct.Input, testStruct.Options...); got != testStruct.Expected {
+			t.Errorf("Expected %s, got %s on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+}
+
+func TestUniqueSlug(t *testing.T) {
+	taken := map[string]bool{"my-post": true, "my-post-2": true, "the-quick-2": true}
+	exists := func(s string) (bool, error) {
+		return taken[s], nil
+	}
+
+	testStructs := []struct {
+		Options  []func(*SlugOption)
+		Input    string
+		Expected string
+	}{
+		{Input: "new-post", Expected: "new-post"},
+		{Input: "my-post", Expected: "my-post-3"},
+		{Options: []func(*SlugOption){WithSlugMaxLength(11)}, Input: "the-quick", Expected: "the-quick"},
+		{Options: []func(*SlugOption){WithSlugMaxLength(11)}, Input: "my-post", Expected: "my-post-3"},
+		{Options: []func(*SlugOption){WithSlugMaxLength(9)}, Input: "my-post", Expected: "my-post-3"},
+		{Options: []func(*SlugOption){WithSlugMaxLength(8)}, Input: "my-post", Expected: "my-2"},
+		{Options: []func(*SlugOption){WithSlugMaxLength(4)}, Input: "my-post", Expected: "my-2"},
+		{Options: []func(*SlugOption){WithSlugMaxLength(3)}, Input: "my-post", Expected: "m-2"},
+		{Options: []func(*SlugOption){WithSlugSeparator('_'), WithSlugMaxLength(8)}, Input: "my_post", Expected: "my_post"},
+	}
+
+	for i, testStruct := range testStructs {
+		got, err := UniqueSlug(testStruct.Input, exists, testStruct.Options...)
+		if err != nil || got != testStruct.Expected {
+			t.Errorf("Expected %s, got %s (%v) on iteration %d", testStruct.Expected, got, err, i)
+		}
+	}
+
+	if _, err := UniqueSlug("x", func(string) (bool, error) { return true, nil }); !errors.Is(err, ErrSlugTaken) {
+		t.Errorf("Expected %v, got %v", ErrSlugTaken, err)
+	}
+
+	if _, err := UniqueSlug("a-b", func(string) (bool, error) { return true, nil }, WithSlugMaxLength(2)); !errors.Is(err, ErrSlugTooLong) {
+		t.Errorf("Expected %v, got %v", ErrSlugTooLong, err)
+	}
+
+	failed := errors.New("database is down")
+	if _, err := UniqueSlug("x", func(string) (bool, error) { return false, failed }); !errors.Is(err, failed) {
+		t.Errorf("Expected %v, got %v", failed, err)
+	}
+}
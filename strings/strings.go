@@ -251,6 +251,9 @@ func TruncateLeft(text string, length int, ellipsis string) string {
 //	'yes',
 //	'yessir',
 //	'yup'
+//
+// To also recognize negative answers, punctuation, emoji and other languages,
+// use ParseAnswer.
 func IsAffirmative(s string) bool {
 	switch strings.ToLower(s) {
 	case "absolutely":
@@ -0,0 +1,151 @@
+package strings
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TruncateMiddle will truncate a string, keeping the beginning and the end
+// of it, and return a new string that is exactly the "length" of runes
+// provided, with the middle part of the string that was removed now being
+// the provided "ellipsis", such as "/usr/loc…/bin/app". When the kept runes
+// cannot be split evenly, the beginning keeps the extra one. If "length" is
+// greater than or equal to the length of "text" (in runes), then "text" is
+// returned as is. If "length" is less than or equal to the length of
+// "ellipsis", then only "length" runes of "ellipsis" is returned.
+//
+// This function operates on runes to remain Unicode safe.
+func TruncateMiddle(text string, length int, ellipsis string) string {
+	rs, avail, s, done := truncateBounds(text, length, ellipsis)
+	if done {
+		return s
+	}
+
+	head := (avail + 1) / 2
+	tail := avail - head
+
+	return string(rs[:head]) + ellipsis + string(rs[len(rs)-tail:])
+}
+
+// TruncateWords will truncate a string, keeping the string starting from the
+// beginning, and return a new string that is at most the "length" of runes
+// provided, ending in the provided "ellipsis". Unlike TruncateRight, the
+// string is cut at the end of the last whole word that fits, and spaces and
+// punctuation before the cut are removed, so "Hello, big world" truncated to
+// 12 runes with "…" becomes "Hello, big…". A first word longer than "length"
+// is cut like TruncateRight does. If "length" is greater than or equal to
+// the length of "text" (in runes), then "text" is returned as is. If
+// "length" is less than or equal to the length of "ellipsis", then only
+// "length" runes of "ellipsis" is returned.
+//
+// Words are separated by white space, so text in languages written without
+// spaces, such as Chinese or Japanese, is cut like TruncateRight does.
+//
+// This function operates on runes to remain Unicode safe.
+func TruncateWords(text string, length int, ellipsis string) string {
+	rs, avail, s, done := truncateBounds(text, length, ellipsis)
+	if done {
+		return s
+	}
+
+	return string(rs[:wordCut(rs, avail)]) + ellipsis
+}
+
+// TruncateSentences will truncate a string, keeping the string starting from
+// the beginning, and return a new string that is at most the "length" of
+// runes provided, ending in the provided "ellipsis". The string is cut after
+// the last whole sentence that fits, keeping its final punctuation, so pass
+// an ellipsis starting with a space, such as " …", to separate the two. When
+// not even the first sentence fits, the string is cut like TruncateWords
+// does. If "length" is greater than or equal to the length of "text" (in
+// runes), then "text" is returned as is. If "length" is less than or equal
+// to the length of "ellipsis", then only "length" runes of "ellipsis" is
+// returned.
+//
+// A sentence ends with ".", "!", "?" or "…", optionally followed by closing
+// quotes or brackets, and then white space, or with the ideographic
+// full stop "。" and its full width relatives. Abbreviations such as "Dr."
+// are not recognized.
+//
+// This function operates on runes to remain Unicode safe.
+func TruncateSentences(text string, length int, ellipsis string) string {
+	rs, avail, s, done := truncateBounds(text, length, ellipsis)
+	if done {
+		return s
+	}
+
+	for i := avail; i > 0; i-- {
+		if sentenceEnd(rs, i) {
+			return string(rs[:i]) + ellipsis
+		}
+	}
+
+	return string(rs[:wordCut(rs, avail)]) + ellipsis
+}
+
+// truncateBounds returns the runes of text and how many of them can be kept
+// beside the ellipsis. When there is nothing to truncate it instead returns
+// the result and true: text itself when it fits in length runes, or as much
+// of ellipsis as fits.
+func truncateBounds(text string, length int, ellipsis string) ([]rune, int, string, bool) {
+	rs := []rune(text)
+
+	// bound length to minimum zero and maximum len(rs)
+	l := max(0, min(length, len(rs)))
+
+	// if we are keeping the whole text, go ahead and return it
+	if len(rs) <= l {
+		return rs, 0, string(rs), true
+	}
+
+	es := []rune(ellipsis)
+	if l <= len(es) {
+		return rs, 0, string(es[:l]), true
+	}
+
+	return rs, l - len(es), "", false
+}
+
+// wordCut returns where to cut rs to keep at most n runes, which must be
+// fewer than len(rs), without splitting a word
+func wordCut(rs []rune, n int) int {
+	i := n
+
+	// unless n is just before a space, back up to the start of the word
+	if !unicode.IsSpace(rs[n]) {
+		for i > 0 && !unicode.IsSpace(rs[i-1]) {
+			i--
+		}
+	}
+
+	for i > 0 && (unicode.IsSpace(rs[i-1]) || unicode.IsPunct(rs[i-1])) {
+		i--
+	}
+
+	// the first word does not fit
+	if i == 0 {
+		return n
+	}
+
+	return i
+}
+
+// sentenceEnd reports whether a sentence ends just before rs[i], which must
+// exist
+func sentenceEnd(rs []rune, i int) bool {
+	if strings.ContainsRune("。！？", rs[i-1]) {
+		return true
+	}
+
+	if !unicode.IsSpace(rs[i]) {
+		return false
+	}
+
+	// skip closing quotes and brackets
+	j := i
+	for j > 0 && (unicode.In(rs[j-1], unicode.Pe, unicode.Pf) || rs[j-1] == '"' || rs[j-1] == '\'') {
+		j--
+	}
+
+	return j > 0 && strings.ContainsRune(".!?…", rs[j-1])
+}
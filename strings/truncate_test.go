@@ -0,0 +1,58 @@
+package strings
+
+import "testing"
+
+func TestTruncateMiddle(t *testing.T) {
+	testTruncate(t, TruncateMiddle, -1, "Hi there!", "…", "")
+	testTruncate(t, TruncateMiddle, 0, "Hi there!", "…", "")
+	testTruncate(t, TruncateMiddle, 1, "Hi there!", "…", "…")
+	testTruncate(t, TruncateMiddle, 2, "Hi there!", "…", "H…")
+	testTruncate(t, TruncateMiddle, 3, "Hi there!", "…", "H…!")
+	testTruncate(t, TruncateMiddle, 4, "Hi there!", "…", "Hi…!")
+	testTruncate(t, TruncateMiddle, 5, "Hi there!", "…", "Hi…e!")
+	testTruncate(t, TruncateMiddle, 8, "Hi there!", "…", "Hi t…re!")
+	testTruncate(t, TruncateMiddle, 9, "Hi there!", "…", "Hi there!")
+	testTruncate(t, TruncateMiddle, 64, "Hi there!", "…", "Hi there!")
+
+	testTruncate(t, TruncateMiddle, 2, "Hi there!", "...", "..")
+	testTruncate(t, TruncateMiddle, 3, "Hi there!", "...", "...")
+	testTruncate(t, TruncateMiddle, 6, "Hi there!", "...", "Hi...!")
+
+	testTruncate(t, TruncateMiddle, 17, "/usr/local/lib/myapp/bin/app", "…", "/usr/loc…/bin/app")
+	testTruncate(t, TruncateMiddle, 7, "⌘日本語世界⌘日本語", "…", "⌘日本…日本語")
+}
+
+func TestTruncateWords(t *testing.T) {
+	testTruncate(t, TruncateWords, -1, "Hello, big world", "…", "")
+	testTruncate(t, TruncateWords, 0, "Hello, big world", "…", "")
+	testTruncate(t, TruncateWords, 1, "Hello, big world", "…", "…")
+	testTruncate(t, TruncateWords, 2, "Hello, big world", "...", "..")
+	testTruncate(t, TruncateWords, 4, "Hello, big world", "…", "Hel…")
+	testTruncate(t, TruncateWords, 7, "Hello, big world", "…", "Hello…")
+	testTruncate(t, TruncateWords, 11, "Hello, big world", "…", "Hello, big…")
+	testTruncate(t, TruncateWords, 12, "Hello, big world", "…", "Hello, big…")
+	testTruncate(t, TruncateWords, 15, "Hello, big world", "…", "Hello, big…")
+	testTruncate(t, TruncateWords, 15, "Hello, big world", " …", "Hello, big …")
+	testTruncate(t, TruncateWords, 16, "Hello, big world", "…", "Hello, big world")
+	testTruncate(t, TruncateWords, 64, "Hello, big world", "…", "Hello, big world")
+
+	testTruncate(t, TruncateWords, 9, "Grüße aus Köln", "…", "Grüße…")
+	testTruncate(t, TruncateWords, 5, "日本語のテキスト", "…", "日本語の…")
+}
+
+func TestTruncateSentences(t *testing.T) {
+	text := `It works. Does it? "Yes," it said. Then it stopped`
+
+	testTruncate(t, TruncateSentences, 0, text, "…", "")
+	testTruncate(t, TruncateSentences, 1, text, "…", "…")
+	testTruncate(t, TruncateSentences, 6, text, "…", "It…")
+	testTruncate(t, TruncateSentences, 11, text, " …", "It works. …")
+	testTruncate(t, TruncateSentences, 18, text, " …", "It works. …")
+	testTruncate(t, TruncateSentences, 20, text, " …", "It works. Does it? …")
+	testTruncate(t, TruncateSentences, 27, text, " …", `It works. Does it? …`)
+	testTruncate(t, TruncateSentences, 36, text, " …", `It works. Does it? "Yes," it said. …`)
+	testTruncate(t, TruncateSentences, 64, text, " …", text)
+
+	testTruncate(t, TruncateSentences, 18, `She said "stop." Then left`, " …", `She said "stop." …`)
+	testTruncate(t, TruncateSentences, 8, "これは文です。次の文です。", "…", "これは文です。…")
+}
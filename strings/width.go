@@ -0,0 +1,281 @@
+package strings
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// eaWidth is the East_Asian_Width of a code point, for the widths that are
+// not a single column
+type eaWidth uint8
+
+const (
+	eaNarrow eaWidth = iota
+	eaWide
+	eaAmbiguous
+)
+
+// widthRange gives the East_Asian_Width of the code points lo through hi
+type widthRange struct {
+	lo, hi rune
+	width  eaWidth
+}
+
+// side is where text is removed or padding is added
+type side int
+
+const (
+	sideRight side = iota
+	sideLeft
+	sideMiddle
+)
+
+type WidthOption struct {
+	truncate      side
+	pad           side
+	ambiguousWide bool
+}
+
+// WithWidthAmbiguousWide counts characters of ambiguous width, such as Greek
+// and Cyrillic letters, "…" and many symbols, as two columns, as terminals set
+// up for Chinese, Japanese or Korean do. They count as one column by default.
+func WithWidthAmbiguousWide() func(o *WidthOption) {
+	return func(o *WidthOption) {
+		o.ambiguousWide = true
+	}
+}
+
+// WithWidthTruncateLeft makes TruncateWidth keep the end of the text, like
+// TruncateLeft.
+func WithWidthTruncateLeft() func(o *WidthOption) {
+	return func(o *WidthOption) {
+		o.truncate = sideLeft
+	}
+}
+
+// WithWidthTruncateMiddle makes TruncateWidth keep the beginning and the end of
+// the text, replacing the middle with the ellipsis.
+func WithWidthTruncateMiddle() func(o *WidthOption) {
+	return func(o *WidthOption) {
+		o.truncate = sideMiddle
+	}
+}
+
+// WithWidthPadLeft makes PadWidth add the padding before the text, aligning it
+// to the right.
+func WithWidthPadLeft() func(o *WidthOption) {
+	return func(o *WidthOption) {
+		o.pad = sideLeft
+	}
+}
+
+// WithWidthPadCenter makes PadWidth add the padding on both sides of the text,
+// centering it. Any odd column of padding goes on the right.
+func WithWidthPadCenter() func(o *WidthOption) {
+	return func(o *WidthOption) {
+		o.pad = sideMiddle
+	}
+}
+
+// DisplayWidth returns the number of columns s takes up in a terminal or other
+// fixed-width layout.
+//
+// Width is measured per grapheme cluster, so that an emoji ZWJ sequence,
+// a flag or a letter with combining marks counts once. Characters that are
+// wide or full width in the Unicode East Asian Width data, such as CJK
+// ideographs, Hangul and most emoji, count as two columns, as does a text
+// symbol followed by the emoji presentation selector U+FE0F. Control
+// characters, including tab and newline, and lone combining marks or joiners
+// count as zero. Everything else counts as one column.
+func DisplayWidth(s string, options ...func(*WidthOption)) int {
+	op := widthOptions(options)
+	w := 0
+
+	for s != "" {
+		n := firstGrapheme(s)
+		w += graphemeWidth(s[:n], op.ambiguousWide)
+		s = s[n:]
+	}
+
+	return w
+}
+
+// TruncateWidth shortens text to at most "width" columns, as measured by
+// DisplayWidth, replacing what was removed with "ellipsis". By default the end
+// of the text is removed, like TruncateRight; use WithWidthTruncateLeft or
+// WithWidthTruncateMiddle to remove the beginning or the middle instead. If
+// text already fits it is returned as is. If "width" is less than or equal to
+// the width of "ellipsis", then only as much of "ellipsis" as fits is
+// returned.
+//
+// Grapheme clusters are never split, so the result may be a column narrower
+// than "width" when a wide character does not fit; use PadWidth to fill it.
+func TruncateWidth(text string, width int, ellipsis string, options ...func(*WidthOption)) string {
+	op := widthOptions(options)
+	width = max(0, width)
+
+	gs := Graphemes(text)
+	ws, total := graphemeWidths(gs, op.ambiguousWide)
+
+	// if we are keeping the whole text, go ahead and return it
+	if total <= width {
+		return text
+	}
+
+	es := Graphemes(ellipsis)
+	ews, ew := graphemeWidths(es, op.ambiguousWide)
+
+	if ew >= width {
+		n, _ := fitStart(ews, width)
+
+		return strings.Join(es[:n], "")
+	}
+
+	avail := width - ew
+
+	switch op.truncate {
+	case sideLeft:
+		return ellipsis + strings.Join(gs[fitEnd(ws, avail):], "")
+	case sideMiddle:
+		// the beginning gets the odd column, and any the end cannot use
+		head, used := fitStart(ws, (avail+1)/2)
+		tail := fitEnd(ws, avail-used)
+
+		return strings.Join(gs[:head], "") + ellipsis + strings.Join(gs[tail:], "")
+	}
+
+	n, _ := fitStart(ws, avail)
+
+	return strings.Join(gs[:n], "") + ellipsis
+}
+
+// PadWidth pads text with spaces to "width" columns, as measured by
+// DisplayWidth, for aligning columns of text in a terminal. By default the
+// text is aligned to the left; use WithWidthPadLeft or WithWidthPadCenter to
+// align it to the right or center it. Text that is already at least "width"
+// columns wide is returned as is, so combine PadWidth with TruncateWidth for a
+// column of fixed width:
+//
+//	cell := strings.PadWidth(strings.TruncateWidth(name, 20, "…"), 20)
+func PadWidth(text string, width int, options ...func(*WidthOption)) string {
+	op := widthOptions(options)
+
+	pad := width - DisplayWidth(text, options...)
+	if pad <= 0 {
+		return text
+	}
+
+	switch op.pad {
+	case sideLeft:
+		return strings.Repeat(" ", pad) + text
+	case sideMiddle:
+		return strings.Repeat(" ", pad/2) + text + strings.Repeat(" ", pad-pad/2)
+	}
+
+	return text + strings.Repeat(" ", pad)
+}
+
+// widthOptions applies options to the defaults
+func widthOptions(options []func(*WidthOption)) WidthOption {
+	var op WidthOption
+
+	for _, o := range options {
+		o(&op)
+	}
+
+	return op
+}
+
+// graphemeWidth returns the number of columns a grapheme cluster takes up
+func graphemeWidth(g string, ambiguousWide bool) int {
+	r, _ := utf8.DecodeRuneInString(g)
+	p := graphemeProperty(r)
+
+	switch p & gcbMask {
+	case gcbControl, gcbCR, gcbLF, gcbExtend, gcbZWJ:
+		return 0
+	case gcbRegionalIndicator:
+		// a flag, or a lone regional indicator shown as a letter in a box
+		return 2
+	}
+
+	// emoji presentation of a character that is shown as text by default
+	if p&extPict != 0 && strings.ContainsRune(g, '\uFE0F') {
+		return 2
+	}
+
+	switch runeEAWidth(r) {
+	case eaWide:
+		return 2
+	case eaAmbiguous:
+		if ambiguousWide {
+			return 2
+		}
+	}
+
+	return 1
+}
+
+// runeEAWidth returns the East_Asian_Width of r
+func runeEAWidth(r rune) eaWidth {
+	if r < utf8.RuneSelf {
+		return eaNarrow
+	}
+
+	i := sort.Search(len(widthTable), func(i int) bool {
+		return widthTable[i].hi >= r
+	})
+
+	if i < len(widthTable) && widthTable[i].lo <= r {
+		return widthTable[i].width
+	}
+
+	return eaNarrow
+}
+
+// graphemeWidths returns the widths of the grapheme clusters gs, and their
+// total
+func graphemeWidths(gs []string, ambiguousWide bool) ([]int, int) {
+	ws := make([]int, len(gs))
+	total := 0
+
+	for i, g := range gs {
+		ws[i] = graphemeWidth(g, ambiguousWide)
+		total += ws[i]
+	}
+
+	return ws, total
+}
+
+// fitStart returns how many of the clusters with widths ws at the start
+// fit in width columns, and the columns they take up
+func fitStart(ws []int, width int) (int, int) {
+	used := 0
+
+	for i, w := range ws {
+		if used+w > width {
+			return i, used
+		}
+
+		used += w
+	}
+
+	return len(ws), used
+}
+
+// fitEnd returns the index of the first of the clusters with widths ws at the
+// end that fit in width columns
+func fitEnd(ws []int, width int) int {
+	used := 0
+
+	for i := len(ws) - 1; i >= 0; i-- {
+		if used+ws[i] > width {
+			return i + 1
+		}
+
+		used += ws[i]
+	}
+
+	return 0
+}
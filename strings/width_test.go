@@ -0,0 +1,112 @@
+package strings
+
+import "testing"
+
+func TestDisplayWidth(t *testing.T) {
+	testStructs := []struct {
+		Input         string
+		Expected      int
+		AmbiguousWide int
+	}{
+		{Input: "", Expected: 0, AmbiguousWide: 0},
+		{Input: "Hi there!", Expected: 9, AmbiguousWide: 9},
+		{Input: "日本語", Expected: 6, AmbiguousWide: 6},
+		{Input: "ｈｅｌｌｏ", Expected: 10, AmbiguousWide: 10},
+		{Input: "ﾊﾛｰ", Expected: 3, AmbiguousWide: 3},
+		{Input: "한국어", Expected: 6, AmbiguousWide: 6},
+		{Input: "한", Expected: 2, AmbiguousWide: 2},
+		{Input: "Zoe\u0308", Expected: 3, AmbiguousWide: 3},
+		{Input: "👩‍👩‍👧‍👦", Expected: 2, AmbiguousWide: 2},
+		{Input: "👍🏽", Expected: 2, AmbiguousWide: 2},
+		{Input: "🇺🇸🇬🇧", Expected: 4, AmbiguousWide: 4},
+		{Input: "❤", Expected: 1, AmbiguousWide: 1},
+		{Input: "❤️", Expected: 2, AmbiguousWide: 2},
+		{Input: "a\u200bb", Expected: 2, AmbiguousWide: 2},
+		{Input: "\u0301", Expected: 0, AmbiguousWide: 0},
+		{Input: "a\tb\n", Expected: 2, AmbiguousWide: 2},
+		{Input: "…", Expected: 1, AmbiguousWide: 2},
+		{Input: "Ωμεγα", Expected: 5, AmbiguousWide: 10},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := DisplayWidth(testStruct.Input); got != testStruct.Expected {
+			t.Errorf("Expected %d, got %d for %+q on iteration %d", testStruct.Expected, got, testStruct.Input, i)
+		}
+
+		if got := DisplayWidth(testStruct.Input, WithWidthAmbiguousWide()); got != testStruct.AmbiguousWide {
+			t.Errorf("Expected %d, got %d for %+q with ambiguous wide on iteration %d", testStruct.AmbiguousWide, got, testStruct.Input, i)
+		}
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	testStructs := []struct {
+		Input    string
+		Width    int
+		Ellipsis string
+		Options  []func(*WidthOption)
+		Expected string
+	}{
+		{Input: "Hi there!", Width: -1, Ellipsis: "…", Expected: ""},
+		{Input: "Hi there!", Width: 0, Ellipsis: "…", Expected: ""},
+		{Input: "Hi there!", Width: 1, Ellipsis: "…", Expected: "…"},
+		{Input: "Hi there!", Width: 2, Ellipsis: "...", Expected: ".."},
+		{Input: "Hi there!", Width: 5, Ellipsis: "…", Expected: "Hi t…"},
+		{Input: "Hi there!", Width: 5, Ellipsis: "...", Expected: "Hi..."},
+		{Input: "Hi there!", Width: 9, Ellipsis: "…", Expected: "Hi there!"},
+		{Input: "Hi there!", Width: 64, Ellipsis: "…", Expected: "Hi there!"},
+		{Input: "Hi there!", Width: 5, Ellipsis: "…", Options: []func(*WidthOption){WithWidthTruncateLeft()}, Expected: "…ere!"},
+		{Input: "Hi there!", Width: 5, Ellipsis: "…", Options: []func(*WidthOption){WithWidthTruncateMiddle()}, Expected: "Hi…e!"},
+		{Input: "Hi there!", Width: 6, Ellipsis: "…", Options: []func(*WidthOption){WithWidthTruncateMiddle()}, Expected: "Hi …e!"},
+		{Input: "Hi there!", Width: 5, Ellipsis: "…", Options: []func(*WidthOption){WithWidthAmbiguousWide()}, Expected: "Hi …"},
+
+		{Input: "日本語のテキスト", Width: 7, Ellipsis: "…", Expected: "日本語…"},
+		{Input: "日本語のテキスト", Width: 8, Ellipsis: "…", Expected: "日本語…"},
+		{Input: "日本語のテキスト", Width: 8, Ellipsis: "…", Options: []func(*WidthOption){WithWidthAmbiguousWide()}, Expected: "日本語…"},
+		{Input: "日本語のテキスト", Width: 7, Ellipsis: "…", Options: []func(*WidthOption){WithWidthTruncateLeft()}, Expected: "…キスト"},
+		{Input: "日本語のテキスト", Width: 9, Ellipsis: "…", Options: []func(*WidthOption){WithWidthTruncateMiddle()}, Expected: "日本…スト"},
+		{Input: "日本語", Width: 1, Ellipsis: "…", Expected: "…"},
+		{Input: "日本語", Width: 1, Ellipsis: "……", Options: []func(*WidthOption){WithWidthAmbiguousWide()}, Expected: ""},
+
+		{Input: "👩‍👩‍👧‍👦👩‍👩‍👧‍👦👩‍👩‍👧‍👦", Width: 5, Ellipsis: "…", Expected: "👩‍👩‍👧‍👦👩‍👩‍👧‍👦…"},
+		{Input: "🇺🇸🇬🇧🇫🇷", Width: 4, Ellipsis: "…", Expected: "🇺🇸…"},
+		{Input: "Zoe\u0308 Saldan\u0303a", Width: 4, Ellipsis: "…", Expected: "Zoe\u0308…"},
+	}
+
+	for i, testStruct := range testStructs {
+		got := TruncateWidth(testStruct.Input, testStruct.Width, testStruct.Ellipsis, testStruct.Options...)
+		if got != testStruct.Expected {
+			t.Errorf("Expected %+q, got %+q on iteration %d", testStruct.Expected, got, i)
+		}
+
+		if w := DisplayWidth(got, testStruct.Options...); w > max(0, testStruct.Width) {
+			t.Errorf("Expected width at most %d, got %d on iteration %d", testStruct.Width, w, i)
+		}
+	}
+}
+
+func TestPadWidth(t *testing.T) {
+	testStructs := []struct {
+		Input    string
+		Width    int
+		Options  []func(*WidthOption)
+		Expected string
+	}{
+		{Input: "abc", Width: 6, Expected: "abc   "},
+		{Input: "abc", Width: 6, Options: []func(*WidthOption){WithWidthPadLeft()}, Expected: "   abc"},
+		{Input: "abc", Width: 6, Options: []func(*WidthOption){WithWidthPadCenter()}, Expected: " abc  "},
+		{Input: "日本", Width: 6, Expected: "日本  "},
+		{Input: "日本", Width: 6, Options: []func(*WidthOption){WithWidthPadLeft()}, Expected: "  日本"},
+		{Input: "…", Width: 3, Expected: "…  "},
+		{Input: "…", Width: 3, Options: []func(*WidthOption){WithWidthAmbiguousWide()}, Expected: "… "},
+		{Input: "日本語", Width: 4, Expected: "日本語"},
+		{Input: "", Width: 2, Expected: "  "},
+		{Input: "abc", Width: -1, Expected: "abc"},
+	}
+
+	for i, testStruct := range testStructs {
+		if got := PadWidth(testStruct.Input, testStruct.Width, testStruct.Options...); got != testStruct.Expected {
+			t.Errorf("Expected %+q, got %+q on iteration %d", testStruct.Expected, got, i)
+		}
+	}
+}